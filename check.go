@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// runCheck regenerates the files in memory, and returns an error listing those that are missing or out of date,
+// without writing anything.
+func runCheck(ctx context.Context) error {
+	stale, result, err := sfgen.Check(ctx, flagOptions)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range result.Warnings() {
+		log.Printf("warning: %s", w)
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stale {
+		path := s.Path
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			path = rel
+		}
+		log.Printf("%s: %s", path, s.Reason)
+	}
+
+	if len(stale) == 1 {
+		return fmt.Errorf("1 generated file is out of date, run go generate")
+	}
+	return fmt.Errorf("%d generated files are out of date, run go generate", len(stale))
+}
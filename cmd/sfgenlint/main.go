@@ -0,0 +1,14 @@
+// Command sfgenlint reports the structs whose go-sfgen generated files are out of date. It is run by go vet:
+//
+//	go install github.com/rad12000/go-sfgen/cmd/sfgenlint@latest
+//	go vet -vettool=$(which sfgenlint) ./...
+package main
+
+import (
+	"github.com/rad12000/go-sfgen/pkg/sfgenlint"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(sfgenlint.Analyzer)
+}
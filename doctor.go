@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"golang.org/x/tools/go/packages"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// doctorCheck is the outcome of a check of the doctor command.
+type doctorCheck struct {
+	name string
+	// detail describes what was found, e.g. the toolchain version, or the problem if err is set.
+	detail string
+	err    error
+	// hint is the action resolving a failed check.
+	hint string
+}
+
+// runDoctor implements the doctor command, checking the environment go-sfgen runs in for the problems that commonly
+// fail generation: a missing or outdated toolchain, running outside a module, packages that fail to load, directives
+// that fail to parse, and output directories that cannot be written to.
+func runDoctor(ctx context.Context, args []string) error {
+	flagSet := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "Usage: go-sfgen doctor [packages]\n\nPackages default to the current directory.")
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	patterns := flagSet.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	toolchain, env := checkToolchain(wd)
+	module := checkModule(wd, env)
+	checks := []doctorCheck{toolchain, module}
+	if toolchain.err == nil && module.err == nil {
+		checks = append(checks, checkPackages(ctx, patterns))
+	}
+	checks = append(checks, checkDirectives(patterns)...)
+
+	failed := 0
+	for _, c := range checks {
+		status := "ok"
+		if c.err != nil {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%-5s %s: %s\n", status, c.name, c.detail)
+		if c.err != nil {
+			fmt.Printf("      %v\n", c.err)
+			if c.hint != "" {
+				fmt.Printf("      hint: %s\n", c.hint)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkToolchain checks that the go command is in PATH and at least as recent as the go version required by the module
+// containing wd. It returns the environment of the go command, used by the other checks.
+func checkToolchain(wd string) (doctorCheck, map[string]string) {
+	check := doctorCheck{name: "toolchain"}
+	vars := []string{"GOVERSION", "GOMOD", "GOFLAGS", "GOTOOLCHAIN"}
+	out, err := exec.Command("go", append([]string{"env"}, vars...)...).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		check.detail, check.err = "go not found", err
+		check.hint = "install Go from https://go.dev/dl and add its bin directory to PATH"
+		return check, nil
+	}
+	if err != nil {
+		check.detail, check.err = "go env failed", err
+		check.hint = "run go env to see the error, e.g. an invalid GOFLAGS or go.env setting"
+		return check, nil
+	}
+
+	env := make(map[string]string, len(vars))
+	for i, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if i < len(vars) {
+			env[vars[i]] = strings.TrimSpace(line)
+		}
+	}
+	check.detail = env["GOVERSION"]
+
+	if required := moduleGoVersion(env["GOMOD"]); required != "" && goVersionBelow(env["GOVERSION"], required) {
+		check.err = fmt.Errorf("the module requires go %s", required)
+		check.hint = fmt.Sprintf("install go %s or later, or set GOTOOLCHAIN=auto to download it", required)
+	}
+	return check, env
+}
+
+// checkModule checks that wd is within a module, as packages are loaded through the go command in module mode.
+func checkModule(wd string, env map[string]string) doctorCheck {
+	check := doctorCheck{name: "module"}
+	if gomod := env["GOMOD"]; env != nil && (gomod == "" || gomod == os.DevNull) {
+		check.detail, check.err = "not within a module", errors.New("packages cannot be loaded outside a module")
+		check.hint = "run go-sfgen from within a module, or create one with go mod init"
+		return check
+	}
+
+	root, path, err := findModule(wd)
+	if err != nil {
+		check.detail, check.err = "no go.mod found", err
+		check.hint = "run go-sfgen from within a module, or create one with go mod init"
+		return check
+	}
+	check.detail = fmt.Sprintf("%s at %s", path, root)
+
+	if _, err := os.Stat(filepath.Join(root, "vendor", "modules.txt")); err == nil && strings.Contains(env["GOFLAGS"], "-mod=mod") {
+		check.err = errors.New("the module is vendored, but GOFLAGS holds -mod=mod")
+		check.hint = "remove -mod=mod from GOFLAGS, or pass --mod-mode mod explicitly to go-sfgen"
+	}
+	return check
+}
+
+// checkPackages checks that the packages matching patterns load without errors, as the structs are read from them.
+func checkPackages(ctx context.Context, patterns []string) doctorCheck {
+	check := doctorCheck{name: "packages"}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedTypes,
+	}, patterns...)
+	if err != nil {
+		check.detail, check.err = "failed to load "+strings.Join(patterns, " "), err
+		check.hint = "run go list " + strings.Join(patterns, " ") + " to see the full error"
+		return check
+	}
+
+	var problems []string
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			problems = append(problems, e.Error())
+		}
+	}
+	paths := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		paths[i] = pkg.PkgPath
+	}
+	check.detail = "loaded " + strings.Join(paths, ", ")
+	if len(paths) > 3 {
+		check.detail = fmt.Sprintf("loaded %d packages", len(paths))
+	}
+	if len(problems) > 0 {
+		check.detail = fmt.Sprintf("%d errors loading %d packages", len(problems), len(pkgs))
+		check.err = errors.New(problems[0])
+		check.hint = "fix the errors reported by go build " + strings.Join(patterns, " ") + ", or run go mod tidy for missing dependencies"
+	}
+	return check
+}
+
+// checkDirectives checks that the go-sfgen directives of the Go files of patterns parse, and that their output
+// directories may be written to. It returns one check per directive that fails to parse, and one per output directory.
+func checkDirectives(patterns []string) []doctorCheck {
+	var (
+		failures   []doctorCheck
+		outputDirs = make(map[string][]string)
+		count      int
+	)
+	for _, pattern := range patterns {
+		files, err := directiveFiles(pattern)
+		if err != nil {
+			return append(failures, doctorCheck{name: "directives", detail: "failed to list " + pattern, err: err})
+		}
+
+		for _, file := range files {
+			directives, err := sfgen.FileDirectives(file)
+			if err != nil {
+				failures = append(failures, doctorCheck{name: "directives", detail: "failed to read " + file, err: err})
+				continue
+			}
+
+			for _, d := range directives {
+				count++
+				position := fmt.Sprintf("%s:%d", d.Path, d.Line)
+				flagOptions, err := d.Options()
+				if err != nil {
+					failures = append(failures, doctorCheck{name: "directive", detail: position, err: err,
+						hint: "run go-sfgen --help for the valid flags"})
+					continue
+				}
+
+				for _, f := range flagOptions {
+					if f.PerPackage || f.GeneratedRoot != "" {
+						continue // the directories are only known once the packages are loaded
+					}
+					outputDirs[f.OutputDir] = append(outputDirs[f.OutputDir], position)
+				}
+			}
+		}
+	}
+	checks := append([]doctorCheck{{name: "directives", detail: fmt.Sprintf("%d found", count)}}, failures...)
+
+	dirs := make([]string, 0, len(outputDirs))
+	for dir := range outputDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		check := doctorCheck{name: "output", detail: dir}
+		if err := checkWritable(dir); err != nil {
+			check.err = err
+			check.hint = fmt.Sprintf("grant write permission on %s, used by %s", dir, strings.Join(outputDirs[dir], ", "))
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// checkWritable checks that a file can be created in dir, or, if it does not exist yet, in the closest existing
+// directory above it, where it would be created.
+func checkWritable(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if errors.Is(err, os.ErrNotExist) && filepath.Dir(dir) != dir {
+			dir = filepath.Dir(dir)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		break
+	}
+
+	file, err := os.CreateTemp(dir, ".sfgen-doctor-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	_ = file.Close()
+	return os.Remove(name)
+}
+
+// moduleGoVersion returns the go version required by the go.mod file at path, or an empty string if there is none.
+func moduleGoVersion(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 2 && fields[0] == "go" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// goVersionBelow reports whether the toolchain version current, e.g. go1.21.5, is older than the version required, e.g.
+// 1.22 or 1.22.1. Development versions of the toolchain are never older.
+func goVersionBelow(current, required string) bool {
+	if strings.HasPrefix(current, "devel") {
+		return false
+	}
+
+	have, want := strings.Split(strings.TrimPrefix(current, "go"), "."), strings.Split(required, ".")
+	for i := range want {
+		var h, w int
+		if i < len(have) {
+			h, _ = strconv.Atoi(strings.TrimRightFunc(have[i], func(r rune) bool { return r < '0' || r > '9' }))
+		}
+		w, _ = strconv.Atoi(strings.TrimRightFunc(want[i], func(r rune) bool { return r < '0' || r > '9' }))
+		if h != w {
+			return h < w
+		}
+	}
+	return false
+}
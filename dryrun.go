@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"go/scanner"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// ANSI escape sequences used to colorize --dry-run output.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// writeDryRun writes the files that would be generated to stdout for review, rather than to the file system. When
+// stdout is a terminal, the output is colorized unless --no-color is set, or the NO_COLOR environment variable is.
+func writeDryRun(ctx context.Context) error {
+	result, err := sfgen.Generate(ctx, flagOptions)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range result.Warnings() {
+		log.Printf("warning: %s", w)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	color := !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+	buf := new(bytes.Buffer)
+	for _, file := range result.Files {
+		path := file.Path
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			path = rel
+		}
+
+		content := file.Content
+		if color {
+			buf.WriteString(fmt.Sprintf("%s==> %s <==%s\n", ansiBold, path, ansiReset))
+			if file.Package != "" {
+				content = colorizeGo(content)
+			}
+		} else {
+			buf.WriteString(fmt.Sprintf("==> %s <==\n", path))
+		}
+
+		buf.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+	}
+
+	if _, err := buf.WriteTo(os.Stdout); err != nil {
+		return fmt.Errorf("failed to write dry run: %w", err)
+	}
+	return nil
+}
+
+// colorizeGo returns src with the names declared by its const declarations, its literal values, and its comments
+// colorized, so names and values are easy to tell apart in long const blocks.
+func colorizeGo(src []byte) []byte {
+	var (
+		s    scanner.Scanner
+		fset = token.NewFileSet()
+		file = fset.AddFile("", fset.Base(), len(src))
+		out  bytes.Buffer
+		last int
+
+		inConst, inGroup, expectName bool
+		// depth is the current nesting of parentheses, and groupDepth that of the const group being scanned.
+		depth, groupDepth int
+	)
+
+	s.Init(file, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		var color string
+		switch tok {
+		case token.CONST:
+			inConst, expectName = true, true
+		case token.LPAREN:
+			depth++
+			if inConst && expectName && !inGroup {
+				inGroup, groupDepth = true, depth
+			}
+		case token.RPAREN:
+			if inGroup && depth == groupDepth {
+				inConst, inGroup, expectName = false, false, false
+			}
+			depth--
+		case token.SEMICOLON:
+			expectName = inGroup
+			inConst = inGroup
+		case token.IDENT:
+			if expectName {
+				color, expectName = ansiCyan, false
+			}
+		case token.STRING, token.CHAR, token.INT, token.FLOAT:
+			color = ansiGreen
+		case token.COMMENT:
+			color = ansiDim
+		}
+
+		// Only tokens taken verbatim from the source are colorized, e.g. not automatically inserted semicolons.
+		offset := file.Offset(pos)
+		if color == "" || lit == "" || offset+len(lit) > len(src) || string(src[offset:offset+len(lit)]) != lit {
+			continue
+		}
+
+		out.Write(src[last:offset])
+		out.WriteString(color + lit + ansiReset)
+		last = offset + len(lit)
+	}
+
+	out.Write(src[last:])
+	return out.Bytes()
+}
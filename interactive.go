@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// interactiveSession holds the selection of an --interactive run. The selection is kept as command line flags, so
+// the directive reproducing it is the flags themselves.
+type interactiveSession struct {
+	// args are the flags of the command, without the boolean flags and --skip-fields, which are tracked below.
+	args []string
+	// boolFlags holds the value of every boolean flag of a generate command.
+	boolFlags map[string]bool
+	// defaultBoolFlags holds the values boolFlags take without being provided, see [sfgen.DefaultsFile].
+	defaultBoolFlags map[string]bool
+	// fields are the fields of the --struct, in the order they are generated.
+	fields  []string
+	skipped map[string]bool
+	// defaultSkipped is true if the defaults provide --skip-fields, which are then overridden even when empty.
+	defaultSkipped bool
+}
+
+// runInteractive previews the constants generated for the command line flags in a table, letting fields and boolean
+// flags be toggled before anything is written. The //go:generate directive reproducing the selection is printed on
+// exit.
+func runInteractive(ctx context.Context, args []string) error {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return errors.New("--interactive requires a terminal")
+	}
+
+	session, err := newInteractiveSession(ctx, sfgen.CommandArgs(args))
+	if err != nil {
+		return err
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for {
+		session.preview(ctx)
+		fmt.Print("toggle> ")
+
+		var line string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case l, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			line = l
+		}
+
+		for _, input := range strings.Fields(line) {
+			switch input {
+			case "w", "write":
+				opts, err := session.options()
+				if err != nil {
+					return err
+				}
+
+				if err := sfgen.Run(ctx, []sfgen.FlagOptions{opts}); err != nil {
+					return err
+				}
+				fmt.Println(session.directive())
+				return nil
+			case "q", "quit":
+				fmt.Println(session.directive())
+				return nil
+			default:
+				session.toggle(input)
+			}
+		}
+	}
+}
+
+// newInteractiveSession returns the session for the flags of a single generate command.
+func newInteractiveSession(ctx context.Context, args []string) (*interactiveSession, error) {
+	session := &interactiveSession{
+		boolFlags:        make(map[string]bool),
+		defaultBoolFlags: make(map[string]bool),
+		skipped:          make(map[string]bool),
+	}
+
+	defaults, err := sfgen.ReadDefaults(".")
+	if err != nil {
+		return nil, err
+	}
+
+	defaultOpts, defaultFlagSet, err := parseFlagSet(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s flags: %w", sfgen.DefaultsFile, err)
+	}
+
+	opts, flagSet, err := parseFlagSet(args)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultFlagSet.VisitAll(func(f *flag.Flag) {
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() {
+			session.defaultBoolFlags[f.Name] = f.Value.String() == "true"
+			session.boolFlags[f.Name] = session.defaultBoolFlags[f.Name]
+		}
+	})
+
+	skipFields, skipProvided := defaultOpts.SkipFields, false
+	flagSet.Visit(func(f *flag.Flag) {
+		if _, ok := session.boolFlags[f.Name]; ok {
+			session.boolFlags[f.Name] = f.Value.String() == "true"
+		}
+		skipProvided = skipProvided || f.Name == "skip-fields"
+	})
+	if skipProvided {
+		skipFields = opts.SkipFields
+	}
+
+	session.defaultSkipped = len(defaultOpts.SkipFields) > 0
+	for _, name := range skipFields {
+		session.skipped[name] = true
+	}
+
+	for i := 0; i < len(args); i++ {
+		name, _, _ := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if _, ok := session.boolFlags[name]; ok && strings.HasPrefix(args[i], "-") {
+			continue
+		}
+
+		if name == "skip-fields" && strings.HasPrefix(args[i], "-") {
+			if !strings.Contains(args[i], "=") {
+				i++
+			}
+			continue
+		}
+
+		session.args = append(session.args, args[i])
+	}
+
+	// The fields are listed with nothing skipped, so skipped fields can be toggled back on.
+	skipped := session.skipped
+	session.skipped = nil
+	result, err := session.generate(ctx)
+	session.skipped = skipped
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range generatedFields(result) {
+		if !seen[field.Field] {
+			seen[field.Field] = true
+			session.fields = append(session.fields, field.Field)
+		}
+	}
+
+	return session, nil
+}
+
+// parseFlagSet parses args into the flags of a generate command, without validating them.
+func parseFlagSet(args []string) (sfgen.FlagOptions, *flag.FlagSet, error) {
+	var (
+		opts    sfgen.FlagOptions
+		flagSet = flag.NewFlagSet("sfgen", flag.ContinueOnError)
+	)
+
+	opts.RegisterFlags(flagSet)
+	return opts, flagSet, flagSet.Parse(args)
+}
+
+// toggle flips the field with the 1-based index input, or the boolean flag named input.
+func (s *interactiveSession) toggle(input string) {
+	if i, err := strconv.Atoi(input); err == nil {
+		if i < 1 || i > len(s.fields) {
+			fmt.Printf("no field %d\n", i)
+			return
+		}
+
+		s.skipped[s.fields[i-1]] = !s.skipped[s.fields[i-1]]
+		return
+	}
+
+	name := strings.TrimLeft(input, "-")
+	if _, ok := s.boolFlags[name]; !ok {
+		fmt.Printf("%s is neither a field number nor a boolean flag\n", input)
+		return
+	}
+	s.boolFlags[name] = !s.boolFlags[name]
+}
+
+// preview prints the fields of the current selection along with the constants generated for them.
+func (s *interactiveSession) preview(ctx context.Context) {
+	result, err := s.generate(ctx)
+	if err != nil {
+		fmt.Printf("\nerror: %v\n", err)
+	}
+
+	generated := make(map[string]sfgen.GeneratedField)
+	for _, field := range generatedFields(result) {
+		if _, ok := generated[field.Field]; !ok {
+			generated[field.Field] = field
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\n#\tFIELD\tCONST\tVALUE")
+	for i, name := range s.fields {
+		field, ok := generated[name]
+		switch {
+		case s.skipped[name]:
+			fmt.Fprintf(w, "%d\t%s\t(skipped)\t\n", i+1, name)
+		case ok:
+			fmt.Fprintf(w, "%d\t%s\t%s\t%q\n", i+1, name, field.Const, field.Value)
+		default:
+			fmt.Fprintf(w, "%d\t%s\t-\t\n", i+1, name)
+		}
+	}
+	w.Flush()
+
+	if result != nil {
+		for _, warning := range result.Warnings() {
+			fmt.Printf("warning: %s\n", warning)
+		}
+	}
+
+	fmt.Printf("\nenabled flags: %s\n", strings.Join(s.enabledFlags(), ", "))
+	fmt.Println("Enter field numbers or boolean flag names, e.g. export, to toggle them. w writes the files, q quits without writing.")
+}
+
+// generate returns the files generated for the current selection, without writing them.
+func (s *interactiveSession) generate(ctx context.Context) (*sfgen.Result, error) {
+	opts, err := s.options()
+	if err != nil {
+		return nil, err
+	}
+	return sfgen.Generate(ctx, []sfgen.FlagOptions{opts})
+}
+
+// options returns the generate command of the current selection.
+func (s *interactiveSession) options() (sfgen.FlagOptions, error) {
+	return parseTopLevelOptions(s.commandLine())
+}
+
+// commandLine returns the flags of the current selection.
+func (s *interactiveSession) commandLine() []string {
+	args := append([]string(nil), s.args...)
+	for _, name := range s.changedFlags() {
+		if s.boolFlags[name] {
+			args = append(args, "--"+name)
+		} else {
+			args = append(args, "--"+name+"=false")
+		}
+	}
+
+	var skipped []string
+	for name, skip := range s.skipped {
+		if skip {
+			skipped = append(skipped, name)
+		}
+	}
+	if len(skipped) > 0 || s.defaultSkipped {
+		sort.Strings(skipped)
+		args = append(args, "--skip-fields="+strings.Join(skipped, ","))
+	}
+
+	return args
+}
+
+// enabledFlags returns the names of the boolean flags that are currently set, sorted.
+func (s *interactiveSession) enabledFlags() []string {
+	var enabled []string
+	for name, value := range s.boolFlags {
+		if value {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
+
+// changedFlags returns the names of the boolean flags whose values differ from their defaults, sorted.
+func (s *interactiveSession) changedFlags() []string {
+	var changed []string
+	for name, value := range s.boolFlags {
+		if value != s.defaultBoolFlags[name] {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// directive returns the //go:generate directive reproducing the current selection.
+func (s *interactiveSession) directive() string {
+	args := s.commandLine()
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'`\\") {
+			args[i] = strconv.Quote(arg)
+		}
+	}
+	return "//go:generate go-sfgen " + strings.Join(args, " ")
+}
+
+// generatedFields returns the constants generated for the Go files of result, which is nil if generation failed.
+func generatedFields(result *sfgen.Result) []sfgen.GeneratedField {
+	if result == nil {
+		return nil
+	}
+
+	var fields []sfgen.GeneratedField
+	for _, file := range result.Files {
+		for _, target := range file.Targets {
+			fields = append(fields, target.Fields...)
+		}
+	}
+	return fields
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
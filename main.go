@@ -5,231 +5,472 @@ Below is a list of flags that can be used with the //go:generate directive. Alon
 may be used to drive behavior for specific fields.
 `sfgen:"-"` results in skipping the field for code generation. Any other value will be used as the value of the generated
 constant for that field. E.g. `type Person struct { Name string `sfgen:"name"` }` results in `const fieldName = "name"`
+Former values of a field may be listed with was options, e.g. `sfgen:"full_name,was:name was:fullname"`, generating
+deprecated constants for each of them to ease renaming a field's tag.
+
+Default flags for the generate commands of a directory, e.g. --export or --style conventions, may be listed in a .sfgen
+file in that directory. They are merged under the flags of each directive, which win when both provide a flag.
 
 Usage:
 
 	go-sfgen --struct [struct_name] [flags]
+	go-sfgen report [--json] [--usages] [packages]
+	go-sfgen rename --struct [package.]Struct --field Old=New [--field Old=New...]
+	go-sfgen doctor [packages]
+
+The report command prints every struct generated from by the go-sfgen directives of the packages, ./... by default,
+along with its output files, its number of generated constants, and whether the outputs are up to date, as a table
+or as JSON. Constants of fields that no longer exist are listed as removed, and with --usages the module is searched
+for references to them, which break once the struct is regenerated.
+
+The rename command renames fields of a struct along with every reference to them across the module, regenerates the
+go-sfgen directives generating from the struct, and updates every reference to the constants generated from the
+renamed fields to their new names.
+
+The doctor command checks the environment go-sfgen runs in, reporting the go toolchain and whether it satisfies the
+module, whether the packages, the current directory by default, load, whether their go-sfgen directives parse, and
+whether their output directories may be written to, with a hint to resolve each failed check.
 
 Flags are:
 
+	-accessors
+	      If true, Get[type] and Set[type] methods of the struct getting and setting the field a constant was generated from are generated,
+	      e.g. func (u *User) GetField(field Field) any, as a switch over the constants rather than through reflection. The output must belong
+	      to the package of the struct
+	-add-fields value
+	      A comma separated list of structs of the --src-dir package whose fields are generated along with those of the --struct,
+	      or of their fields, of the form Struct.Field, e.g. Account.ID. May be repeated. The fields are parsed with the same flags as those of the --struct,
+	      including --skip-fields, except for the fields listed explicitly
+	-all-structs
+	      If true, constants are generated for every named struct type of the --src-dir packages in place of a single --struct.
+	      The constants of each struct are prefixed with its name, as with --include-struct-name, and written to their own file unless --out-file is provided
+	-ascii-identifiers
+	      If true, non-ASCII characters are transliterated when building generated identifiers. Constant values are preserved verbatim
+	-bind
+	      If true, a [prefix]Bind function setting the fields of the struct from url.Values keyed by the constants, e.g. the query parameters
+	      of an HTTP request with --tag query, is generated. Fields of string, bool, and numeric kinds, and slices of them, are bound without reflection
+	-bindings string
+	      If provided, constants are generated for each request binding tag of this web framework used by the struct, in place of --tag,
+	      e.g. uriField, formField, and headerField for Gin. Valid options are: gin, for the uri, form, and header tags, and echo, for the param,
+	      query, form, and header tags. Fields without the tag are skipped
+	-builder
+	      If true, a [struct]Builder type with a fluent With[field] setter for each field the constants are generated from is generated,
+	      e.g. NewUserBuilder().WithEmail(email).Build()
+	-chain value
+	      A command run in the --out-dir once the generated files are written, e.g. 'mockgen -source=$GOFILE -destination=mocks.go',
+	      so generators consuming the constants regenerate in the same pass rather than relying on the order of go:generate directives.
+	      May be repeated, and commands are run in order. GOFILE and GOPACKAGE are set to the output file and its package, as by go generate.
+	      Not run by --dry-run, --emit-bundle, or --check
+	-check
+	      if true, the generated files are regenerated in memory, and go-sfgen exits with an error listing those that are missing or out of date, without writing anything
+	-config string
+	      a file listing one set of top level flags per line, allowing multiple generate commands to be specified
+	-dry-run
+	      if true, the generated files are written to stdout for review rather than to their paths.
+	      When stdout is a terminal, the names and values of generated constants are colorized
+	-emit value
+	      Writes the generated constants in another language to a path, in the form lang:path, e.g. ts:web/fields.ts.
+	      May be repeated. Valid languages are: ts, md, json, txt. Commands sharing a path are written to the same file.
+	      json and txt assets must be within --out-dir, where a [asset]_[lang]_generated.go file embedding them and providing an accessor is written
+	-emit-bundle
+	      if true, the generated files are written to stdout as a txtar archive rather than to their paths
+	-expand-oneofs
+	      If true, protobuf oneof fields are replaced by the fields of each of their generated case wrappers
 	-export
 	      If true, the generated constants will be exported
+	-field-index
+	      If true, a [prefix]Index map from each constant to the reflect index path of its field is generated, for use with reflect.Value.FieldByIndex
+	-field-meta
+	      If true, a [prefix]Meta map from each constant to a [prefix]Info describing its field is generated, holding the Go field name,
+	      the value of every tag of the field, and its type, e.g. for admin UIs and query validators
+	-fold-lookup
+	      If true, a [type]FromValueFold function returning the constant holding a value regardless of case, e.g. of query parameters or
+	      CSV headers, is generated along with a map of the lower-cased values
+	-fold-markers string
+	      If provided, the code generated by each command is wrapped in markers editors can collapse it with. Valid options are:
+	      region, folded by VS Code and GoLand, and editor-fold, folded by GoLand
+	-functional-options
+	      If true, a New[struct] constructor taking functional options is generated, along with a With[field] option for each exported field
+	      the constants are generated from, e.g. NewUser(WithEmail(email))
 	-gen value
 	      accepts all the top level flags in a string, allowing multiple generate commands to be specified
+	-gen-set
+	      If true, a map backed [prefix]Set type of the constants is generated, along with a New[prefix]Set constructor and its Contains, Add,
+	      and Delete methods. Requires a style other than generic
+	-generated-root string
+	      If provided, the code generated from each struct is written below this directory, to the path of the package declaring it within
+	      its module, and belongs to a package of the same name, in place of --out-dir and --out-pkg. E.g. generated/internal/models for internal/models
+	-gitattributes
+	      If true, the generated Go files are marked linguist-generated in the .gitattributes file of their directory, which is created or
+	      appended to as needed, so code review tools collapse them. --check reports missing entries
+	-gostring
+	      If true, a GoString method returning the name of the constant holding the value is generated for the typed, generic, int, and bitmask styles, so %#v prints it
+	-guard-test
+	      If true, a [out-file]_guard_test.go file asserting the values of the generated constants is written alongside them.
+	      The file is only written when absent, so renamed values fail its test until it is deleted and regenerated
+	-identifier-pattern string
+	      If provided, generation fails if any package level identifier generated, e.g. a constant, type, or function, does not match this regex.
+	      E.g. '^(Col|col)[A-Z]' enforces a naming policy when set in a shared .sfgen or config file
 	-include-struct-name
 	      If true, the generated constants will be prefixed with the source struct name
 	-include-unexported-fields
 	      If true, the generated constants will include fields that are not exported on the struct
+	-index-consts
+	      If true, a [prefix]Index[field] int constant holding the reflect field index of each field declared directly in the struct is
+	      generated, for use with reflect.Value.Field, along with a [prefix]Indices map from each constant to its index
+	-interface string
+	      If provided, an interface with this name will be generated and implemented by the generated type.
+	      Requires the typed, generic, int, or bitmask style. All commands sharing an interface must write to the same output file
+	-interactive
+	      if true, the constants of a single generate command are previewed in a table, where fields and boolean flags can be toggled before writing.
+	      The //go:generate directive reproducing the selection is printed on exit. Requires a terminal
+	-is-valid
+	      If true, an IsValid method reporting whether the value is held by one of the constants is generated for the typed, generic, int, and bitmask styles
 	-iter
-	      if true, an All() method will be generated for the type, which returns an array of all the values generated
+	      if true, an All() method will be generated for the type, which returns an array of all the values generated, along with Names() and Values() returning the constant names and values as strings
+	-iter-style string
+	      If provided, the return type of the All(), Names(), and Values() methods generated with --iter. Valid options are: array, the default, returning an
+	      array of the values, typed-array, returning an array of the constants, and seq, returning an iter.Seq of the constants, which requires Go 1.23
+	-json-marshaler
+	      If true, MarshalJSON and UnmarshalJSON methods rejecting values no constant holds are generated for the typed, generic, int, and
+	      bitmask styles, so the type may be used directly in API request structs. The bits of the bitmask style are marshaled as an array
+	-json-pointer
+	      If true, a [const]Pointer constant holding the RFC 6901 JSON Pointer of each field, e.g. /address, is generated for use with JSON Patch.
+	      The fields of struct fields are followed, e.g. /address/city
+	-lenient-tags
+	      If true, the --tag is extracted from malformed struct tags that fail strict parsing, rather than falling back to the field name
+	-link-tag value
+	      Generates a method of the generated type returning the value of another tag of the field of each constant, in the form
+	      tag[:Method], e.g. db:Column, linking the representations of a field. May be repeated. The method is named after the tag by default,
+	      and returns the field name for fields without the tag. Used with the typed, generic, int, and bitmask styles
+	-managed-region
+	      If true, the generated code is placed between the "// sfgen:region begin" and "// sfgen:region end" lines of the existing
+	      --out-file, leaving the rest of the file, e.g. maintained by hand or by another generator, untouched
+	-map-tags value
+	      Generates a function translating the constants of the --tag into those generated from the same struct with another tag,
+	      in the form from:to, e.g. json:db generating JSONToDB(c JSONField) (DBField, bool), backed by a map. The from tag must be the --tag,
+	      and the constants of the to tag must be generated by another command with the same style and naming flags. May be repeated
+	-max-file-lines int
+	      If provided, an --out-file longer than this many lines is split into numbered files of the same package, e.g.
+	      user_generated_2.go, each holding the code of whole commands
+	-min-go string
+	      If provided, the command is skipped with a notice when the Go toolchain is older than this version, e.g. 1.23
+	-mirror-export
+	      If true, aliases of the generated constants using the opposite casing of --export will also be generated
+	-mod-mode string
+	      The -mod build flag used when loading the --src-dir package. Valid options are: readonly, vendor, mod
+	-modfile string
+	      If provided, the -modfile build flag used when loading the --src-dir package, e.g. tools.go.mod, for modules resolving their
+	      dependencies from an alternate go.mod file. GOFLAGS, e.g. GOFLAGS=-modfile=tools.go.mod, are respected as well, and are overridden by this flag
+	-namespace string
+	      If provided, the generated constants will also be grouped under a package level var with this name, nested by struct name.
+	      All commands sharing a namespace must write to the same output file
+	-no-format
+	      If true, generated code that fails to format is written unformatted with a warning rather than failing, to help diagnose bugs
+	-no-color
+	      if true, --dry-run output is not colorized, even when stdout is a terminal. Setting the NO_COLOR environment variable has the same effect
+	-no-stringer
+	      If true, no String method is generated for the typed, generic, int, and bitmask styles, so that one may be declared alongside the struct
+	-offline
+	      If true, the module proxy is disabled (GOPROXY=off) while loading packages, so generation fails rather than downloading missing dependencies
 	-out-dir string
 	      The directory in which to place the generated file. Defaults to the current directory (default ".")
 	-out-file string
 	      The file to write generated output to. Defaults to [--struct]_[prefix]_generated.go
+	      If the path is absolute, --out-dir is ignored
 	-out-pkg string
-	      The package the generated code should belong to. Defaults to the package containing the go:generate directive
+	      The package the generated code should belong to. Defaults to the package containing the go:generate directive, or else to the
+	      package of the Go files in --out-dir, or the name of --out-dir for a new package
+	-parse
+	      If true, a Parse[type] function returning the constant holding a string value, or an error for unknown values, is generated for the typed, generic, int, and bitmask styles
+	-per-package
+	      If true, the code generated from each struct is written to the directory of the package declaring it, and belongs to that package,
+	      in place of --out-dir and --out-pkg. E.g. --src-dir ./... --all-structs --per-package generates alongside every struct of a module
+	-platform value
+	      A comma separated list of GOOS or GOOS/GOARCH values, e.g. linux,darwin/arm64.
+	      If provided, the command is skipped with a notice when the target platform is not listed
 	-prefix value
 	      A value to prepend to the generated const names. Defaults to [tag]Field
+	-provenance
+	      If true, a Source method returning the struct, Go field, and tag the constant holding the value is generated from is generated for the typed, generic, int, and bitmask styles
+	-receiver-name string
+	      The receiver name of the methods generated for the typed, generic, int, and bitmask styles, e.g. field. Defaults to the lower-cased first character of the type name
+	-scan-dest
+	      If true, a [prefix]ScanDest function returning pointers to the fields selected by a list of constants, in order, is generated for use with sql.Rows.Scan
+	-schema value
+	      If provided, generation fails unless the values of the constants match the columns or properties of an external schema,
+	      in the form kind:path[#name], e.g. sql:db/schema.sql#users. Valid kinds are: sql, for the columns of a CREATE TABLE statement,
+	      jsonschema, for the properties of a JSON Schema or of one of its $defs, proto, for the fields of a .proto message, and db, for the
+	      columns of a table of a live database, e.g. 'db:$DATABASE_URL#users', queried with its psql, mysql, or sqlite3 client
+	-skip-fields value
+	      A comma separated list of --struct field names to skip, as if they were tagged sfgen:"-".
+	      Fields of embedded structs with a listed name are skipped as well
+	-source-map
+	      If true, each generated constant is followed by a comment with the file:line of its source field, relative to --out-dir
+	-sql-columns
+	      If true, a [struct]Columns constant listing the values of the fields as comma separated SQL columns in declaration order is generated,
+	      e.g. UserColumns = "id, email, created_at", along with a [struct]ColumnNames []string var. Requires a --tag, typically db
+	-sql-insert
+	      If true, [struct]InsertColumns() and [struct]InsertPlaceholders(dialect) functions returning matching column and placeholder lists
+	      are generated for INSERT statements, e.g. (id, email) and ($1, $2) for postgres or (?, ?) for mysql. Requires a --tag, typically db
 	-src-dir string
-	      The directory containing the --struct. Defaults to the current directory (default ".")
-	-struct string
-	      The struct to use as the source for code generation. REQUIRED
+	      The directory containing the --struct. Defaults to the current directory.
+	      A pattern such as ./... searches every package below the directory for the --struct (default ".")
+	-src-files value
+	      A comma separated list of Go files containing the --struct. If provided, the files are loaded as a single package
+	      by type checking them rather than with go list, --src-dir is ignored, and --out-pkg defaults to the package of the files.
+	      Imports that fail to resolve are reported as warnings
+	-stable-ids string
+	      If provided, a stable int ID is generated for each constant, along with maps from the constants to their IDs and back. The IDs are
+	      persisted in a JSON file at this path, so a field keeps its ID when it is renamed, e.g. for persisted values. Each command needs its own path
+	-stamp
+	      If true, the generator version and a hash of the normalized flags are stamped into the header of the generated file
+	-strict
+	      If true, warnings such as malformed tags falling back to the field name, skipped fields, or duplicate values fail generation
+	-stringer-format string
+	      If provided, the String method generated for the typed, generic, int, and bitmask styles returns the value formatted with this fmt format, e.g. 'db:%s'
+	-struct value
+	      The struct to use as the source for code generation. REQUIRED, unless --all-structs is provided
+	      May be qualified by the name of the package in --src-dir, e.g. models.User
+	-struct-regex string
+	      This flag requires the --all-structs flag be provided as well. If provided, only the structs whose name matches this regex are generated from
 	-style string
-	      Specifies the style of constants desired. Valid options are: alias, typed, generic
+	      Specifies the style of constants desired. Valid options are: alias, typed, generic, int, bitmask
+	-symbol-index string
+	      If provided, a JSON index mapping each generated constant to its struct, field, tag, and value is written to this path.
+	      All commands sharing a path are written to the same index
 	-tag string
 	      If provided, the provided tag will be parsed for each field on the --struct.
 	      If the tag is missing, the struct field's name is used.
 	      Otherwise, the first attribute in the tag is used as the name'
+	      xml tags follow the naming rules of encoding/xml, including nested paths and namespaces
+	-tag-options
+	      This flag requires the --tag flag be provided as well.
+	      If true, a [prefix]Options function will be generated, returning the options of the --tag for a given constant. E.g. omitempty
 	-tag-regex string
 	      This flag requires the --tag flag be provided as well.
 	      The provided regex will be tested on the specified tag contents for each field.
 	      The first capture group will be used as the value for the generated constant.
 	      If the regex does not match the tag contents, the struct field's' name will be used instead.
+	-template string
+	      If provided, the code of the command is generated by executing this text/template file with a sfgen.TemplateData in place of the
+	      built-in styles. A template made only of {{define}} actions instead overrides the "type", "consts", or "decls" partials of the
+	      built-in code, which {{builtin "name"}} renders. See the README for the functions available to templates
+	-text-marshaler
+	      If true, MarshalText and UnmarshalText methods are generated for the typed, generic, int, and bitmask styles, so the type may be
+	      decoded from JSON, YAML, or flags. UnmarshalText rejects values no constant holds
+	-timeout duration
+	      the maximum duration of the whole run, e.g. 30s. Defaults to no timeout
+	-timings
+	      If true, the durations of the load, parse, render, format, and write phases of the command are logged once its output is written.
+	      They are not recorded or sent anywhere else
+	-type-map string
+	      If provided, the path to a JSON file mapping Go types to the types the --emit languages represent them with, keyed by the Go type
+	      qualified by its import path and then by language, e.g. {"github.com/google/uuid.UUID": {"ts": "string"}}. Used by the ts and md emitters
+	-unsafe-offsets string
+	      If provided, a [out-file]_unsafe.go file guarded by this build constraint, e.g. sfgen_unsafe, is generated.
+	      It declares a [const]Offset constant holding the unsafe.Offsetof of the field of each constant, for zero-reflection field access.
+	      Fields behind a pointer are omitted
+	-value-pattern string
+	      If provided, generation fails if the value of any generated constant does not match this regex, e.g. '^[a-z_]+$'
+	-vendor
+	      If true, packages are loaded from the vendor directory of the module (-mod=vendor), without accessing the network. Shorthand for --mod-mode vendor
+	-watch
+	      if true, go-sfgen keeps running after generating, and regenerates the output files of the commands whose source package files change, until interrupted
 */
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"flag"
-	"fmt"
-	"github.com/fatih/structtag"
-	"go/types"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"io"
 	"log"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"regexp"
-	"strings"
-	"sync"
-	"unicode"
+	"os/signal"
+	"time"
 )
 
-var flagOptions []FlagOptions
+var (
+	flagOptions []sfgen.FlagOptions
+	timeout     time.Duration
+	emitBundle  bool
+	interactive bool
+	dryRun      bool
+	noColor     bool
+	check       bool
+	watch       bool
+)
 
 func init() {
+	if _, ok := subcommand(); ok {
+		return
+	}
 	flagOptions = parseOptions()
 }
 
-func main() {
-	err := os.Setenv("GODEBUG", "gotypesalias=1")
-	if err != nil {
-		log.Fatalf("failed to set GODEBUG variable")
-	}
-	defer func() {
-		_ = os.Unsetenv("GODEBUG")
-	}()
+// subcommands are the commands go-sfgen may be run as in place of a generate command, e.g. go-sfgen report.
+var subcommands = map[string]func(ctx context.Context, args []string) error{
+	"report": runReport,
+	"rename": runRename,
+	"doctor": runDoctor,
+}
 
-	var (
-		outputFileGroups = make(map[string][]FlagOptions)
-		packageDirs      = make([]string, 0, len(flagOptions))
-	)
+// subcommand returns the subcommand go-sfgen was run as, reporting false if it was run as a generate command.
+func subcommand() (func(ctx context.Context, args []string) error, bool) {
+	if len(os.Args) < 2 {
+		return nil, false
+	}
+	run, ok := subcommands[os.Args[1]]
+	return run, ok
+}
 
-	for _, fOpt := range flagOptions {
-		absSrcDir, err := filepath.Abs(fOpt.SourceStructDir)
-		if err != nil {
-			log.Fatalf("failed to parse source dir: %s", fOpt.SourceStructDir)
-		}
-		packageDirs = append(packageDirs, absSrcDir)
-		fOpt.SourceStructDir = absSrcDir
+func main() {
+	// Type aliases are represented as such by go/types, keeping the other GODEBUG settings of the environment
+	godebug := "gotypesalias=1"
+	if prev := os.Getenv("GODEBUG"); prev != "" {
+		godebug = prev + "," + godebug
+	}
+	if err := os.Setenv("GODEBUG", godebug); err != nil {
+		log.Fatalf("failed to set GODEBUG variable: %v", err)
+	}
 
-		if fOpt.OutputFile == "" {
-			fOpt.OutputFile = fmt.Sprintf("%s_%s_generated.go", strings.ToLower(fOpt.SourceStruct), strings.ToLower(calculateBaseName(fOpt)))
-		}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-		absOutDir, err := filepath.Abs(fOpt.OutputDir)
-		if err != nil {
-			log.Fatalf("failed to get absolute path to out file %q: %v", fOpt.OutputFile, err)
-		}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
-		absOut := filepath.Join(absOutDir, fOpt.OutputFile)
-		fOpt.OutputDir = absOutDir
-		fOpt.OutputFile = absOut
-		currentOpts := outputFileGroups[absOut]
-		if len(currentOpts) > 0 && currentOpts[0].OutputPackage != fOpt.OutputPackage {
-			log.Fatalf("invalid package values provided. Cannot use both %q and %q package values within output file %q",
-				currentOpts[0].OutputFile, fOpt.OutputPackage, fOpt.OutputFile)
+	if run, ok := subcommand(); ok {
+		if err := run(ctx, os.Args[2:]); err != nil {
+			log.Fatal(err)
 		}
-		outputFileGroups[absOut] = append(outputFileGroups[absOut], fOpt)
+		return
 	}
 
-	loadPackageScopes(packageDirs)
-
-	var wg sync.WaitGroup
-	for _, group := range outputFileGroups {
-		wg.Add(1)
-		go func(group []FlagOptions) {
-			defer wg.Done()
-			generateCodeForFileGroup(group)
-		}(group)
+	if interactive {
+		if err := runInteractive(ctx, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	wg.Wait()
-}
-
-func generateCodeForFileGroup(flagOptions []FlagOptions) {
-	if len(flagOptions) == 0 {
+	if watch {
+		if err := sfgen.NewGenerator(nil, nil, nil).Watch(ctx, flagOptions, sfgen.DefaultWatchInterval); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
 
-	var (
-		err      error
-		outPkg   = flagOptions[0].OutputPackage
-		outFile  = flagOptions[0].OutputFile
-		outDir   = flagOptions[0].OutputDir
-		imports  = make([][]string, len(flagOptions))
-		contents = make([][]byte, len(flagOptions))
-	)
-
-	for i, fOpt := range flagOptions {
-		contents[i], imports[i], err = parsePackage(fOpt)
-		if err != nil {
-			log.Fatalf("failed to parse struct: %v", err)
+	if check {
+		if err := runCheck(ctx); err != nil {
+			log.Fatal(err)
 		}
+		return
 	}
 
-	buf := new(bytes.Buffer)
-	buf.WriteString("// Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT.\n\n")
-	buf.WriteString(fmt.Sprintf("// Source %s.%s:%s\n\n",
-		os.Getenv("GOPACKAGE"), os.Getenv("GOFILE"), os.Getenv("GOLINE")))
-	buf.WriteString(fmt.Sprintf("package %s\n", outPkg))
-	seenImport := make(map[string]struct{})
-	hasWrittenImportHeader := false
-	for _, imports := range imports {
-	InnerLoop:
-		for _, imp := range imports {
-			if _, ok := seenImport[imp]; ok {
-				continue InnerLoop
-			}
-
-			seenImport[imp] = struct{}{}
-			if !hasWrittenImportHeader {
-				buf.WriteString("\nimport (\n")
-				hasWrittenImportHeader = true
-			}
-
-			buf.WriteByte('"')
-			buf.WriteString(imp)
-			buf.WriteByte('"')
-			buf.WriteByte('\n')
+	if emitBundle {
+		if err := writeBundle(ctx); err != nil {
+			log.Fatal(err)
 		}
-
-	}
-	if hasWrittenImportHeader {
-		buf.WriteString(")\n")
+		return
 	}
 
-	for _, c := range contents {
-		buf.Write(c)
-		buf.WriteByte('\n')
+	if dryRun {
+		if err := writeDryRun(ctx); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	if _, err = os.Stat(outFile); err != nil {
-		err = os.MkdirAll(outDir, 0755)
+	if err := sfgen.Run(ctx, flagOptions); err != nil {
+		log.Fatal(err)
 	}
+}
 
+// writeBundle writes the files that would be generated to stdout, rather than to the file system.
+func writeBundle(ctx context.Context) error {
+	result, err := sfgen.Generate(ctx, flagOptions)
 	if err != nil {
-		log.Fatalf("%v", err)
+		return err
 	}
 
-	file, err := os.OpenFile(outFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Fatalf("failed to open file at %s: %v", outFile, err)
+	for _, w := range result.Warnings() {
+		log.Printf("warning: %s", w)
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
-	_ = file.Truncate(0)
 
-	if _, err = file.Write(buf.Bytes()); err != nil {
-		log.Fatalf("failed to write to out file %s: %v", outFile, err)
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
 	}
+	return result.WriteBundle(os.Stdout, wd)
+}
 
-	cmd := exec.Command("go", "fmt", outFile)
-	if err := cmd.Run(); err != nil {
-		log.Fatalf("failed to run 'go fmt %s'", outFile)
+// parseTopLevelOptions parses the top level flags of the command line, along with the flags of the
+// [sfgen.DefaultsFile] of the current directory, which the command line flags win over.
+func parseTopLevelOptions(args []string) (sfgen.FlagOptions, error) {
+	var opts sfgen.FlagOptions
+	if err := opts.LoadDefaults("."); err != nil {
+		return opts, err
 	}
+	return opts, opts.Parse(sfgen.CommandArgs(args))
 }
 
-func parseOptions() []FlagOptions {
+func parseOptions() []sfgen.FlagOptions {
 	var (
 		commands     = NewMultiFlagOptions()
-		topLevelOpts FlagOptions
+		topLevelOpts sfgen.FlagOptions
+		configPath   string
 	)
 
 	flag.Var(&commands, "gen", "accepts all the top level flags in a string, allowing multiple generate commands to be specified")
+	flag.DurationVar(&timeout, "timeout", 0, "the maximum duration of the whole run, e.g. 30s. Defaults to no timeout")
+	flag.BoolVar(&emitBundle, "emit-bundle", false, "if true, the generated files are written to stdout as a txtar archive rather than to their paths")
+	flag.BoolVar(&dryRun, "dry-run", false, "if true, the generated files are written to stdout for review rather than to their paths")
+	flag.BoolVar(&check, "check", false,
+		"if true, the generated files are regenerated in memory, and go-sfgen exits with an error listing those that are missing or out of date, without writing anything")
+	flag.BoolVar(&watch, "watch", false,
+		"if true, go-sfgen keeps running after generating, and regenerates the output files of the commands whose source package files change, until interrupted")
+	flag.BoolVar(&noColor, "no-color", false, "if true, --dry-run output is not colorized, even when stdout is a terminal")
+	flag.BoolVar(&interactive, "interactive", false,
+		"if true, the constants of a single generate command are previewed in a table, where fields and boolean flags can be toggled before writing")
+	flag.StringVar(&configPath, "config", "", "a file listing one set of top level flags per line, allowing multiple generate commands to be specified")
 	topLevelOpts.RegisterFlags(flag.CommandLine)
-	flag.Parse()
+	// Invalid flags, e.g. of go:generate directives or --gen strings, are reported along with a suggestion rather than
+	// the full usage, which is only printed for -h
+	if err := sfgen.CheckFlags(flag.CommandLine, os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+	if err := flag.CommandLine.Parse(os.Args[1:]); errors.Is(err, flag.ErrHelp) {
+		flag.CommandLine.SetOutput(os.Stderr)
+		flag.Usage()
+		os.Exit(0)
+	} else if err != nil {
+		log.Fatal(err)
+	}
 
 	var (
 		visitedGen    bool
+		visitedConfig bool
 		visitedNonGen bool
 	)
 
 	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "gen" {
+		switch f.Name {
+		case "gen":
 			visitedGen = true
-		} else {
-			visitedNonGen = true
+		case "config":
+			visitedConfig = true
+		default:
+			if _, ok := sfgen.RunLevelFlags[f.Name]; !ok { // run-level flags may be combined with any other flag
+				visitedNonGen = true
+			}
 		}
 	})
 
@@ -237,409 +478,34 @@ func parseOptions() []FlagOptions {
 		log.Fatalf("if --gen flags are used, only --gen flags may be provided")
 	}
 
-	if visitedGen {
-		return commands.Slice()
-	}
-
-	if err := topLevelOpts.Validate(); err != nil {
-		log.Fatal(err.Error())
-	}
-
-	return []FlagOptions{topLevelOpts}
-}
-
-func parsePackage(f FlagOptions) (code []byte, imports []string, err error) {
-	if f.Iter && f.Style == StyleAlias {
-		log.Fatalf("Invalid style %s: only %s and %s styles may be used with the --iter flag", f.Style, StyleGeneric, StyleTyped)
-	}
-
-	structType, s, err := loadStruct(f.SourceStructDir, f.SourceStruct)
-	if err != nil {
-		return nil, nil, err
-	}
-	structPackage := structType.String()[:strings.LastIndexByte(structType.String(), '.')]
-
-	var (
-		outBuf         bytes.Buffer
-		constBuf       bytes.Buffer
-		closeConstants = func() {
-			constBuf.WriteByte(')')
-		}
-	)
-
-	baseName := calculateBaseName(f)
-	firstChar := strings.ToLower(baseName[:1])
-
-	if f.Style != "" {
-		outBuf.WriteString(fmt.Sprintf("// %s is a strong type generated from %s. Its type is used for all of its related generated constants.\n", baseName, f.SourceStruct))
-	}
-
-	switch f.Style {
-	case StyleAlias:
-		outBuf.WriteString(fmt.Sprintf("type %s = string\n", baseName))
-	case StyleTyped:
-		outBuf.WriteString(fmt.Sprintf("type %s string\n", baseName))
-		outBuf.WriteString("// String implements the [fmt.Stringer] interface\n")
-		outBuf.WriteString(fmt.Sprintf("func (%s %s) String() string { return (string)(%s) }\n", firstChar, baseName, firstChar))
-	case StyleGeneric:
-		outBuf.WriteString(fmt.Sprintf("type %s[T any] string\n", baseName))
-		outBuf.WriteString("// String implements the [fmt.Stringer] interface\n")
-		outBuf.WriteString(fmt.Sprintf("func (%s %s[T]) String() string { return (string)(%s) }\n", firstChar, baseName, firstChar))
-	}
-
-	fields, err := parseStructFields(f, structPackage, baseName, s)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if len(fields) == 0 {
-		closeConstants()
-	}
-
-	var fieldNames []string
-	for i, field := range fields {
-		if f.Style == StyleGeneric {
-			imports = append(imports, field.requiredImports...)
-		}
-
-		if constBuf.Len() == 0 {
-			constBuf.WriteByte('\n')
-			constBuf.WriteString(fmt.Sprintf("// Constants generated from [%s] struct field\n", f.SourceStruct))
-			constBuf.WriteString("const (")
-		} else {
-			constBuf.WriteByte('\n')
-		}
-
-		switch f.Style {
-		case StyleAlias, StyleTyped:
-			constBuf.WriteString(fmt.Sprintf("%s %s = %q", field.constName, field.baseName, field.constValue))
-		case StyleGeneric:
-			constBuf.WriteString(fmt.Sprintf("%s %s[%s] = %q", field.constName, field.baseName, field.fieldType, field.constValue))
-		default:
-			constBuf.WriteString(fmt.Sprintf("%s = %q", field.constName, field.constValue))
-		}
-		fieldNames = append(fieldNames, field.constValue)
-		if i == len(fields)-1 {
-			closeConstants()
-		}
-	}
-
-	if f.Iter {
-		outBuf.WriteString(fmt.Sprintf("// All was generated from the [%s] struct. It returns an array of all [%s]'s associated constant values.\n", f.SourceStruct, baseName))
-
-		var sb strings.Builder
-		for _, n := range fieldNames {
-			sb.WriteByte('\n')
-			sb.WriteByte('"')
-			sb.WriteString(n)
-			sb.WriteByte('"')
-			sb.WriteByte(',')
-		}
-		fieldNamesStr := sb.String()
-		if f.Style == StyleGeneric {
-			outBuf.WriteString(fmt.Sprintf("func (%s %s[T]) All() [%d]string { return [%d]string{%s} }\n", firstChar, baseName, len(fieldNames), len(fieldNames), fieldNamesStr))
-		} else {
-			outBuf.WriteString(fmt.Sprintf("func (%s %s) All() [%d]string { return [%d]string{%s} }\n", firstChar, baseName, len(fieldNames), len(fieldNames), fieldNamesStr))
-		}
-	}
-
-	if _, err = constBuf.WriteTo(&outBuf); err != nil {
-		log.Fatalf("failed to write full contents in memory: %v", err)
-	}
-
-	return outBuf.Bytes(), imports, nil
-}
-
-type parsedField struct {
-	parseFieldResult
-	baseName string
-}
-
-func fieldIsEmbeddedStruct(f *types.Var) (*types.Struct, bool) {
-	if !f.Embedded() {
-		return nil, false
+	if visitedConfig && (visitedGen || visitedNonGen) {
+		log.Fatalf("if the --config flag is used, no other flags may be provided")
 	}
 
-	t := f.Type()
-	for {
-		switch v := t.(type) {
-		case *types.Pointer:
-			t = t.Underlying()
-		case *types.Named:
-			t = t.Underlying()
-		case *types.Struct:
-			return v, true
-		default:
-			return nil, false
-		}
+	if interactive && (visitedGen || visitedConfig) {
+		log.Fatalf("the --interactive flag may only be used with the top level flags of a single generate command")
 	}
-}
 
-func parseStructFields(f FlagOptions, structPackage, baseName string, s *types.Struct) ([]parsedField, error) {
-	var (
-		topLevelFields = make(map[string]struct{})
-		fields         []parsedField
-		embeddedFields []parsedField
-	)
-	for i := 0; i < s.NumFields(); i++ {
-		field := s.Field(i)
-		if !f.IncludeUnexportedFields && !field.Exported() {
-			continue
-		}
-
-		tag := s.Tag(i)
-		parseFieldResult, err := parseField(structPackage, field, tag, baseName, f)
+	if visitedConfig {
+		opts, err := sfgen.ParseConfig(configPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse field with name %s: %w", field.Name(), err)
-		}
-
-		if parseFieldResult.constValue == "-" { // Handle the case that the field is ignored
-			continue
+			log.Fatal(err)
 		}
-
-		if structType, ok := fieldIsEmbeddedStruct(field); ok {
-			embFields, err := parseStructFields(f, structPackage, baseName, structType)
-			if err != nil {
-				return nil, err
-			}
-
-			embeddedFields = append(embeddedFields, embFields...)
-			continue
-		}
-
-		bName := []rune(baseName)
-		if f.Export {
-			bName[0] = unicode.ToUpper(bName[0])
-		} else {
-			bName[0] = unicode.ToLower(bName[0])
-		}
-		baseName = string(bName)
-		fields = append(fields, parsedField{
-			parseFieldResult: parseFieldResult,
-			baseName:         baseName,
-		})
-		topLevelFields[parseFieldResult.constName] = struct{}{}
-	}
-
-	for _, field := range embeddedFields {
-		_, ok := topLevelFields[field.constName]
-		if ok {
-			continue
-		}
-		fields = append(fields, field)
-	}
-
-	return fields, nil
-}
-
-type parseFieldResult struct {
-	fieldType, constName, constValue string
-	requiredImports                  []string
-}
-
-func parseField(structPackage string, field *types.Var, tag, baseName string, f FlagOptions) (parseFieldResult, error) {
-	tags, err := structtag.Parse(tag)
-	if err != nil {
-		return parseFieldResult{}, fmt.Errorf("failed to parse struct tags for field %s: %w", field.Name(), err)
+		return opts
 	}
 
-	fieldType, imps := parseTypeName(structPackage, field.Type())
-	if sfgenTag, ok := sfgenTagName(f.Tag, tags); ok {
-		return parseFieldResult{
-			fieldType:       fieldType,
-			constName:       baseName + field.Name(),
-			constValue:      sfgenTag,
-			requiredImports: imps,
-		}, nil
-	}
-
-	tagNameValue := field.Name()
-	if f.Tag != "" {
-		nameFromTag, err := tags.Get(f.Tag)
-		if err == nil && len(nameFromTag.Value()) > 0 && f.TagNameRegex != "" {
-			re, err := regexp.Compile(f.TagNameRegex)
-			if err != nil {
-				return parseFieldResult{}, fmt.Errorf("failed to compile regex expression %q: %w", f.TagNameRegex, err)
-			}
-
-			if matches := re.FindStringSubmatch(nameFromTag.Value()); len(matches) >= 2 {
-				tagNameValue = matches[1]
-			}
-		}
-
-		if err == nil && len(nameFromTag.Name) > 0 && f.TagNameRegex == "" {
-			tagNameValue = nameFromTag.Name
-		}
+	if visitedGen {
+		return commands.Slice()
 	}
 
-	return parseFieldResult{
-		fieldType:       fieldType,
-		constName:       baseName + field.Name(),
-		constValue:      tagNameValue,
-		requiredImports: imps,
-	}, nil
-}
-
-func sfgenTagName(targetTagName string, tags *structtag.Tags) (string, bool) {
-	sfgenTag, err := tags.Get("sfgen")
+	opts, err := parseTopLevelOptions(os.Args[1:])
 	if err != nil {
-		return "", false
-	}
-
-	tagValue := sfgenTag.Value()
-	if tagValue == "" {
-		return "", false
-	}
-
-	tagParts := strings.SplitN(strings.TrimSpace(tagValue), ",", 2)
-	tagName := tagParts[0] // We are guaranteed at least a slice with len(1)
-	if len(tagParts) == 1 {
-		return tagName, tagName != ""
-	}
-
-	// From here on we know that tagParts length is 2
-	tagSpecificValues := strings.Split(tagParts[1], " ")
-	for _, tagSpecificVal := range tagSpecificValues {
-		tagSpecificVal = strings.TrimSpace(tagSpecificVal)
-		if tagSpecificVal == "" {
-			continue
-		}
-
-		tagValParts := strings.SplitN(tagSpecificVal, ":", 2)
-		if len(tagValParts) != 2 || tagValParts[0] != targetTagName {
-			continue
-		}
-
-		if tagValParts[1] != "" {
-			tagName = tagValParts[1]
-			break
-		}
-	}
-
-	return tagName, tagName != ""
-}
-
-func calculateBaseName(f FlagOptions) string {
-	var (
-		tagName string
-		prefix  string
-	)
-
-	if f.UseStructName || f.Export {
-		tagName = strings.ToUpper(f.Tag)
-	} else {
-		tagName = strings.ToLower(f.Tag)
-	}
-
-	if f.Prefix == nil {
-		prefix = f.SourceStruct + tagName
-		if !f.UseStructName {
-			prefix = tagName
-		}
-
-		prefix += "Field"
-	} else {
-		prefix = *f.Prefix
-	}
-
-	properlyCasedName := []rune(prefix)
-	if f.Export {
-		properlyCasedName[0] = unicode.ToUpper(properlyCasedName[0])
-	} else {
-		properlyCasedName[0] = unicode.ToLower(properlyCasedName[0])
-	}
-
-	return string(properlyCasedName)
-}
-
-func loadStruct(source, structName string) (*types.Named, *types.Struct, error) {
-	scope, ok := scopeForPackage(source)
-	if !ok {
-		var a []string
-		for k := range packageNameToScopes {
-			a = append(a, k)
-		}
-		return nil, nil, fmt.Errorf("failed to find package scope: %s, %+v", source, a)
-	}
-
-	foundObj := scope.Lookup(structName) // *types.TypeName is returned here
-	if foundObj == nil {
-		return nil, nil, fmt.Errorf("type %s not found in package %s", structName, source)
-	}
-
-	n, ok := foundObj.Type().(*types.Named)
-	if !ok {
-		return nil, nil, fmt.Errorf("cannot use type %s, only named struct types are supported", structName)
-	}
-
-	s, ok := n.Underlying().(*types.Struct)
-	if !ok {
-		return nil, nil, fmt.Errorf("cannot use type %s, only named struct types are supported", structName)
-	}
-
-	return n, s, nil
-}
-
-func parseNamedType(structPackage string, u types.Type) (string, []string) {
-	name := u.String()
-	dotIndex := strings.LastIndexByte(name, '.')
-	pkgPath := name
-	if dotIndex >= 0 {
-		pkgPath = name[:dotIndex]
-	}
-
-	if pkgPath == structPackage {
-		return name[dotIndex+1:], nil
-	}
-
-	slashIndex := strings.LastIndexByte(name, '/')
-	newName := name
-	if slashIndex >= 0 {
-		newName = name[slashIndex+1:]
-	}
-
-	if dotIndex >= 0 {
-		return newName, []string{name[:dotIndex]}
-	}
-
-	return newName, nil
-}
-
-func parseTypeNameSignature(structPackage string, u *types.Signature) (string, []string) {
-	var (
-		sb      strings.Builder
-		imports []string
-	)
-
-	sb.WriteString("func (")
-	for i := 0; i < u.Params().Len(); i++ {
-		param := u.Params().At(i)
-		paramType, imps := parseTypeName(structPackage, param.Type())
-		imports = append(imports, imps...)
-		if i > 0 && i < u.Params().Len() {
-			sb.WriteByte(',')
-
-		}
-		sb.WriteString(paramType)
-	}
-	sb.WriteByte(')')
-
-	if u.Results().Len() > 1 {
-		sb.WriteByte('(')
+		log.Fatal(err.Error())
 	}
-	for i := 0; i < u.Results().Len(); i++ {
-		param := u.Results().At(i)
-		paramType, imps := parseTypeName(structPackage, param.Type())
-		imports = append(imports, imps...)
-		if i > 0 && i < u.Results().Len() {
-			sb.WriteByte(',')
 
-		}
-		sb.WriteString(paramType)
-	}
-	if u.Results().Len() > 1 {
-		sb.WriteByte(')')
+	if interactive && opts.AllStructs {
+		log.Fatalf("the --interactive flag may only be used with a single --struct, not --all-structs")
 	}
 
-	return sb.String(), imports
+	return []sfgen.FlagOptions{opts}
 }
@@ -2,11 +2,15 @@ package main
 
 import (
 	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
 )
 
-func NewMultiFlagOptions() MultiValue[FlagOptions] {
-	return NewMultiValue(func(s string) (FlagOptions, error) {
-		var f FlagOptions
+func NewMultiFlagOptions() MultiValue[sfgen.FlagOptions] {
+	return NewMultiValue(func(s string) (sfgen.FlagOptions, error) {
+		var f sfgen.FlagOptions
+		if err := f.LoadDefaults("."); err != nil {
+			return f, err
+		}
 		return f, f.ParseString(s)
 	})
 }
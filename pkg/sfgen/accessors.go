@@ -0,0 +1,89 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// accessorNames returns the names of the --accessors methods of the type baseName, e.g. GetField and SetField,
+// unexported along with the type.
+func accessorNames(f FlagOptions, baseName string) (getter, setter string) {
+	name := casedIdentifier(baseName, true)
+	return casedIdentifier("Get"+name, f.Export), casedIdentifier("Set"+name, f.Export)
+}
+
+// writeAccessors writes methods of the source struct getting and setting the field a constant was generated from, as a
+// switch over the constants rather than through reflection. The setter reports an error when the value is not of the
+// type of the field. Methods can only be declared in the package of the struct, so the output must belong to it.
+// Fields that cannot be reached without a pointer indirection are skipped with a warning, as are duplicate values. It
+// returns the imports the methods require.
+func writeAccessors(buf *bytes.Buffer, f FlagOptions, baseName string, structPkg *types.Package, s *types.Struct,
+	fields []parsedField, warn *warnings) ([]string, error) {
+	if structPkg.Name() != f.OutputPackage {
+		return nil, fmt.Errorf("cannot use --accessors for %s, as methods can only be generated into its package %s, not %s",
+			f.SourceStruct, structPkg.Name(), f.OutputPackage)
+	}
+
+	var (
+		getter, setter = accessorNames(f, baseName)
+		receiver       = receiverName(FlagOptions{}, f.SourceStruct)
+		keyType        = "string"
+		imports        = []string{"fmt"}
+		gets, sets     strings.Builder
+		seenValues     = make(map[string]struct{})
+	)
+	if f.Style == StyleAlias || f.Style == StyleTyped || f.intBased() {
+		keyType = baseName
+	}
+	qualifier := func(p *types.Package) string {
+		if p == structPkg {
+			return ""
+		}
+		imports = append(imports, p.Path())
+		return p.Name()
+	}
+
+	for _, field := range fields {
+		selectors, ok := offsetSelectors(s, field.index, false)
+		if !ok {
+			warn.add("skipped the accessors of %s field %s, as it cannot be reached without a pointer indirection", f.SourceStruct, field.fieldName)
+			continue
+		}
+
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+
+		key := field.constName
+		if f.Style == StyleGeneric {
+			key = fmt.Sprintf("%q", field.constValue)
+		}
+		target := fmt.Sprintf("%s.%s", receiver, strings.Join(selectors, "."))
+		fieldType := types.TypeString(fieldByIndex(s, field.index).Type(), qualifier)
+		gets.WriteString(fmt.Sprintf("case %s:\nreturn %s\n", key, target))
+		sets.WriteString(fmt.Sprintf("case %s:\nfieldValue, ok := value.(%s)\nif !ok {\n", key, fieldType))
+		sets.WriteString(fmt.Sprintf("return fmt.Errorf(\"invalid value of type %%T for field %%v, expected %s\", value, field)\n}\n", fieldType))
+		sets.WriteString(fmt.Sprintf("%s = fieldValue\nreturn nil\n", target))
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns the value of the struct field the constant field was generated from, or nil if there is none.\n",
+		getter))
+	buf.WriteString(fmt.Sprintf("func (%s *%s) %s(field %s) any {\n", receiver, f.SourceStruct, getter, keyType))
+	if gets.Len() > 0 {
+		buf.WriteString(fmt.Sprintf("switch field {\n%s}\n", gets.String()))
+	}
+	buf.WriteString("return nil\n}\n")
+
+	buf.WriteString(fmt.Sprintf("\n// %s sets the struct field the constant field was generated from to value, or returns an error if there is\n",
+		setter))
+	buf.WriteString("// none, or value is not of the type of the field.\n")
+	buf.WriteString(fmt.Sprintf("func (%s *%s) %s(field %s, value any) error {\n", receiver, f.SourceStruct, setter, keyType))
+	if sets.Len() > 0 {
+		buf.WriteString(fmt.Sprintf("switch field {\n%s}\n", sets.String()))
+	}
+	buf.WriteString("return fmt.Errorf(\"unknown field %v\", field)\n}\n")
+	return imports, nil
+}
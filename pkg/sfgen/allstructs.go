@@ -0,0 +1,79 @@
+package sfgen
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+)
+
+// expandAllStructs returns flagOptions with each --all-structs command replaced by one command per named struct type
+// of its loaded packages, or per struct matching its --struct-regex. Generic structs are skipped, as constants cannot
+// be generated from them without type arguments.
+func (g *Generator) expandAllStructs(flagOptions []FlagOptions) ([]FlagOptions, error) {
+	expanded := make([]FlagOptions, 0, len(flagOptions))
+	for _, f := range flagOptions {
+		if !f.AllStructs {
+			expanded = append(expanded, f)
+			continue
+		}
+
+		var re *regexp.Regexp
+		if f.StructRegex != "" {
+			var err error
+			if re, err = regexp.Compile(f.StructRegex); err != nil {
+				return nil, fmt.Errorf("failed to compile --struct-regex %q: %w", f.StructRegex, err)
+			}
+		}
+
+		pkgs, ok := g.packagesForDir(f.SourceStructDir)
+		if !ok {
+			return nil, fmt.Errorf("failed to find package scope: %s", f.SourceStructDir)
+		}
+
+		found := 0
+		for _, pkg := range pkgs {
+			for _, name := range pkg.Scope().Names() { // sorted, so the commands are generated in a stable order
+				if !isStructTypeName(pkg.Scope().Lookup(name)) || (re != nil && !re.MatchString(name)) {
+					continue
+				}
+
+				structOpts := f
+				structOpts.AllStructs, structOpts.StructRegex = false, ""
+				structOpts.SourceStruct = name
+				structOpts.UseStructName = true
+				if len(pkgs) > 1 {
+					structOpts.SourcePackage = pkg.Name()
+				}
+				expanded = append(expanded, structOpts)
+				found++
+			}
+		}
+
+		if found == 0 && re != nil {
+			return nil, fmt.Errorf("no struct matching --struct-regex %q found in %s", f.StructRegex, f.SourceStructDir)
+		}
+
+		if found == 0 {
+			return nil, fmt.Errorf("no struct found in %s", f.SourceStructDir)
+		}
+	}
+
+	return expanded, nil
+}
+
+// isStructTypeName reports whether obj declares a named, non-generic struct type. Aliases are skipped, as the struct
+// they refer to is generated from under its own name.
+func isStructTypeName(obj types.Object) bool {
+	typeName, ok := obj.(*types.TypeName)
+	if !ok || typeName.IsAlias() {
+		return false
+	}
+
+	named, ok := typeName.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return false
+	}
+
+	_, ok = named.Underlying().(*types.Struct)
+	return ok
+}
@@ -0,0 +1,50 @@
+package sfgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// asciiReplacements transliterates the Latin letters with diacritics, and ligatures, most commonly found in
+// identifiers.
+var asciiReplacements = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "Ae", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "ae", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'Æ': "Ae", 'æ': "ae", 'Ç': "C", 'ç': "c", 'Ć': "C", 'ć': "c", 'Č': "C", 'č': "c",
+	'Ð': "D", 'ð': "d", 'Ď': "D", 'ď': "d", 'Đ': "D", 'đ': "d",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'Ğ': "G", 'ğ': "g", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'İ': "I",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'Ł': "L", 'ł': "l", 'Ñ': "N", 'ñ': "n", 'Ń': "N", 'ń': "n", 'Ň': "N", 'ň': "n",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "Oe", 'Ø': "O", 'Ō': "O", 'Ő': "O",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "oe", 'ø': "o", 'ō': "o", 'ő': "o",
+	'Œ': "Oe", 'œ': "oe", 'Ř': "R", 'ř': "r", 'Ś': "S", 'ś': "s", 'Ş': "S", 'ş': "s", 'Š': "S", 'š': "s",
+	'ß': "ss", 'Ţ': "T", 'ţ': "t", 'Ť': "T", 'ť': "t", 'Þ': "Th", 'þ': "th",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "Ue", 'Ū': "U", 'Ů': "U", 'Ű': "U",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "ue", 'ū': "u", 'ů': "u", 'ű': "u",
+	'Ý': "Y", 'ý': "y", 'ÿ': "y", 'Ź': "Z", 'ź': "z", 'Ż': "Z", 'ż': "z", 'Ž': "Z", 'ž': "z",
+}
+
+// identifier returns name as it is used within generated identifiers. With --ascii-identifiers, letters with
+// diacritics are transliterated, and any other non-ASCII rune is replaced by its code point, e.g. U4E2D.
+func (f FlagOptions) identifier(name string) string {
+	if !f.ASCIIIdentifiers {
+		return name
+	}
+
+	var sb strings.Builder
+	for _, r := range name {
+		switch replacement, ok := asciiReplacements[r]; {
+		case r <= unicode.MaxASCII:
+			sb.WriteRune(r)
+		case ok:
+			sb.WriteString(replacement)
+		default:
+			sb.WriteString(fmt.Sprintf("U%04X", r))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,187 @@
+package sfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// assetCatalog holds the constants written to a --emit json or txt asset, grouped by struct in the order they were
+// generated.
+type assetCatalog struct {
+	structs []string
+	consts  map[string][]GeneratedField
+}
+
+func (c *assetCatalog) add(target TargetResult) {
+	if c.consts == nil {
+		c.consts = make(map[string][]GeneratedField)
+	}
+
+	name := target.Options.SourceStruct
+	if _, ok := c.consts[name]; !ok {
+		c.structs = append(c.structs, name)
+	}
+	c.consts[name] = append(c.consts[name], target.Fields...)
+}
+
+// assetFiles returns the asset at path holding the constants of targets, along with the Go file embedding it and
+// providing its accessor. The accessor is written to the --out-dir of the targets, which must contain the asset for
+// it to be embedded.
+func assetFiles(lang, path string, targets []TargetResult) ([]FileResult, error) {
+	var catalog assetCatalog
+	for _, target := range targets {
+		if target.Options.OutputDir != targets[0].Options.OutputDir || target.Options.OutputPackage != targets[0].Options.OutputPackage {
+			return nil, fmt.Errorf("invalid --emit usage. Commands writing to %q must share the same --out-dir and --out-pkg", path)
+		}
+		catalog.add(target)
+	}
+
+	var (
+		f       = targets[0].Options
+		content []byte
+		err     error
+	)
+	switch lang {
+	case EmitJSON:
+		content, err = jsonAsset(catalog)
+	case EmitText:
+		content, err = textAsset(catalog)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write asset %s: %w", path, err)
+	}
+
+	rel, err := filepath.Rel(f.OutputDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("invalid --emit %s:%s. The asset must be within --out-dir %s to be embedded", lang, path, f.OutputDir)
+	}
+
+	accessorPath := filepath.Join(f.OutputDir, assetAccessorFile(path))
+	accessor, err := format.Source(assetAccessor(f, lang, filepath.ToSlash(rel), path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code for %s: %w", accessorPath, err)
+	}
+
+	return []FileResult{
+		{Path: path, Content: content},
+		{Path: accessorPath, Package: f.OutputPackage, Content: accessor},
+	}, nil
+}
+
+// jsonAsset returns catalog as a JSON object, keyed by struct name and then by constant name.
+func jsonAsset(catalog assetCatalog) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("{")
+	for i, name := range catalog.structs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n  " + jsonString(name) + ": {")
+		for j, field := range catalog.consts[name] {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString("\n    " + jsonString(field.Const) + ": " + jsonString(field.Value))
+		}
+		buf.WriteString("\n  }")
+	}
+	buf.WriteString("\n}\n")
+
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("generated invalid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+func jsonString(s string) string {
+	encoded, _ := json.Marshal(s) // strings always encode
+	return string(encoded)
+}
+
+// textAsset returns catalog as one Struct.Const=value line per constant.
+func textAsset(catalog assetCatalog) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("# Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT.\n")
+	for _, name := range catalog.structs {
+		for _, field := range catalog.consts[name] {
+			if strings.ContainsAny(field.Value, "\r\n") {
+				return nil, fmt.Errorf("the value of %s contains a line break, which cannot be written to a txt asset", field.Const)
+			}
+			buf.WriteString(fmt.Sprintf("%s.%s=%s\n", name, field.Const, field.Value))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// assetAccessorFile returns the name of the Go file embedding the asset at path, e.g. fields_json_generated.go for
+// fields.json.
+func assetAccessorFile(path string) string {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return strings.ToLower(strings.TrimSuffix(base, ext)+"_"+strings.TrimPrefix(ext, ".")) + "_generated.go"
+}
+
+// assetAccessorName returns the name of the accessor of the asset at path, e.g. FieldCatalog for field-catalog.json.
+func assetAccessorName(f FlagOptions, path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(base, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		sb.WriteString(casedIdentifier(part, true))
+	}
+
+	name := f.identifier(sb.String())
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "Asset" + name
+	}
+	return casedIdentifier(name, f.Export)
+}
+
+// assetAccessor returns the Go file embedding the asset at rel, relative to the --out-dir, which declares the content
+// of the asset along with a function parsing it.
+func assetAccessor(f FlagOptions, lang, rel, path string) []byte {
+	var (
+		name    = assetAccessorName(f, path)
+		private = casedIdentifier(name, false)
+		asset   = name + "Asset"
+		buf     = new(bytes.Buffer)
+	)
+
+	buf.WriteString("// Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT.\n\n")
+	buf.WriteString(fmt.Sprintf("package %s\n\n", f.OutputPackage))
+	buf.WriteString("import (\n_ \"embed\"\n")
+	if lang == EmitJSON {
+		buf.WriteString("\"encoding/json\"\n")
+	} else {
+		buf.WriteString("\"strings\"\n")
+	}
+	buf.WriteString("\"sync\"\n)\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s is the content of %s, listing the values of the constants generated from each struct.\n//\n", asset, rel))
+	buf.WriteString(fmt.Sprintf("//go:embed %s\nvar %s []byte\n\n", rel, asset))
+	buf.WriteString(fmt.Sprintf("var (\n%sOnce sync.Once\n%sCatalog map[string]map[string]string\n)\n\n", private, private))
+
+	buf.WriteString(fmt.Sprintf("// %s returns the values of the constants generated from each struct, keyed by struct name and then by constant name,\n", name))
+	buf.WriteString(fmt.Sprintf("// as parsed from [%s]. The map is shared and must not be modified.\n", asset))
+	buf.WriteString(fmt.Sprintf("func %s() map[string]map[string]string {\n", name))
+	buf.WriteString(fmt.Sprintf("%sOnce.Do(func() {\n", private))
+	if lang == EmitJSON {
+		buf.WriteString(fmt.Sprintf("if err := json.Unmarshal(%s, &%sCatalog); err != nil {\n", asset, private))
+		buf.WriteString(fmt.Sprintf("panic(\"invalid generated asset %s: \" + err.Error())\n}\n", rel))
+	} else {
+		buf.WriteString(fmt.Sprintf("%sCatalog = make(map[string]map[string]string)\n", private))
+		buf.WriteString(fmt.Sprintf("for _, line := range strings.Split(string(%s), \"\\n\") {\n", asset))
+		buf.WriteString("key, value, ok := strings.Cut(line, \"=\")\n")
+		buf.WriteString("structName, constName, _ := strings.Cut(key, \".\")\n")
+		buf.WriteString("if !ok || strings.HasPrefix(line, \"#\") {\ncontinue\n}\n")
+		buf.WriteString(fmt.Sprintf("if %sCatalog[structName] == nil {\n%sCatalog[structName] = make(map[string]string)\n}\n", private, private))
+		buf.WriteString(fmt.Sprintf("%sCatalog[structName][constName] = value\n}\n", private))
+	}
+	buf.WriteString("})\n")
+	buf.WriteString(fmt.Sprintf("return %sCatalog\n}\n", private))
+	return buf.Bytes()
+}
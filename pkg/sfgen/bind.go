@@ -0,0 +1,155 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// writeBinder writes a [prefix]Bind function setting the fields of the source struct from url.Values, such as the
+// query parameters of a net/http request or its parsed form, keyed by the generated constants, without reflection.
+// Fields of string, bool, integer, and floating point kinds, and slices of them, are bound, and values that fail to
+// parse are reported as errors. Absent parameters leave their field unchanged. Fields of other types, or that cannot be
+// reached without a pointer indirection, are skipped with a warning. It returns the imports the function requires.
+func writeBinder(buf *bytes.Buffer, f FlagOptions, baseName string, structPkg *types.Package, s *types.Struct,
+	fields []parsedField, warn *warnings) []string {
+	var (
+		funcName   = baseName + "Bind"
+		structType = f.SourceStruct
+		external   = structPkg.Name() != f.OutputPackage
+		// types are qualified relative to the output package, which is only the struct package if not external
+		qualifier = structPkg.Path()
+		imports   = []string{"net/url"}
+		body      strings.Builder
+	)
+	if external {
+		structType = structPkg.Name() + "." + structType
+		qualifier = ""
+		imports = append(imports, structPkg.Path())
+	}
+
+	for _, field := range fields {
+		selectors, ok := offsetSelectors(s, field.index, external)
+		if !ok {
+			warn.add("skipped binding %s field %s, as it cannot be reached without a pointer indirection", f.SourceStruct, field.fieldName)
+			continue
+		}
+
+		target := "s." + strings.Join(selectors, ".")
+		code, bindImports, ok := bindField(fieldByIndex(s, field.index).Type(), qualifier, external, target, field.constValue)
+		if !ok {
+			warn.add("skipped binding %s field %s, as its type is not supported", f.SourceStruct, field.fieldName)
+			continue
+		}
+		imports = append(imports, bindImports...)
+
+		key := fmt.Sprintf("string(%s)", field.constName)
+		if f.intBased() { // the constants hold the index or bit of their field rather than its value
+			key = fmt.Sprintf("%q", field.constValue)
+		}
+		body.WriteString(fmt.Sprintf("if v := values[%s]; len(v) > 0 {\n%s}\n", key, code))
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s sets the fields of s from the values keyed by the constants generated from them, e.g. the query parameters\n", funcName))
+	buf.WriteString("// of an HTTP request. Fields whose key is absent are left unchanged, and multiple values are only bound to slices.\n")
+	buf.WriteString(fmt.Sprintf("func %s(s *%s, values url.Values) error {\n", funcName, structType))
+	buf.WriteString(body.String())
+	buf.WriteString("return nil\n}\n")
+	return imports
+}
+
+// bindField returns the statements setting target from v, the values of the parameter named key, reporting false if
+// values cannot be bound to its type t. Types are qualified relative to the package with the path qualifier.
+func bindField(t types.Type, qualifier string, external bool, target, key string) (string, []string, bool) {
+	if slice, ok := t.Underlying().(*types.Slice); ok {
+		typeName, imports, ok := bindTypeName(t, qualifier, external)
+		if !ok {
+			return "", nil, false
+		}
+
+		code, value, elemImports, ok := bindValue(slice.Elem(), qualifier, external, "x", key)
+		if !ok {
+			return "", nil, false
+		}
+
+		return fmt.Sprintf("%s = make(%s, len(v))\nfor i, x := range v {\n%s%s[i] = %s\n}\n", target, typeName, code, target, value),
+			append(imports, elemImports...), true
+	}
+
+	code, value, imports, ok := bindValue(t, qualifier, external, "v[0]", key)
+	if !ok {
+		return "", nil, false
+	}
+	return fmt.Sprintf("%s%s = %s\n", code, target, value), imports, true
+}
+
+// bindValue returns the expression of the value of type t parsed from the string expression src, along with the
+// statements parsing it, which return an error naming the parameter key if it fails to parse.
+func bindValue(t types.Type, qualifier string, external bool, src, key string) (string, string, []string, bool) {
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", "", nil, false
+	}
+
+	typeName, imports, ok := bindTypeName(t, qualifier, external)
+	if !ok {
+		return "", "", nil, false
+	}
+
+	// parsed is the type returned by the strconv function parsing the value
+	var parse, parsed string
+	switch info := basic.Info(); {
+	case info&types.IsString != 0 && typeName == "string":
+		return "", src, imports, true
+	case info&types.IsString != 0:
+		return "", fmt.Sprintf("%s(%s)", typeName, src), imports, true
+	case info&types.IsBoolean != 0:
+		parse, parsed = fmt.Sprintf("strconv.ParseBool(%s)", src), "bool"
+	case info&types.IsUnsigned != 0 && basic.Kind() != types.Uintptr:
+		parse, parsed = fmt.Sprintf("strconv.ParseUint(%s, 10, %d)", src, bitSize(basic)), "uint64"
+	case info&types.IsInteger != 0 && basic.Kind() != types.Uintptr:
+		parse, parsed = fmt.Sprintf("strconv.ParseInt(%s, 10, %d)", src, bitSize(basic)), "int64"
+	case info&types.IsFloat != 0:
+		parse, parsed = fmt.Sprintf("strconv.ParseFloat(%s, %d)", src, bitSize(basic)), "float64"
+	default:
+		return "", "", nil, false
+	}
+
+	message := strconv.Quote("invalid " + strings.ReplaceAll(key, "%", "%%") + " %q: %w")
+	code := fmt.Sprintf("parsed, err := %s\nif err != nil {\nreturn fmt.Errorf(%s, %s, err)\n}\n", parse, message, src)
+	value := "parsed"
+	if typeName != parsed {
+		value = fmt.Sprintf("%s(parsed)", typeName)
+	}
+	return code, value, append(imports, "fmt", "strconv"), true
+}
+
+// bindTypeName returns the name of t qualified relative to the package with the path qualifier, reporting false if it
+// is an unexported type of another package.
+func bindTypeName(t types.Type, qualifier string, external bool) (string, []string, bool) {
+	if named, ok := t.(*types.Named); ok && external && !token.IsExported(named.Obj().Name()) {
+		return "", nil, false
+	}
+
+	name, imports := qualifiedTypeString(qualifier, t)
+	return name, imports, true
+}
+
+// bitSize returns the size in bits of the numeric kind of basic, or 0 for int and uint, whose size depends on the
+// platform, as expected by the strconv parse functions.
+func bitSize(basic *types.Basic) int {
+	switch basic.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int64, types.Uint64, types.Float64:
+		return 64
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package sfgen
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// Web frameworks of the --bindings, whose request binding tags constants are generated for.
+const (
+	// BindingsGin generates constants for the uri, form, and header tags of Gin.
+	BindingsGin = "gin"
+	// BindingsEcho generates constants for the param, query, form, and header tags of Echo.
+	BindingsEcho = "echo"
+)
+
+// bindingTags are the tags each framework of the --bindings reads request values from, in the order their constants
+// are generated.
+var bindingTags = map[string][]string{
+	BindingsGin:  {"uri", "form", "header"},
+	BindingsEcho: {"param", "query", "form", "header"},
+}
+
+// expandBindings returns flagOptions with each --bindings command replaced by one command per binding tag of its
+// framework used by the struct, e.g. uriField and headerField constants for a Gin request struct. Only the fields
+// holding the tag are generated from, and the commands share a single output file unless --out-file is provided.
+func (g *Generator) expandBindings(flagOptions []FlagOptions) ([]FlagOptions, error) {
+	expanded := make([]FlagOptions, 0, len(flagOptions))
+	for _, f := range flagOptions {
+		if f.Bindings == "" {
+			expanded = append(expanded, f)
+			continue
+		}
+
+		_, s, err := g.loadStruct(f.SourceStructDir, f.SourcePackage, f.SourceStruct)
+		if err != nil {
+			return nil, err
+		}
+
+		found := 0
+		for _, tag := range bindingTags[f.Bindings] {
+			if !structHasTag(s, tag, make(map[*types.Struct]struct{})) {
+				continue
+			}
+
+			tagOpts := f
+			tagOpts.Bindings = ""
+			tagOpts.Tag = tag
+			tagOpts.taggedOnly = true
+			if tagOpts.OutputFile == "" {
+				tagOpts.OutputFile = fmt.Sprintf("%s_%s_generated.go", strings.ToLower(f.SourceStruct), f.Bindings)
+			}
+			expanded = append(expanded, tagOpts)
+			found++
+		}
+
+		if found == 0 {
+			return nil, fmt.Errorf("%s has no field with a %s binding tag, expected one of: %s", f.SourceStruct, f.Bindings,
+				strings.Join(bindingTags[f.Bindings], ", "))
+		}
+	}
+
+	return expanded, nil
+}
+
+// structHasTag reports whether a field of s, or of the structs it embeds, has the tag key. seen guards against embedded
+// pointers to the struct itself.
+func structHasTag(s *types.Struct, key string, seen map[*types.Struct]struct{}) bool {
+	if _, ok := seen[s]; ok {
+		return false
+	}
+	seen[s] = struct{}{}
+
+	for i := 0; i < s.NumFields(); i++ {
+		if _, ok := reflect.StructTag(s.Tag(i)).Lookup(key); ok {
+			return true
+		}
+
+		if embedded, ok := fieldIsEmbeddedStruct(s.Field(i)); ok && structHasTag(embedded, key, seen) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxBitmaskFields is the number of fields the uint64 of the bitmask style holds a bit for.
+const maxBitmaskFields = 64
+
+// writeBitmaskMethods writes the methods of the type of the bitmask style: String, returning the values of the set
+// bits joined by |, formatted with the --stringer-format if provided, along with Has, Set, and Clear. It returns the
+// imports the methods require.
+func writeBitmaskMethods(buf *bytes.Buffer, f FlagOptions, typeName, receiver string, fields []parsedField) []string {
+	var imports []string
+	if !f.NoStringer {
+		consts := make([]string, len(fields))
+		buf.WriteString("// String implements the [fmt.Stringer] interface, returning the values of the set bits joined by |\n")
+		buf.WriteString(fmt.Sprintf("func (%s %s) String() string {\n", receiver, typeName))
+		buf.WriteString("var values []string\n")
+		for i, field := range fields {
+			value := field.constValue
+			if f.StringerFormat != "" {
+				value = fmt.Sprintf(f.StringerFormat, value)
+			}
+			buf.WriteString(fmt.Sprintf("if %s&%s != 0 {\nvalues = append(values, %q)\n}\n", receiver, field.constName, value))
+			consts[i] = field.constName
+		}
+
+		// Bits not held by a constant are written as a conversion, e.g. jsonField(128)
+		rest := receiver
+		if len(consts) > 0 {
+			rest = fmt.Sprintf("%s &^ (%s)", receiver, strings.Join(consts, " | "))
+		}
+		buf.WriteString(fmt.Sprintf("if rest := %s; rest != 0 {\n", rest))
+		buf.WriteString(fmt.Sprintf("values = append(values, %q + strconv.FormatUint(uint64(rest), 10) + \")\")\n}\n", typeName+"("))
+		buf.WriteString("return strings.Join(values, \"|\")\n}\n")
+		imports = append(imports, "strconv", "strings")
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// Has reports whether every bit of flags is set in %s.\n", receiver))
+	buf.WriteString(fmt.Sprintf("func (%s %s) Has(flags %s) bool { return %s&flags == flags }\n", receiver, typeName, typeName, receiver))
+	buf.WriteString(fmt.Sprintf("\n// Set returns %s with the bits of flags set.\n", receiver))
+	buf.WriteString(fmt.Sprintf("func (%s %s) Set(flags %s) %s { return %s | flags }\n", receiver, typeName, typeName, typeName, receiver))
+	buf.WriteString(fmt.Sprintf("\n// Clear returns %s with the bits of flags cleared.\n", receiver))
+	buf.WriteString(fmt.Sprintf("func (%s %s) Clear(flags %s) %s { return %s &^ flags }\n", receiver, typeName, typeName, typeName, receiver))
+	return imports
+}
@@ -0,0 +1,98 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// builderTypeName returns the name of the --builder type of the source struct, e.g. UserBuilder, exported along with
+// the constants.
+func builderTypeName(f FlagOptions) string {
+	return casedIdentifier(f.SourceStruct+"Builder", f.Export)
+}
+
+// writeBuilder writes a builder of the source struct, with a fluent With[field] setter for each field the constants are
+// generated from, named after the field as the constants are, e.g. WithEmail. Fields that cannot be reached without a
+// pointer indirection, or whose field or type cannot be referenced from the output package, are skipped with a
+// warning. It returns the imports the builder requires.
+func writeBuilder(buf *bytes.Buffer, f FlagOptions, structPkg *types.Package, s *types.Struct, fields []parsedField, warn *warnings) []string {
+	var (
+		typeName   = builderTypeName(f)
+		ctorName   = casedIdentifier("New"+casedIdentifier(typeName, true), f.Export)
+		structType = f.SourceStruct
+		external   = structPkg.Name() != f.OutputPackage
+		imports    []string
+	)
+	if external {
+		structType = structPkg.Name() + "." + structType
+		imports = append(imports, structPkg.Path())
+	}
+	qualifier := func(p *types.Package) string {
+		if p == structPkg && !external {
+			return ""
+		}
+		imports = append(imports, p.Path())
+		return p.Name()
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s builds a [%s] through fluent setters of the fields the constants are generated from.\n", typeName, structType))
+	buf.WriteString(fmt.Sprintf("type %s struct {\nvalue %s\n}\n", typeName, structType))
+	buf.WriteString(fmt.Sprintf("\n// %s returns a [%s] starting from the zero %s.\n", ctorName, typeName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("func %s() *%s {\nreturn &%s{}\n}\n", ctorName, typeName, typeName))
+
+	for _, field := range fields {
+		selectors, ok := offsetSelectors(s, field.index, external)
+		if !ok {
+			warn.add("skipped the builder setter of %s field %s, as it cannot be reached without a pointer indirection", f.SourceStruct, field.fieldName)
+			continue
+		}
+
+		fieldType := fieldByIndex(s, field.index).Type()
+		if external && !referenceable(fieldType) {
+			warn.add("skipped the builder setter of %s field %s, as its type cannot be referenced from package %s", f.SourceStruct, field.fieldName, f.OutputPackage)
+			continue
+		}
+
+		method := "With" + field.identName
+		buf.WriteString(fmt.Sprintf("\n// %s sets the %s field.\n", method, strings.Join(selectors, ".")))
+		buf.WriteString(fmt.Sprintf("func (b *%s) %s(v %s) *%s {\n", typeName, method, types.TypeString(fieldType, qualifier), typeName))
+		buf.WriteString(fmt.Sprintf("b.value.%s = v\nreturn b\n}\n", strings.Join(selectors, ".")))
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// Build returns the built %s.\n", f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("func (b *%s) Build() %s {\nreturn b.value\n}\n", typeName, structType))
+	return imports
+}
+
+// referenceable reports whether t can be referenced from another package than its own, i.e. whether the named types it
+// is composed of are exported.
+func referenceable(t types.Type) bool {
+	switch u := t.(type) {
+	case *types.Named:
+		if u.Obj().Pkg() != nil && !token.IsExported(u.Obj().Name()) {
+			return false
+		}
+		args := u.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if !referenceable(args.At(i)) {
+				return false
+			}
+		}
+		return true
+	case *types.Pointer:
+		return referenceable(u.Elem())
+	case *types.Slice:
+		return referenceable(u.Elem())
+	case *types.Array:
+		return referenceable(u.Elem())
+	case *types.Chan:
+		return referenceable(u.Elem())
+	case *types.Map:
+		return referenceable(u.Key()) && referenceable(u.Elem())
+	default:
+		return true
+	}
+}
@@ -0,0 +1,32 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// WriteBundle writes the files of the result to w as a txtar archive, letting callers decide where and how to write
+// them, e.g. when running remotely or within test harnesses. Paths are written relative to dir when possible, and in
+// slash separated form.
+func (r *Result) WriteBundle(w io.Writer, dir string) error {
+	buf := new(bytes.Buffer)
+	for _, file := range r.Files {
+		path := file.Path
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			path = rel
+		}
+
+		buf.WriteString(fmt.Sprintf("-- %s --\n", filepath.ToSlash(path)))
+		buf.Write(file.Content)
+		if len(file.Content) > 0 && file.Content[len(file.Content)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	if _, err := buf.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package sfgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/shlex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// parseChain parses the command line of a --chain flag, failing on empty commands and unbalanced quotes.
+func parseChain(s string) ([]string, error) {
+	args, err := shlex.Split(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("the command is empty")
+	}
+	return args, nil
+}
+
+// runChains runs the --chain commands of the targets of result once its files are written, so the generators of a
+// package consuming the constants, e.g. mockgen or stringer, see the regenerated code in the same pass. Commands are
+// run in the output directory of their target, in the order of the output files, with GOFILE and GOPACKAGE set to the
+// output file and its package as by go generate, and are expanded in their arguments, e.g. $GOFILE. A command shared
+// by several targets of a directory is only run once. The output of the commands is written to the logger of g, one
+// line at a time.
+func (g *Generator) runChains(ctx context.Context, result *Result) error {
+	ran := make(map[string]struct{})
+	for _, file := range result.Files {
+		for _, target := range file.Targets {
+			for _, chain := range target.Options.Chain {
+				dir := filepath.Dir(file.Path)
+				key := dir + "\x00" + chain
+				if _, ok := ran[key]; ok {
+					continue
+				}
+				ran[key] = struct{}{}
+
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				args, err := parseChain(chain)
+				if err != nil {
+					return fmt.Errorf("invalid --chain %q: %w", chain, err)
+				}
+
+				env := map[string]string{"GOFILE": filepath.Base(file.Path), "GOPACKAGE": file.Package}
+				for i, arg := range args {
+					args[i] = os.Expand(arg, func(name string) string {
+						if v, ok := env[name]; ok {
+							return v
+						}
+						return os.Getenv(name)
+					})
+				}
+
+				cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+				cmd.Dir = dir
+				cmd.Env = append(os.Environ(), "GOFILE="+env["GOFILE"], "GOPACKAGE="+env["GOPACKAGE"])
+				output, err := cmd.CombinedOutput()
+				for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+					if line != "" {
+						g.logger.Printf("%s: %s", args[0], line)
+					}
+				}
+				if err != nil {
+					return fmt.Errorf("--chain %q failed in %s: %w", chain, dir, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package sfgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// StaleFile describes a generated file whose content on disk does not match the content it would be generated with.
+type StaleFile struct {
+	// Path is the absolute path of the file.
+	Path string
+	// Reason describes how the file differs, e.g. that it is missing.
+	Reason string
+}
+
+// Check generates the code for each of the provided options using a [Generator] with the default dependencies, and
+// returns the output files that are missing or differ from the generated code, without writing anything.
+func Check(ctx context.Context, flagOptions []FlagOptions) ([]StaleFile, *Result, error) {
+	return NewGenerator(nil, nil, nil).Check(ctx, flagOptions)
+}
+
+// Check generates the code for each of the provided options, and returns the output files that are missing or differ
+// from the generated code, without writing anything, e.g. to fail CI when go generate was not re-run after editing a
+// struct. Files only written when absent, such as a --guard-test, are only checked for existence, and the files no
+// longer generated, such as the surplus parts of a --max-file-lines output, for absence. The result of the
+// generation is returned along with the stale files, so callers may report its warnings.
+func (g *Generator) Check(ctx context.Context, flagOptions []FlagOptions) ([]StaleFile, *Result, error) {
+	result, err := g.Generate(ctx, flagOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stale []StaleFile
+	for _, file := range result.Files {
+		existing, ok, err := readFile(g.fs, file.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read out file %s: %w", file.Path, err)
+		}
+
+		switch {
+		case !ok:
+			stale = append(stale, StaleFile{Path: file.Path, Reason: "missing"})
+		case !file.CreateOnly && !bytes.Equal(existing, file.Content):
+			stale = append(stale, StaleFile{Path: file.Path, Reason: staleReason(existing, file.Content)})
+		}
+	}
+
+	for _, path := range result.Removed {
+		exists, err := fileExists(g.fs, path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check out file %s: %w", path, err)
+		}
+
+		if exists {
+			stale = append(stale, StaleFile{Path: path, Reason: "no longer generated"})
+		}
+	}
+
+	return stale, result, nil
+}
+
+// staleReason describes how existing differs from the generated content, preferring the mismatch of their
+// [Stamp] if both were generated with --stamp.
+func staleReason(existing, generated []byte) string {
+	if previous, ok := ParseStamp(existing); ok {
+		if current, ok := ParseStamp(generated); ok {
+			if err := previous.Compatible(current); err != nil {
+				return err.Error()
+			}
+		}
+	}
+
+	existingLines, generatedLines := bytes.Split(existing, []byte("\n")), bytes.Split(generated, []byte("\n"))
+	for i := range generatedLines {
+		if i >= len(existingLines) || !bytes.Equal(existingLines[i], generatedLines[i]) {
+			return fmt.Sprintf("differs from the generated code at line %d", i+1)
+		}
+	}
+	return fmt.Sprintf("differs from the generated code at line %d", len(generatedLines)+1)
+}
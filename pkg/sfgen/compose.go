@@ -0,0 +1,79 @@
+package sfgen
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// parseAddedFields parses an --add-fields value: a comma separated list of structs of the source package, adding all
+// their fields, or of their fields, of the form Struct.Field.
+func parseAddedFields(s string) ([]string, error) {
+	var added []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		structName, fieldName, hasField := strings.Cut(entry, ".")
+		if !token.IsIdentifier(structName) || (hasField && !token.IsIdentifier(fieldName)) {
+			return nil, fmt.Errorf("invalid --add-fields value %q, expected Struct or Struct.Field, e.g. Account.ID", entry)
+		}
+		added = append(added, entry)
+	}
+	return added, nil
+}
+
+// parseAddedStructFields parses the fields of the --add-fields of f, with the tag and naming flags of f, so they are
+// generated along with those of the --struct, e.g. public API fields made of User and Account.ID. The added fields
+// have no index within the --struct, so the declarations reaching fields through it skip them, and a field generating
+// the same constant as another is an error.
+func (g *Generator) parseAddedStructFields(f FlagOptions, structPackage, baseName string, fields []parsedField, warn *warnings) ([]parsedField, error) {
+	constFields := make(map[string]string, len(fields))
+	for _, field := range fields {
+		constFields[field.constName] = f.SourceStruct + "." + field.fieldName
+	}
+
+	var added []parsedField
+	for _, entry := range f.AddFields {
+		structName, fieldName, hasField := strings.Cut(entry, ".")
+		_, s, err := g.loadStruct(f.SourceStructDir, f.SourcePackage, structName)
+		if err != nil {
+			return nil, fmt.Errorf("--add-fields %s: %w", entry, err)
+		}
+
+		// Fields listed explicitly are added even if --skip-fields, meant for the --struct, lists their name
+		sub := f
+		sub.SourceStruct = structName
+		if hasField {
+			sub.SkipFields = nil
+		}
+		structFields, err := g.parseStructFields(sub, structPackage, baseName, s, warn)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, field := range structFields {
+			if hasField && field.fieldName != fieldName {
+				continue
+			}
+			found = true
+
+			source := structName + "." + field.fieldName
+			if other, ok := constFields[field.constName]; ok {
+				return nil, fmt.Errorf("--add-fields %s: %s and %s both generate the constant %s, skip one with --skip-fields",
+					entry, other, source, field.constName)
+			}
+			constFields[field.constName] = source
+
+			field.index, field.sourceStruct = nil, structName
+			added = append(added, field)
+		}
+
+		if hasField && !found {
+			return nil, fmt.Errorf("--add-fields %s: %s has no field %s generating a constant", entry, structName, fieldName)
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,93 @@
+package sfgen
+
+import (
+	"fmt"
+	"go/build"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// goVersionPattern matches the values accepted by --min-go.
+var goVersionPattern = regexp.MustCompile(`^(go)?1(\.[0-9]+){1,2}$`)
+
+var (
+	toolchainVersionOnce sync.Once
+	toolchainVersionText string
+)
+
+// SkippedTarget describes a generate command that was skipped, as the conditions of its --min-go or --platform flags
+// are not met.
+type SkippedTarget struct {
+	// Options are the options of the command.
+	Options FlagOptions
+	// Reason describes the condition that is not met.
+	Reason string
+}
+
+// skipReason returns why the command must be skipped for the current toolchain and target platform, or an empty
+// string if it must not be.
+func (f *FlagOptions) skipReason() string {
+	if f.MinGoVersion != "" {
+		if current := toolchainVersion(); !goVersionAtLeast(current, f.MinGoVersion) {
+			return fmt.Sprintf("requires Go %s, but the toolchain is %s", strings.TrimPrefix(f.MinGoVersion, "go"), current)
+		}
+	}
+
+	if len(f.Platforms) > 0 {
+		goos, goarch := build.Default.GOOS, build.Default.GOARCH
+		for _, platform := range f.Platforms {
+			if platform == goos || platform == goos+"/"+goarch {
+				return ""
+			}
+		}
+		return fmt.Sprintf("only generated for %s, but the target platform is %s/%s", strings.Join(f.Platforms, ", "), goos, goarch)
+	}
+
+	return ""
+}
+
+// toolchainVersion returns the version of the go command used to load packages, e.g. go1.23.1, falling back to the
+// version go-sfgen was built with if it cannot be determined.
+func toolchainVersion() string {
+	toolchainVersionOnce.Do(func() {
+		out, err := exec.Command("go", "env", "GOVERSION").Output()
+		toolchainVersionText = strings.TrimSpace(string(out))
+		if err != nil || toolchainVersionText == "" {
+			toolchainVersionText = runtime.Version()
+		}
+	})
+	return toolchainVersionText
+}
+
+// goVersionAtLeast reports whether the toolchain version current, e.g. go1.23.1, is at least minimum, e.g. 1.23.
+// Development versions of the toolchain satisfy any minimum.
+func goVersionAtLeast(current, minimum string) bool {
+	if strings.HasPrefix(current, "devel") {
+		return true
+	}
+
+	have, want := goVersionParts(current), goVersionParts(minimum)
+	for i := range want {
+		if have[i] != want[i] {
+			return have[i] > want[i]
+		}
+	}
+	return true
+}
+
+// goVersionParts returns the major, minor, and patch numbers of a Go version such as go1.23rc1 or 1.22.3.
+func goVersionParts(version string) [3]int {
+	var parts [3]int
+	for i, part := range strings.SplitN(strings.TrimPrefix(version, "go"), ".", 3) {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		parts[i], _ = strconv.Atoi(part[:end])
+	}
+	return parts
+}
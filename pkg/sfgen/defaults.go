@@ -0,0 +1,112 @@
+package sfgen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/shlex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultsFile is the name of the file providing default flags to the generate commands of its directory, e.g. to
+// set --export or --style conventions per package. Flags may span multiple lines, and lines starting with # are
+// ignored.
+const DefaultsFile = ".sfgen"
+
+// LoadDefaults loads the flags of the [DefaultsFile] in dir, if there is one, as defaults of the options. The flags
+// are merged under the flags later provided to [FlagOptions.Parse], which win when both provide a flag.
+func (f *FlagOptions) LoadDefaults(dir string) error {
+	defaults, err := ReadDefaults(dir)
+	if err != nil {
+		return err
+	}
+
+	f.defaults = defaults
+	return nil
+}
+
+// ReadDefaults returns the flags of the [DefaultsFile] in dir, or nil if there is none.
+func ReadDefaults(dir string) ([]string, error) {
+	path := filepath.Join(dir, DefaultsFile)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read defaults file %s: %w", path, err)
+	}
+
+	var (
+		lines   []string
+		scanner = bufio.NewScanner(bytes.NewReader(content))
+	)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	args, err := shlex.Split(strings.Join(lines, " "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defaults file %s: %w", path, err)
+	}
+	return args, nil
+}
+
+// mergeDefaults returns args preceded by the flags of defaults that args does not provide, so each flag is only
+// provided once and the flags of args win. Both must only hold flags defined on flagSet.
+func mergeDefaults(flagSet *flag.FlagSet, defaults, args []string) []string {
+	provided := make(map[string]struct{})
+	for _, fa := range splitFlagArgs(flagSet, args) {
+		provided[fa.name] = struct{}{}
+	}
+
+	var merged []string
+	for _, fa := range splitFlagArgs(flagSet, defaults) {
+		if _, ok := provided[fa.name]; !ok && fa.name != "" {
+			merged = append(merged, fa.args...)
+		}
+	}
+
+	return append(merged, args...)
+}
+
+// flagArgs holds a flag along with its separate value, if any.
+type flagArgs struct {
+	// name is the name of the flag, or empty for the trailing arguments following the flags.
+	name string
+	args []string
+}
+
+// splitFlagArgs splits args into one entry per flag, the way flagSet parses them.
+func splitFlagArgs(flagSet *flag.FlagSet, args []string) []flagArgs {
+	var split []flagArgs
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(split, flagArgs{args: args[i:]})
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		definedFlag := flagSet.Lookup(name)
+		if definedFlag == nil || hasValue {
+			split = append(split, flagArgs{name: name, args: []string{arg}})
+			continue
+		}
+
+		if boolFlag, ok := definedFlag.Value.(interface{ IsBoolFlag() bool }); (ok && boolFlag.IsBoolFlag()) || i+1 == len(args) {
+			split = append(split, flagArgs{name: name, args: []string{arg}})
+			continue
+		}
+
+		split = append(split, flagArgs{name: name, args: args[i : i+2]})
+		i++
+	}
+
+	return split
+}
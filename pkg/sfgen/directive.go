@@ -0,0 +1,207 @@
+package sfgen
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/google/shlex"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// RunLevelFlags are the flags of the go-sfgen command applying to the whole run rather than to a generate command,
+// such as --check. They may be combined with the flags of any generate command, and are ignored by
+// [Directive.Options].
+var RunLevelFlags = map[string]struct{}{"timeout": {}, "emit-bundle": {}, "interactive": {}, "dry-run": {}, "no-color": {}, "check": {}, "watch": {}}
+
+// Directive is a //go:generate directive running go-sfgen, either as an installed binary or through go run.
+type Directive struct {
+	// Path is the path of the Go file holding the directive.
+	Path string
+	// Package is the package clause of the file.
+	Package string
+	// Line is the 1-based line of the directive within the file.
+	Line int
+	// Args are the arguments go-sfgen is run with, with the environment variables go generate expands substituted.
+	Args []string
+}
+
+// ParseDirective parses line, the line at lineNum of the Go file at path whose package clause is pkgName, reporting
+// false if it is not a //go:generate directive running go-sfgen.
+func ParseDirective(line, path, pkgName string, lineNum int) (Directive, bool) {
+	if !strings.HasPrefix(line, "//go:generate ") {
+		return Directive{}, false
+	}
+
+	rest := os.Expand(strings.TrimPrefix(line, "//go:generate "), func(name string) string {
+		switch name {
+		case "GOFILE":
+			return filepath.Base(path)
+		case "GOPACKAGE":
+			return pkgName
+		case "GOLINE":
+			return strconv.Itoa(lineNum)
+		case "GOOS":
+			return runtime.GOOS
+		case "GOARCH":
+			return runtime.GOARCH
+		case "DOLLAR":
+			return "$"
+		}
+		return os.Getenv(name)
+	})
+
+	words, err := shlex.Split(rest)
+	if err != nil || len(words) == 0 {
+		return Directive{}, false
+	}
+
+	d := Directive{Path: path, Package: pkgName, Line: lineNum}
+	if filepath.Base(words[0]) == "go-sfgen" {
+		d.Args = words[1:]
+		return d, true
+	}
+
+	if len(words) > 2 && words[0] == "go" && words[1] == "run" {
+		for i, word := range words[2:] {
+			if pkg, _, _ := strings.Cut(word, "@"); pkg == modulePath {
+				d.Args = words[i+3:]
+				return d, true
+			}
+		}
+	}
+
+	return Directive{}, false
+}
+
+// FileDirectives returns the go-sfgen directives of the Go file at path. Files that cannot be parsed have none, as
+// go generate skips them as well.
+func FileDirectives(path string) ([]Directive, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := parser.ParseFile(token.NewFileSet(), path, src, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, nil
+	}
+
+	var (
+		directives []Directive
+		scanner    = bufio.NewScanner(bytes.NewReader(src))
+		line       int
+	)
+	for scanner.Scan() {
+		line++
+		if d, ok := ParseDirective(scanner.Text(), path, pkg.Name.Name, line); ok {
+			directives = append(directives, d)
+		}
+	}
+
+	return directives, scanner.Err()
+}
+
+// Options parses the arguments of the directive into its generate commands, as go-sfgen parses them when run by go
+// generate: relative paths and the [DefaultsFile] are resolved against the directory of the file, and --out-pkg
+// defaults to its package. Unlike go generate, neither the working directory nor the environment is changed, so
+// directives may be parsed concurrently, e.g. by analyzers.
+func (d Directive) Options() ([]FlagOptions, error) {
+	absPath, err := filepath.Abs(d.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path to %q: %w", d.Path, err)
+	}
+
+	dir := filepath.Dir(absPath)
+	defaults, err := ReadDefaults(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The package is provided as the first default, so the DefaultsFile and the arguments win over it, as they do over
+	// the GOPACKAGE default of the command
+	defaults = append([]string{"--out-pkg", d.Package}, defaults...)
+	source := fmt.Sprintf("%s.%s:%d", d.Package, filepath.Base(absPath), d.Line)
+	parse := func(args []string) (FlagOptions, error) {
+		f := FlagOptions{defaults: defaults}
+		if err := f.Parse(args); err != nil {
+			return f, err
+		}
+
+		f.resolvePaths(dir)
+		f.owner, f.source = absPath, source
+		return f, nil
+	}
+
+	var (
+		flagSet    = flag.NewFlagSet("sfgen", flag.ContinueOnError)
+		commands   []FlagOptions
+		configPath string
+	)
+	flagSet.SetOutput(new(bytes.Buffer))
+	flagSet.Func("gen", "", func(s string) error {
+		args, err := shlex.Split(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("failed to parse flag string: %w", err)
+		}
+
+		f, err := parse(args)
+		commands = append(commands, f)
+		return err
+	})
+	flagSet.StringVar(&configPath, "config", "", "")
+	flagSet.Duration("timeout", 0, "")
+	for name := range RunLevelFlags {
+		if name != "timeout" {
+			flagSet.Bool(name, false, "")
+		}
+	}
+	new(FlagOptions).RegisterFlags(flagSet)
+	if err := flagSet.Parse(d.Args); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case configPath != "":
+		if !filepath.IsAbs(configPath) {
+			configPath = filepath.Join(dir, configPath)
+		}
+
+		flagOptions, err := ParseConfig(configPath)
+		for i := range flagOptions {
+			flagOptions[i].source = source
+		}
+		return flagOptions, err
+	case len(commands) > 0:
+		return commands, nil
+	}
+
+	f, err := parse(CommandArgs(d.Args))
+	if err != nil {
+		return nil, err
+	}
+	return []FlagOptions{f}, nil
+}
+
+// CommandArgs returns args without the flags applying to the whole run, see [RunLevelFlags].
+func CommandArgs(args []string) []string {
+	var command []string
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if _, ok := RunLevelFlags[name]; !ok || !strings.HasPrefix(args[i], "-") {
+			command = append(command, args[i])
+			continue
+		}
+
+		if name == "timeout" && !hasValue { // the only run-level flag taking a value
+			i++
+		}
+	}
+	return command
+}
@@ -0,0 +1,167 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	EmitTypeScript = "ts"
+	EmitMarkdown   = "md"
+	// EmitJSON and EmitText write the constants as an asset, along with a Go file embedding it and providing an
+	// accessor, e.g. to serve the field catalog over an API without reflection.
+	EmitJSON = "json"
+	EmitText = "txt"
+)
+
+// Emitter writes the constants of a generate command in another language, from the same parse as the Go output.
+type Emitter struct {
+	// Lang is the language of the output, one of EmitTypeScript, EmitMarkdown, EmitJSON, or EmitText.
+	Lang string
+	// Path is the file the output is written to. Targets sharing a path are written to the same file.
+	Path string
+}
+
+// parseEmitter parses an --emit value of the form lang:path.
+func parseEmitter(s string) (Emitter, error) {
+	lang, path, ok := strings.Cut(s, ":")
+	if !ok || path == "" {
+		return Emitter{}, fmt.Errorf("invalid --emit value %q, expected lang:path", s)
+	}
+
+	switch lang {
+	case EmitTypeScript, EmitMarkdown, EmitJSON, EmitText:
+		return Emitter{Lang: lang, Path: path}, nil
+	default:
+		return Emitter{}, fmt.Errorf("invalid --emit language %q. Valid options are: %s, %s, %s, %s",
+			lang, EmitTypeScript, EmitMarkdown, EmitJSON, EmitText)
+	}
+}
+
+// emitterFiles returns the files of the --emit outputs of the targets of files, which must be sorted by path so the
+// outputs are stable across runs.
+func emitterFiles(files []FileResult) ([]FileResult, error) {
+	var (
+		outputs = make(map[string]*bytes.Buffer)
+		langs   = make(map[string]string)
+		paths   []string
+		// assets holds the targets of each json and txt asset, which are written once all of them are known
+		assets = make(map[string][]TargetResult)
+		// typeMaps caches the --type-map files by path, as commands commonly share one
+		typeMaps = make(map[string]typeMap)
+	)
+
+	for _, file := range files {
+		for _, target := range file.Targets {
+			for _, e := range target.Options.Emitters {
+				buf, ok := outputs[e.Path]
+				if !ok {
+					buf = new(bytes.Buffer)
+					outputs[e.Path], langs[e.Path] = buf, e.Lang
+					paths = append(paths, e.Path)
+					buf.WriteString(emitterHeader(e.Lang))
+				}
+
+				if langs[e.Path] != e.Lang {
+					return nil, fmt.Errorf("invalid --emit usage. Cannot write both %s and %s output to %q", langs[e.Path], e.Lang, e.Path)
+				}
+
+				m, ok := typeMaps[target.Options.TypeMap]
+				if !ok {
+					var err error
+					if m, err = readTypeMap(target.Options.TypeMap); err != nil {
+						return nil, err
+					}
+					typeMaps[target.Options.TypeMap] = m
+				}
+
+				switch e.Lang {
+				case EmitTypeScript:
+					writeTypeScript(buf, target, m)
+				case EmitMarkdown:
+					writeMarkdown(buf, target, m)
+				case EmitJSON, EmitText:
+					assets[e.Path] = append(assets[e.Path], target)
+				}
+			}
+		}
+	}
+
+	results := make([]FileResult, 0, len(paths))
+	// accessors holds the asset of each accessor name by --out-dir, as assets sharing a name, e.g. fields.json and
+	// fields.txt, would declare the same accessor in the package
+	accessors := make(map[string]string)
+	for _, path := range paths {
+		if targets, ok := assets[path]; ok {
+			f := targets[0].Options
+			key := f.OutputDir + "\x00" + assetAccessorName(f, path)
+			if other, ok := accessors[key]; ok {
+				return nil, fmt.Errorf("invalid --emit usage. The assets %q and %q would both declare the accessor %s in %s, rename one of them",
+					other, path, assetAccessorName(f, path), f.OutputDir)
+			}
+			accessors[key] = path
+
+			files, err := assetFiles(langs[path], path, targets)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, files...)
+			continue
+		}
+
+		results = append(results, FileResult{Path: path, Content: outputs[path].Bytes()})
+	}
+
+	return results, nil
+}
+
+func emitterHeader(lang string) string {
+	switch lang {
+	case EmitMarkdown:
+		return "<!-- Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT. -->\n"
+	case EmitJSON, EmitText:
+		return "" // assets are written by assetFiles
+	}
+	return "// Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT.\n"
+}
+
+// writeTypeScript writes the constants of target as a const object, along with a union type of its values, and an
+// interface of the types of the fields keyed by their values, as mapped by m.
+func writeTypeScript(buf *bytes.Buffer, target TargetResult, m typeMap) {
+	name := casedIdentifier(calculateBaseName(target.Options), true)
+	if name == "" {
+		name = target.Options.SourceStruct
+	}
+
+	buf.WriteString(fmt.Sprintf("\n/** Constants generated from the %s struct. */\n", target.Options.SourceStruct))
+	buf.WriteString(fmt.Sprintf("export const %s = {\n", name))
+	for _, field := range target.Fields {
+		buf.WriteString(fmt.Sprintf("  %s: %q,\n", field.Field, field.Value))
+	}
+	buf.WriteString("} as const;\n\n")
+	buf.WriteString(fmt.Sprintf("export type %s = (typeof %s)[keyof typeof %s];\n", name, name, name))
+
+	// Fields sharing a value are warned about when generating, and only the first is kept so the interface is valid
+	seen := make(map[string]struct{}, len(target.Fields))
+	buf.WriteString(fmt.Sprintf("\n/** The types of the %s struct fields, keyed by the values of %s. */\n", target.Options.SourceStruct, name))
+	buf.WriteString(fmt.Sprintf("export interface %sTypes {\n", name))
+	for _, field := range target.Fields {
+		if _, ok := seen[field.Value]; ok {
+			continue
+		}
+		seen[field.Value] = struct{}{}
+		buf.WriteString(fmt.Sprintf("  %q: %s;\n", field.Value, typeScriptType(m, field.goType)))
+	}
+	buf.WriteString("}\n")
+}
+
+// writeMarkdown writes the constants of target as a table, along with the types of their fields as mapped by m.
+func writeMarkdown(buf *bytes.Buffer, target TargetResult, m typeMap) {
+	buf.WriteString(fmt.Sprintf("\n## %s\n\n", target.Options.SourceStruct))
+	buf.WriteString("| Field | Type | Constant | Value |\n")
+	buf.WriteString("| --- | --- | --- | --- |\n")
+	for _, field := range target.Fields {
+		buf.WriteString(fmt.Sprintf("| %s | `%s` | `%s` | `%s` |\n", field.Field, markdownType(m, field.goType), field.Const, field.Value))
+	}
+}
@@ -0,0 +1,51 @@
+package sfgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateEmitAssets(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "assets with different names",
+			args: "--emit json:/out/fields.json --emit txt:/out/names.txt",
+			want: []string{"/out/fields_json_generated.go", "/out/names_txt_generated.go"},
+		},
+		{
+			name:    "assets sharing a name",
+			args:    "--emit json:/out/fields.json --emit txt:/out/fields.txt",
+			wantErr: `The assets "/out/fields.json" and "/out/fields.txt" would both declare the accessor Fields in /out`,
+		},
+		{
+			name:    "assets sharing a name in different directories",
+			args:    "--emit json:/out/a/fields.json --emit json:/out/b/fields.json",
+			wantErr: `The assets "/out/a/fields.json" and "/out/b/fields.json" would both declare the accessor Fields in /out`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := new(MemFS)
+			err := generateTest(t, fsys, testModels, testCommand{args: "--struct User --tag json " + tt.args})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+
+			for _, name := range tt.want {
+				readTestFile(t, fsys, name)
+			}
+		})
+	}
+}
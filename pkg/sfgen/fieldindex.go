@@ -0,0 +1,46 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// writeFieldIndex writes a map from each generated constant to the reflect index path of its field, so runtime code
+// can use reflect.Value.FieldByIndex rather than repeated FieldByName lookups. Fields that are not reachable through
+// an index path, such as the fields of oneof case wrappers, are omitted.
+func writeFieldIndex(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField) {
+	varName := baseName + "Index"
+	keyType := "string"
+	if f.Style == StyleAlias || f.Style == StyleTyped || f.intBased() {
+		keyType = baseName
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s maps the constants generated from [%s] to the reflect index paths of their fields.\n",
+		varName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("var %s = map[%s][]int{", varName, keyType))
+
+	seenValues := make(map[string]struct{})
+	for _, field := range fields {
+		if field.index == nil {
+			continue
+		}
+
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+
+		key := field.constName
+		if f.Style == StyleGeneric {
+			key = fmt.Sprintf("string(%s)", field.constName)
+		}
+
+		index := make([]string, len(field.index))
+		for i, idx := range field.index {
+			index[i] = fmt.Sprint(idx)
+		}
+		buf.WriteString(fmt.Sprintf("\n%s: {%s},", key, strings.Join(index, ", ")))
+	}
+	buf.WriteString("\n}\n")
+}
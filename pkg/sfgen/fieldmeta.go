@@ -0,0 +1,51 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// writeFieldMeta writes a map from each generated constant to a description of its field: the Go field name, the value
+// of every tag of the field, and its type, e.g. for admin UIs and query validators needing more than the constants.
+// The description is a struct type named after the generated type, e.g. UserFieldInfo.
+func writeFieldMeta(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField) {
+	var (
+		varName  = baseName + "Meta"
+		infoName = baseName + "Info"
+		keyType  = "string"
+	)
+	if f.Style == StyleAlias || f.Style == StyleTyped || f.intBased() {
+		keyType = baseName
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s describes the [%s] struct field a constant is generated from, see [%s].\n", infoName, f.SourceStruct, varName))
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", infoName))
+	buf.WriteString("// GoField is the name of the struct field.\nGoField string\n")
+	buf.WriteString("// Tags maps the keys of the struct tags of the field to their values, e.g. json to \"full_name,omitempty\".\nTags map[string]string\n")
+	buf.WriteString("// Type is the Go type of the field.\nType string\n}\n")
+
+	buf.WriteString(fmt.Sprintf("\n// %s maps the constants generated from [%s] to descriptions of their fields.\n", varName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("var %s = map[%s]%s{", varName, keyType, infoName))
+
+	seenValues := make(map[string]struct{})
+	for _, field := range fields {
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+
+		key := field.constName
+		if f.Style == StyleGeneric {
+			key = fmt.Sprintf("string(%s)", field.constName)
+		}
+
+		buf.WriteString(fmt.Sprintf("\n%s: {GoField: %q, Tags: map[string]string{", key, field.fieldName))
+		if field.tags != nil {
+			for _, t := range field.tags.Tags() {
+				buf.WriteString(fmt.Sprintf("%q: %q, ", t.Key, t.Value()))
+			}
+		}
+		buf.WriteString(fmt.Sprintf("}, Type: %q},", field.fieldType))
+	}
+	buf.WriteString("\n}\n")
+}
@@ -0,0 +1,75 @@
+package sfgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// loadFiles parses and type-checks the provided files as a single package, without go/packages. Imports are resolved
+// from source by go/build, which still invokes the go command to locate the packages of a module. Imports failing to
+// resolve are not an error, as only the field names and tags are required, so fields whose type cannot be resolved are
+// still generated. The failed imports are left incomplete in the imports of the package, see [unresolvedImports].
+func loadFiles(ctx context.Context, fset *token.FileSet, files []string) (*types.Package, error) {
+	parsed := make([]*ast.File, 0, len(files))
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		parsed = append(parsed, f)
+	}
+
+	if len(parsed) == 0 {
+		return nil, errors.New("no source files provided")
+	}
+
+	var (
+		pkgName = parsed[0].Name.Name
+		typeErr error
+		cfg     = types.Config{
+			Importer: importer.ForCompiler(fset, "source", nil),
+			Error: func(err error) {
+				var tErr types.Error
+				if errors.As(err, &tErr) && strings.Contains(tErr.Msg, "could not import") {
+					return
+				}
+
+				if typeErr == nil {
+					typeErr = err
+				}
+			},
+		}
+	)
+
+	pkg, _ := cfg.Check(pkgName, fset, parsed, nil)
+	if typeErr != nil {
+		return nil, fmt.Errorf("failed to type check %s: %w", strings.Join(files, ", "), typeErr)
+	}
+
+	return pkg, nil
+}
+
+// unresolvedImports returns the sorted paths of the imports of pkgs that failed to resolve, which the type checker
+// replaces by empty, incomplete, packages.
+func unresolvedImports(pkgs []*types.Package) []string {
+	var paths []string
+	for _, pkg := range pkgs {
+		for _, imp := range pkg.Imports() {
+			if !imp.Complete() {
+				paths = append(paths, imp.Path())
+			}
+		}
+	}
+	return uniqueSorted(paths)
+}
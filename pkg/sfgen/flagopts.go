@@ -0,0 +1,794 @@
+package sfgen
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/shlex"
+	"go/build/constraint"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	StyleTyped   = "typed"
+	StyleGeneric = "generic"
+	StyleAlias   = "alias"
+	StyleInt     = "int"
+	StyleBitmask = "bitmask"
+)
+
+const (
+	ModModeReadonly = "readonly"
+	ModModeVendor   = "vendor"
+	ModModeMod      = "mod"
+)
+
+type FlagOptions struct {
+	OutputFile              string
+	OutputDir               string
+	OutputPackage           string
+	SourceStruct            string
+	SourcePackage           string
+	SourceStructDir         string
+	SourceFiles             []string
+	Style                   string
+	Tag                     string
+	TagNameRegex            string
+	Prefix                  *string
+	Namespace               string
+	Interface               string
+	Export                  bool
+	MirrorExport            bool
+	UseStructName           bool
+	IncludeUnexportedFields bool
+	Iter                    bool
+	TagOptions              bool
+	ExpandOneofs            bool
+	LenientTags             bool
+	Vendor                  bool
+	ModMode                 string
+	ModFile                 string
+	Offline                 bool
+	Strict                  bool
+	SourceMap               bool
+	SymbolIndex             string
+	GuardTest               bool
+	ValuePattern            string
+	ASCIIIdentifiers        bool
+	FieldIndex              bool
+	UnsafeOffsets           string
+	Emitters                []Emitter
+	Stamp                   bool
+	ManagedRegion           bool
+	NoFormat                bool
+	SkipFields              []string
+	ScanDest                bool
+	MinGoVersion            string
+	Platforms               []string
+	IdentifierPattern       string
+	AllStructs              bool
+	StructRegex             string
+	ReceiverName            string
+	PerPackage              bool
+	NoStringer              bool
+	StringerFormat          string
+	GoString                bool
+	FoldMarkers             string
+	Template                string
+	MaxFileLines            int
+	GeneratedRoot           string
+	Gitattributes           bool
+	JSONPointer             bool
+	ParseFunc               bool
+	Bind                    bool
+	IterStyle               string
+	Bindings                string
+	TypeMap                 string
+	GenSet                  bool
+	Schema                  SchemaSource
+	IsValid                 bool
+	Chain                   []string
+	Provenance              bool
+	TextMarshaler           bool
+	FoldLookup              bool
+	JSONMarshaler           bool
+	LinkTags                []LinkTag
+	MapTags                 []TagMapping
+	FieldMeta               bool
+	IndexConsts             bool
+	Accessors               bool
+	Builder                 bool
+	Timings                 bool
+	FunctionalOptions       bool
+	StableIDs               string
+	SQLColumns              bool
+	SQLInsert               bool
+	AddFields               []string
+
+	// owner is the config file the options were parsed from, if any, see [ownedBlock]
+	owner string
+	// source is the package, file, and line of the directive the options were parsed from, if any, see
+	// [Directive.Options]. It defaults to the one go generate runs go-sfgen from.
+	source string
+	// defaults are the flags loaded by [FlagOptions.LoadDefaults]
+	defaults []string
+	// taggedOnly skips the fields without the --tag, for the commands of --bindings
+	taggedOnly bool
+}
+
+func (f *FlagOptions) ParseString(args string) error {
+	argSlice, err := shlex.Split(strings.TrimSpace(args))
+	if err != nil {
+		return fmt.Errorf("failed to parse flag string: %w", err)
+	}
+
+	return f.Parse(argSlice)
+}
+
+func (f *FlagOptions) Parse(args []string) error {
+	flagSet := flag.NewFlagSet("sfgen", flag.ContinueOnError)
+	f.RegisterFlags(flagSet)
+	if err := CheckFlags(flagSet, args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	if len(f.defaults) > 0 {
+		if err := CheckFlags(flagSet, f.defaults); err != nil {
+			return fmt.Errorf("failed to parse %s flags: %w", DefaultsFile, err)
+		}
+
+		args = mergeDefaults(flagSet, f.defaults, args)
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	return f.Validate()
+}
+
+func (f *FlagOptions) RegisterFlags(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&f.OutputFile, "out-file", "", `The file to write generated output to. Defaults to [--struct]_[prefix]_generated.go
+If the path is absolute, --out-dir is ignored`)
+	flagSet.StringVar(&f.OutputDir, "out-dir", ".", `The directory in which to place the generated file. Defaults to the current directory`)
+	flagSet.StringVar(&f.OutputPackage, "out-pkg", os.Getenv("GOPACKAGE"),
+		`The package the generated code should belong to. Defaults to the package containing the go:generate directive, or else to the
+package of the Go files in --out-dir, or the name of --out-dir for a new package`)
+	flagSet.Func("struct", `The struct to use as the source for code generation. REQUIRED, unless --all-structs is provided
+May be qualified by the name of the package in --src-dir, e.g. models.User`, func(s string) error {
+		f.SourcePackage, f.SourceStruct = "", s
+		if pkg, name, ok := strings.Cut(s, "."); ok {
+			f.SourcePackage, f.SourceStruct = pkg, name
+		}
+		return nil
+	})
+	flagSet.BoolVar(&f.AllStructs, "all-structs", false,
+		`If true, constants are generated for every named struct type of the --src-dir packages in place of a single --struct.
+The constants of each struct are prefixed with its name, as with --include-struct-name, and written to their own file unless --out-file is provided`)
+	flagSet.StringVar(&f.StructRegex, "struct-regex", "",
+		"This flag requires the --all-structs flag be provided as well. If provided, only the structs whose name matches this regex are generated from")
+	flagSet.StringVar(&f.SourceStructDir, "src-dir", ".",
+		`The directory containing the --struct. Defaults to the current directory.
+A pattern such as ./... searches every package below the directory for the --struct`)
+	flagSet.BoolVar(&f.PerPackage, "per-package", false,
+		`If true, the code generated from each struct is written to the directory of the package declaring it, and belongs to that package,
+in place of --out-dir and --out-pkg. E.g. --src-dir ./... --all-structs --per-package generates alongside every struct of a module`)
+	flagSet.StringVar(&f.GeneratedRoot, "generated-root", "",
+		`If provided, the code generated from each struct is written below this directory, to the path of the package declaring it within
+its module, and belongs to a package of the same name, in place of --out-dir and --out-pkg. E.g. generated/internal/models for internal/models`)
+	flagSet.BoolVar(&f.Gitattributes, "gitattributes", false,
+		`If true, the generated Go files are marked linguist-generated in the .gitattributes file of their directory, which is created or
+appended to as needed, so code review tools collapse them. --check reports missing entries`)
+	flagSet.Func("src-files", `A comma separated list of Go files containing the --struct. If provided, the files are loaded as a single package
+by type checking them rather than with go list, --src-dir is ignored, and --out-pkg defaults to the package of the files.
+Imports that fail to resolve are reported as warnings`, func(s string) error {
+		for _, file := range strings.Split(s, ",") {
+			if file = strings.TrimSpace(file); file != "" {
+				f.SourceFiles = append(f.SourceFiles, file)
+			}
+		}
+		return nil
+	})
+	flagSet.StringVar(&f.Tag, "tag", "",
+		`If provided, the provided tag will be parsed for each field on the --struct. 
+If the tag is missing, the struct field's name is used. 
+Otherwise, the first attribute in the tag is used as the name'
+xml tags follow the naming rules of encoding/xml, including nested paths and namespaces`)
+	flagSet.StringVar(&f.TagNameRegex, "tag-regex", "",
+		`This flag requires the --tag flag be provided as well. 
+The provided regex will be tested on the specified tag contents for each field.
+The first capture group will be used as the value for the generated constant. 
+If the regex does not match the tag contents, the struct field's' name will be used instead.`)
+
+	flagSet.StringVar(&f.Bindings, "bindings", "",
+		`If provided, constants are generated for each request binding tag of this web framework used by the struct, in place of --tag,
+e.g. uriField, formField, and headerField for Gin. Valid options are: gin, for the uri, form, and header tags, and echo, for the param,
+query, form, and header tags. Fields without the tag are skipped`)
+	flagSet.BoolVar(&f.GenSet, "gen-set", false,
+		`If true, a map backed [prefix]Set type of the constants is generated, along with a New[prefix]Set constructor and its Contains, Add,
+and Delete methods. Requires a style other than generic`)
+	flagSet.BoolVar(&f.Bind, "bind", false,
+		`If true, a [prefix]Bind function setting the fields of the struct from url.Values keyed by the constants, e.g. the query parameters
+of an HTTP request with --tag query, is generated. Fields of string, bool, and numeric kinds, and slices of them, are bound without reflection`)
+	flagSet.BoolVar(&f.FoldLookup, "fold-lookup", false,
+		`If true, a [type]FromValueFold function returning the constant holding a value regardless of case, e.g. of query parameters or
+CSV headers, is generated along with a map of the lower-cased values`)
+	flagSet.BoolVar(&f.ParseFunc, "parse", false,
+		"If true, a Parse[type] function returning the constant holding a string value, or an error for unknown values, is generated for the typed, generic, int, and bitmask styles")
+	flagSet.BoolVar(&f.JSONPointer, "json-pointer", false,
+		`If true, a [const]Pointer constant holding the RFC 6901 JSON Pointer of each field, e.g. /address, is generated for use with JSON Patch.
+The fields of struct fields are followed, e.g. /address/city`)
+	flagSet.BoolVar(&f.TagOptions, "tag-options", false,
+		`This flag requires the --tag flag be provided as well.
+If true, a [prefix]Options function will be generated, returning the options of the --tag for a given constant. E.g. omitempty`)
+
+	flagSet.Func("prefix", "A value to prepend to the generated const names. Defaults to [tag]Field", func(s string) error {
+		if f.Prefix != nil {
+			return errors.New("invalid --prefix usage, flag may only be specified once")
+		}
+		f.Prefix = &s
+		return nil
+	})
+	flagSet.StringVar(&f.Template, "template", "",
+		`If provided, the code of the command is generated by executing this text/template file with a sfgen.TemplateData in place of the
+built-in styles. A template made only of {{define}} actions instead overrides the "type", "consts", or "decls" partials of the
+built-in code, which {{builtin "name"}} renders. See the README for the functions available to templates`)
+	flagSet.StringVar(&f.Style, "style", "", `Specifies the style of constants desired. Valid options are: alias, typed, generic, int, bitmask`)
+	flagSet.StringVar(&f.ReceiverName, "receiver-name", "",
+		"The receiver name of the methods generated for the typed, generic, int, and bitmask styles, e.g. field. Defaults to the lower-cased first character of the type name")
+	flagSet.BoolVar(&f.Export, "export", false, "If true, the generated constants will be exported")
+	flagSet.BoolVar(&f.MirrorExport, "mirror-export", false, "If true, aliases of the generated constants using the opposite casing of --export will also be generated")
+	flagSet.BoolVar(&f.UseStructName, "include-struct-name", false, "If true, the generated constants will be prefixed with the source struct name")
+	flagSet.BoolVar(&f.IncludeUnexportedFields, "include-unexported-fields", false, "If true, the generated constants will include fields that are not exported on the struct")
+	flagSet.StringVar(&f.Namespace, "namespace", "",
+		`If provided, the generated constants will also be grouped under a package level var with this name, nested by struct name.
+All commands sharing a namespace must write to the same output file`)
+	flagSet.BoolVar(&f.NoStringer, "no-stringer", false,
+		"If true, no String method is generated for the typed, generic, int, and bitmask styles, so that one may be declared alongside the struct")
+	flagSet.StringVar(&f.StringerFormat, "stringer-format", "",
+		"If provided, the String method generated for the typed, generic, int, and bitmask styles returns the value formatted with this fmt format, e.g. 'db:%s'")
+	flagSet.BoolVar(&f.IsValid, "is-valid", false,
+		"If true, an IsValid method reporting whether the value is held by one of the constants is generated for the typed, generic, int, and bitmask styles")
+	flagSet.BoolVar(&f.Provenance, "provenance", false,
+		"If true, a Source method returning the struct, Go field, and tag the constant holding the value is generated from is generated for the typed, generic, int, and bitmask styles")
+	flagSet.BoolVar(&f.TextMarshaler, "text-marshaler", false,
+		`If true, MarshalText and UnmarshalText methods are generated for the typed, generic, int, and bitmask styles, so the type may be
+decoded from JSON, YAML, or flags. UnmarshalText rejects values no constant holds`)
+	flagSet.BoolVar(&f.JSONMarshaler, "json-marshaler", false,
+		`If true, MarshalJSON and UnmarshalJSON methods rejecting values no constant holds are generated for the typed, generic, int, and
+bitmask styles, so the type may be used directly in API request structs. The bits of the bitmask style are marshaled as an array`)
+	flagSet.Func("link-tag", `Generates a method of the generated type returning the value of another tag of the field of each constant, in the form
+tag[:Method], e.g. db:Column, linking the representations of a field. May be repeated. The method is named after the tag by default,
+and returns the field name for fields without the tag. Used with the typed, generic, int, and bitmask styles`, func(s string) error {
+		link, err := parseLinkTag(s)
+		if err != nil {
+			return err
+		}
+		f.LinkTags = append(f.LinkTags, link)
+		return nil
+	})
+	flagSet.Func("map-tags", `Generates a function translating the constants of the --tag into those generated from the same struct with another tag,
+in the form from:to, e.g. json:db generating JSONToDB(c JSONField) (DBField, bool), backed by a map. The from tag must be the --tag,
+and the constants of the to tag must be generated by another command with the same style and naming flags. May be repeated`, func(s string) error {
+		m, err := parseTagMapping(s)
+		if err != nil {
+			return err
+		}
+		f.MapTags = append(f.MapTags, m)
+		return nil
+	})
+	flagSet.BoolVar(&f.GoString, "gostring", false,
+		"If true, a GoString method returning the name of the constant holding the value is generated for the typed, generic, int, and bitmask styles, so %#v prints it")
+	flagSet.StringVar(&f.Interface, "interface", "",
+		`If provided, an interface with this name will be generated and implemented by the generated type.
+Requires the typed, generic, int, or bitmask style. All commands sharing an interface must write to the same output file`)
+	flagSet.BoolVar(&f.ExpandOneofs, "expand-oneofs", false, "If true, protobuf oneof fields are replaced by the fields of each of their generated case wrappers")
+	flagSet.BoolVar(&f.LenientTags, "lenient-tags", false,
+		"If true, the --tag is extracted from malformed struct tags that fail strict parsing, rather than falling back to the field name")
+	flagSet.BoolVar(&f.Vendor, "vendor", false,
+		"If true, packages are loaded from the vendor directory of the module (-mod=vendor), without accessing the network. Shorthand for --mod-mode vendor")
+	flagSet.StringVar(&f.ModMode, "mod-mode", "", "The -mod build flag used when loading the --src-dir package. Valid options are: readonly, vendor, mod")
+	flagSet.StringVar(&f.ModFile, "modfile", "",
+		`If provided, the -modfile build flag used when loading the --src-dir package, e.g. tools.go.mod, for modules resolving their
+dependencies from an alternate go.mod file. GOFLAGS, e.g. GOFLAGS=-modfile=tools.go.mod, are respected as well, and are overridden by this flag`)
+	flagSet.BoolVar(&f.Offline, "offline", false,
+		"If true, the module proxy is disabled (GOPROXY=off) while loading packages, so generation fails rather than downloading missing dependencies")
+	flagSet.BoolVar(&f.SourceMap, "source-map", false,
+		"If true, each generated constant is followed by a comment with the file:line of its source field, relative to --out-dir")
+	flagSet.Func("emit", `Writes the generated constants in another language to a path, in the form lang:path, e.g. ts:web/fields.ts.
+May be repeated. Valid languages are: ts, md, json, txt. Commands sharing a path are written to the same file.
+json and txt assets must be within --out-dir, where a [asset]_[lang]_generated.go file embedding them and providing an accessor is written`, func(s string) error {
+		e, err := parseEmitter(s)
+		if err != nil {
+			return err
+		}
+		f.Emitters = append(f.Emitters, e)
+		return nil
+	})
+	flagSet.StringVar(&f.TypeMap, "type-map", "",
+		`If provided, the path to a JSON file mapping Go types to the types the --emit languages represent them with, keyed by the Go type
+qualified by its import path and then by language, e.g. {"github.com/google/uuid.UUID": {"ts": "string"}}. Used by the ts and md emitters`)
+	flagSet.Func("schema", `If provided, generation fails unless the values of the constants match the columns or properties of an external schema,
+in the form kind:path[#name], e.g. sql:db/schema.sql#users. Valid kinds are: sql, for the columns of a CREATE TABLE statement,
+jsonschema, for the properties of a JSON Schema or of one of its $defs, proto, for the fields of a .proto message, and db, for the
+columns of a table of a live database, e.g. 'db:$DATABASE_URL#users', queried with its psql, mysql, or sqlite3 client`, func(s string) error {
+		schema, err := parseSchemaSource(s)
+		if err != nil {
+			return err
+		}
+		f.Schema = schema
+		return nil
+	})
+	flagSet.Func("chain", `A command run in the --out-dir once the generated files are written, e.g. 'mockgen -source=$GOFILE -destination=mocks.go',
+so generators consuming the constants regenerate in the same pass rather than relying on the order of go:generate directives.
+May be repeated, and commands are run in order. GOFILE and GOPACKAGE are set to the output file and its package, as by go generate.
+Not run by --dry-run, --emit-bundle, or --check`, func(s string) error {
+		if _, err := parseChain(s); err != nil {
+			return err
+		}
+		f.Chain = append(f.Chain, s)
+		return nil
+	})
+	flagSet.BoolVar(&f.ManagedRegion, "managed-region", false,
+		`If true, the generated code is placed between the "// sfgen:region begin" and "// sfgen:region end" lines of the existing
+--out-file, leaving the rest of the file, e.g. maintained by hand or by another generator, untouched`)
+	flagSet.StringVar(&f.FoldMarkers, "fold-markers", "",
+		`If provided, the code generated by each command is wrapped in markers editors can collapse it with. Valid options are:
+region, folded by VS Code and GoLand, and editor-fold, folded by GoLand`)
+	flagSet.IntVar(&f.MaxFileLines, "max-file-lines", 0,
+		`If provided, an --out-file longer than this many lines is split into numbered files of the same package, e.g.
+user_generated_2.go, each holding the code of whole commands`)
+	flagSet.BoolVar(&f.NoFormat, "no-format", false,
+		"If true, generated code that fails to format is written unformatted with a warning rather than failing, to help diagnose bugs")
+	flagSet.StringVar(&f.MinGoVersion, "min-go", "",
+		"If provided, the command is skipped with a notice when the Go toolchain is older than this version, e.g. 1.23")
+	flagSet.Func("platform", `A comma separated list of GOOS or GOOS/GOARCH values, e.g. linux,darwin/arm64.
+If provided, the command is skipped with a notice when the target platform is not listed`, func(s string) error {
+		for _, platform := range strings.Split(s, ",") {
+			if platform = strings.TrimSpace(platform); platform != "" {
+				f.Platforms = append(f.Platforms, platform)
+			}
+		}
+		return nil
+	})
+	flagSet.BoolVar(&f.Accessors, "accessors", false,
+		`If true, Get[type] and Set[type] methods of the struct getting and setting the field a constant was generated from are generated,
+e.g. func (u *User) GetField(field Field) any, as a switch over the constants rather than through reflection. The output must belong
+to the package of the struct`)
+	flagSet.BoolVar(&f.Builder, "builder", false,
+		`If true, a [struct]Builder type with a fluent With[field] setter for each field the constants are generated from is generated,
+e.g. NewUserBuilder().WithEmail(email).Build()`)
+	flagSet.BoolVar(&f.FunctionalOptions, "functional-options", false,
+		`If true, a New[struct] constructor taking functional options is generated, along with a With[field] option for each exported field
+the constants are generated from, e.g. NewUser(WithEmail(email))`)
+	flagSet.BoolVar(&f.SQLColumns, "sql-columns", false,
+		`If true, a [struct]Columns constant listing the values of the fields as comma separated SQL columns in declaration order is generated,
+e.g. UserColumns = "id, email, created_at", along with a [struct]ColumnNames []string var. Requires a --tag, typically db`)
+	flagSet.BoolVar(&f.SQLInsert, "sql-insert", false,
+		`If true, [struct]InsertColumns() and [struct]InsertPlaceholders(dialect) functions returning matching column and placeholder lists
+are generated for INSERT statements, e.g. (id, email) and ($1, $2) for postgres or (?, ?) for mysql. Requires a --tag, typically db`)
+	flagSet.BoolVar(&f.ScanDest, "scan-dest", false,
+		"If true, a [prefix]ScanDest function returning pointers to the fields selected by a list of constants, in order, is generated for use with sql.Rows.Scan")
+	flagSet.Func("skip-fields", `A comma separated list of --struct field names to skip, as if they were tagged sfgen:"-".
+Fields of embedded structs with a listed name are skipped as well`, func(s string) error {
+		for _, name := range strings.Split(s, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				f.SkipFields = append(f.SkipFields, name)
+			}
+		}
+		return nil
+	})
+	flagSet.Func("add-fields", `A comma separated list of structs of the --src-dir package whose fields are generated along with those of the --struct,
+or of their fields, of the form Struct.Field, e.g. Account.ID. May be repeated. The fields are parsed with the same flags as those of the --struct,
+including --skip-fields, except for the fields listed explicitly`, func(s string) error {
+		added, err := parseAddedFields(s)
+		if err != nil {
+			return err
+		}
+		f.AddFields = append(f.AddFields, added...)
+		return nil
+	})
+	flagSet.BoolVar(&f.Stamp, "stamp", false,
+		"If true, the generator version and a hash of the normalized flags are stamped into the header of the generated file")
+	flagSet.StringVar(&f.UnsafeOffsets, "unsafe-offsets", "",
+		`If provided, a [out-file]_unsafe.go file guarded by this build constraint, e.g. sfgen_unsafe, is generated.
+It declares a [const]Offset constant holding the unsafe.Offsetof of the field of each constant, for zero-reflection field access.
+Fields behind a pointer are omitted`)
+	flagSet.BoolVar(&f.FieldIndex, "field-index", false,
+		"If true, a [prefix]Index map from each constant to the reflect index path of its field is generated, for use with reflect.Value.FieldByIndex")
+	flagSet.BoolVar(&f.IndexConsts, "index-consts", false,
+		`If true, a [prefix]Index[field] int constant holding the reflect field index of each field declared directly in the struct is
+generated, for use with reflect.Value.Field, along with a [prefix]Indices map from each constant to its index`)
+	flagSet.BoolVar(&f.FieldMeta, "field-meta", false,
+		`If true, a [prefix]Meta map from each constant to a [prefix]Info describing its field is generated, holding the Go field name,
+the value of every tag of the field, and its type, e.g. for admin UIs and query validators`)
+	flagSet.BoolVar(&f.ASCIIIdentifiers, "ascii-identifiers", false,
+		"If true, non-ASCII characters are transliterated when building generated identifiers. Constant values are preserved verbatim")
+	flagSet.StringVar(&f.IdentifierPattern, "identifier-pattern", "",
+		`If provided, generation fails if any package level identifier generated, e.g. a constant, type, or function, does not match this regex.
+E.g. '^(Col|col)[A-Z]' enforces a naming policy when set in a shared .sfgen or config file`)
+	flagSet.StringVar(&f.ValuePattern, "value-pattern", "",
+		"If provided, generation fails if the value of any generated constant does not match this regex, e.g. '^[a-z_]+$'")
+	flagSet.BoolVar(&f.GuardTest, "guard-test", false,
+		`If true, a [out-file]_guard_test.go file asserting the values of the generated constants is written alongside them.
+The file is only written when absent, so renamed values fail its test until it is deleted and regenerated`)
+	flagSet.StringVar(&f.SymbolIndex, "symbol-index", "",
+		`If provided, a JSON index mapping each generated constant to its struct, field, tag, and value is written to this path.
+All commands sharing a path are written to the same index`)
+	flagSet.StringVar(&f.StableIDs, "stable-ids", "",
+		`If provided, a stable int ID is generated for each constant, along with maps from the constants to their IDs and back. The IDs are
+persisted in a JSON file at this path, so a field keeps its ID when it is renamed, e.g. for persisted values. Each command needs its own path`)
+	flagSet.BoolVar(&f.Strict, "strict", false,
+		"If true, warnings such as malformed tags falling back to the field name, skipped fields, or duplicate values fail generation")
+	flagSet.BoolVar(&f.Timings, "timings", false,
+		"If true, the durations of the load, parse, render, format, and write phases of the command are logged once its output is written. They are not recorded or sent anywhere else")
+	flagSet.BoolVar(&f.Iter, "iter", false, "if true, an All() method will be generated for the type, which returns an array of all the values generated, along with Names() and Values() returning the constant names and values as strings")
+	flagSet.StringVar(&f.IterStyle, "iter-style", "",
+		`If provided, the return type of the All(), Names(), and Values() methods generated with --iter. Valid options are: array, the default, returning an
+array of the values, typed-array, returning an array of the constants, and seq, returning an iter.Seq of the constants, which requires Go 1.23`)
+}
+
+// resolvePaths resolves the relative source and output paths of the options against dir.
+func (f *FlagOptions) resolvePaths(dir string) {
+	resolve := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	f.SourceStructDir = resolve(f.SourceStructDir)
+	f.OutputDir = resolve(f.OutputDir)
+	if f.SymbolIndex != "" {
+		f.SymbolIndex = resolve(f.SymbolIndex)
+	}
+	if f.Template != "" {
+		f.Template = resolve(f.Template)
+	}
+	if f.GeneratedRoot != "" {
+		f.GeneratedRoot = resolve(f.GeneratedRoot)
+	}
+	if f.ModFile != "" {
+		f.ModFile = resolve(f.ModFile)
+	}
+	if f.StableIDs != "" {
+		f.StableIDs = resolve(f.StableIDs)
+	}
+	if f.TypeMap != "" {
+		f.TypeMap = resolve(f.TypeMap)
+	}
+	if f.Schema.Path != "" && f.Schema.Kind != SchemaDB {
+		f.Schema.Path = resolve(f.Schema.Path)
+	}
+	for i, e := range f.Emitters {
+		f.Emitters[i].Path = resolve(e.Path)
+	}
+	for i, file := range f.SourceFiles {
+		f.SourceFiles[i] = resolve(file)
+	}
+}
+
+// BuildFlags returns the build flags used when loading the --src-dir package. They are passed to the go command along
+// with GOFLAGS, which they take precedence over. The --modfile is made absolute, as the go command resolves it against
+// its working directory.
+func (f *FlagOptions) BuildFlags() []string {
+	var flags []string
+	if f.Vendor {
+		flags = append(flags, "-mod="+ModModeVendor)
+	} else if f.ModMode != "" {
+		flags = append(flags, "-mod="+f.ModMode)
+	}
+
+	if f.ModFile != "" {
+		modFile, err := filepath.Abs(f.ModFile)
+		if err != nil {
+			modFile = f.ModFile
+		}
+		flags = append(flags, "-modfile="+modFile)
+	}
+	return flags
+}
+
+// LoadEnv returns the environment used when loading the --src-dir package. A nil value means the current
+// environment is used as is.
+func (f *FlagOptions) LoadEnv() []string {
+	if f.Offline {
+		return append(os.Environ(), "GOPROXY=off")
+	}
+	return nil
+}
+
+// skipsField reports whether the field with the given name is listed in --skip-fields.
+func (f *FlagOptions) skipsField(name string) bool {
+	for _, skipped := range f.SkipFields {
+		if skipped == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateStyleFlags returns an error if f sets a flag that cannot be used with its style.
+func (f *FlagOptions) validateStyleFlags() error {
+	var (
+		methodStyles = []string{StyleTyped, StyleGeneric, StyleInt, StyleBitmask}
+		sharedStyles = []string{"", StyleAlias, StyleTyped, StyleInt, StyleBitmask}
+	)
+
+	// Reasons are given as the end of a sentence, following the error
+	styleFlags := []struct {
+		Name   string
+		Set    bool
+		Styles []string
+		Reason string
+	}{
+		{Name: "iter", Set: f.Iter, Styles: []string{"", StyleTyped, StyleGeneric, StyleInt, StyleBitmask}},
+		{Name: "interface", Set: f.Interface != "", Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "receiver-name", Set: f.ReceiverName != "", Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "no-stringer", Set: f.NoStringer, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "stringer-format", Set: f.StringerFormat != "", Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "parse", Set: f.ParseFunc, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "is-valid", Set: f.IsValid, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "provenance", Set: f.Provenance, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "text-marshaler", Set: f.TextMarshaler, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "json-marshaler", Set: f.JSONMarshaler, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "link-tag", Set: len(f.LinkTags) > 0, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "gostring", Set: f.GoString, Styles: methodStyles, Reason: "as it declares no methods"},
+		{Name: "gen-set", Set: f.GenSet, Styles: sharedStyles, Reason: "as the constants of each field have their own type"},
+		{Name: "map-tags", Set: len(f.MapTags) > 0, Styles: sharedStyles, Reason: "as the constants of each field have their own type"},
+	}
+
+	for _, sf := range styleFlags {
+		allowed := !sf.Set
+		for _, style := range sf.Styles {
+			allowed = allowed || style == f.Style
+		}
+		if allowed {
+			continue
+		}
+
+		style := f.Style
+		if style == "" {
+			style = "default"
+		}
+
+		err := fmt.Sprintf("invalid style %s: the %s style cannot be used with the --%s flag", style, style, sf.Name)
+		if sf.Reason != "" {
+			err += ", " + sf.Reason
+		}
+		return errors.New(err)
+	}
+
+	return nil
+}
+
+// hasMethods reports whether the style of f declares a type that methods, such as String, are generated for.
+func (f *FlagOptions) hasMethods() bool {
+	return f.Style == StyleTyped || f.Style == StyleGeneric || f.Style == StyleInt || f.Style == StyleBitmask
+}
+
+// intBased reports whether the style of f declares integer constants, which hold the position or bit of their field
+// rather than its value, as with the int and bitmask styles.
+func (f *FlagOptions) intBased() bool {
+	return f.Style == StyleInt || f.Style == StyleBitmask
+}
+
+func (f *FlagOptions) Validate() error {
+	if f.Tag == "" && len(f.TagNameRegex) > 0 {
+		return fmt.Errorf("cannot use tag regex %q with an empty tag", f.TagNameRegex)
+	}
+
+	if f.SourcePackage != "" && (!token.IsIdentifier(f.SourcePackage) || !token.IsIdentifier(f.SourceStruct)) {
+		return fmt.Errorf("--struct must be of the form [package.]Struct, got %q", f.SourcePackage+"."+f.SourceStruct)
+	}
+
+	if f.ValuePattern != "" {
+		if _, err := regexp.Compile(f.ValuePattern); err != nil {
+			return fmt.Errorf("invalid --value-pattern %q: %w", f.ValuePattern, err)
+		}
+	}
+
+	if f.AllStructs && f.SourceStruct != "" {
+		return errors.New("cannot use --struct with --all-structs")
+	}
+
+	if f.Bindings != "" && f.Tag != "" {
+		return errors.New("cannot use --tag with --bindings, as the tags are those of the framework")
+	}
+
+	if f.Bindings != "" && f.Prefix != nil {
+		return errors.New("cannot use --prefix with --bindings, as the constants of every tag would share it")
+	}
+
+	if f.AllStructs && len(f.AddFields) > 0 {
+		return errors.New("cannot use --add-fields with --all-structs")
+	}
+
+	if f.AllStructs && f.Prefix != nil {
+		return errors.New("cannot use --prefix with --all-structs, as the constants of every struct would share it")
+	}
+
+	if f.StructRegex != "" && !f.AllStructs {
+		return errors.New("cannot use --struct-regex without --all-structs")
+	}
+
+	if f.StructRegex != "" {
+		if _, err := regexp.Compile(f.StructRegex); err != nil {
+			return fmt.Errorf("invalid --struct-regex %q: %w", f.StructRegex, err)
+		}
+	}
+
+	if f.PerPackage && filepath.IsAbs(f.OutputFile) {
+		return errors.New("cannot use an absolute --out-file with --per-package, as it is written to the directory of each package")
+	}
+
+	if f.PerPackage && f.GeneratedRoot != "" {
+		return errors.New("cannot use --per-package with --generated-root")
+	}
+
+	if f.GeneratedRoot != "" && filepath.IsAbs(f.OutputFile) {
+		return errors.New("cannot use an absolute --out-file with --generated-root, as it is written to the mirrored directory of each package")
+	}
+
+	if f.IterStyle != "" && !f.Iter {
+		return errors.New("cannot use --iter-style without --iter")
+	}
+
+	if f.TypeMap != "" && len(f.Emitters) == 0 {
+		return errors.New("cannot use --type-map without --emit")
+	}
+
+	if f.MaxFileLines < 0 {
+		return fmt.Errorf("--max-file-lines must not be negative, got %d", f.MaxFileLines)
+	}
+
+	if f.MaxFileLines > 0 && f.ManagedRegion {
+		return errors.New("cannot use --max-file-lines with --managed-region, as the region must be within a single file")
+	}
+
+	if f.IdentifierPattern != "" {
+		if _, err := regexp.Compile(f.IdentifierPattern); err != nil {
+			return fmt.Errorf("invalid --identifier-pattern %q: %w", f.IdentifierPattern, err)
+		}
+	}
+
+	if f.UnsafeOffsets != "" {
+		if _, err := constraint.Parse("//go:build " + f.UnsafeOffsets); err != nil {
+			return fmt.Errorf("invalid --unsafe-offsets build constraint %q: %w", f.UnsafeOffsets, err)
+		}
+	}
+
+	if f.Prefix != nil && *f.Prefix == "" && f.Style != "" {
+		return fmt.Errorf("--prefix must not be empty when using the %s style", f.Style)
+	}
+
+	if f.MinGoVersion != "" && !goVersionPattern.MatchString(f.MinGoVersion) {
+		return fmt.Errorf("--min-go must be a Go version such as 1.23, got %q", f.MinGoVersion)
+	}
+
+	if f.Vendor && f.ModMode != "" && f.ModMode != ModModeVendor {
+		return fmt.Errorf("cannot use --vendor with --mod-mode %s", f.ModMode)
+	}
+
+	// The go command rejects other extensions, as it derives the go.sum file from the name of the --modfile
+	if f.ModFile != "" && filepath.Ext(f.ModFile) != ".mod" {
+		return fmt.Errorf("--modfile %s must have a .mod extension", f.ModFile)
+	}
+
+	if f.Tag == "" && f.TagOptions {
+		return errors.New("cannot use --tag-options with an empty tag")
+	}
+
+	if f.ReceiverName != "" && (!token.IsIdentifier(f.ReceiverName) || f.ReceiverName == "_") {
+		return fmt.Errorf("--receiver-name must be a valid identifier, got %q", f.ReceiverName)
+	}
+
+	if f.Namespace != "" && !token.IsIdentifier(f.Namespace) {
+		return fmt.Errorf("--namespace must be a valid identifier, got %q", f.Namespace)
+	}
+
+	if f.Interface != "" && !token.IsIdentifier(f.Interface) {
+		return fmt.Errorf("--interface must be a valid identifier, got %q", f.Interface)
+	}
+
+	if err := f.validateStyleFlags(); err != nil {
+		return err
+	}
+
+	if f.NoStringer && f.StringerFormat != "" {
+		return errors.New("cannot use --stringer-format with --no-stringer")
+	}
+
+	// The --interface requires the String method, which the type would otherwise not implement
+	if f.NoStringer && f.Interface != "" {
+		return errors.New("cannot use --interface with --no-stringer, as the interface requires the String method")
+	}
+
+	if f.SQLColumns && f.Tag == "" {
+		return errors.New("--sql-columns requires a --tag holding the column names, e.g. db")
+	}
+
+	if f.SQLInsert && f.Tag == "" {
+		return errors.New("--sql-insert requires a --tag holding the column names, e.g. db")
+	}
+
+	for _, m := range f.MapTags {
+		if m.From != f.Tag {
+			return fmt.Errorf("invalid --map-tags %s:%s. The constants are mapped from the --tag %q", m.From, m.To, f.Tag)
+		}
+	}
+
+	if f.StringerFormat != "" && strings.Contains(fmt.Sprintf(f.StringerFormat, "value"), "%!") {
+		return fmt.Errorf("--stringer-format must format a single string, e.g. 'db:%%s', got %q", f.StringerFormat)
+	}
+
+	type flagNameToValue struct {
+		Name     string
+		Value    string
+		Required bool
+		NotEmpty bool
+		OneOf    map[string]struct{}
+	}
+
+	validations := []flagNameToValue{
+		{
+			Name:  "style",
+			Value: f.Style,
+			OneOf: map[string]struct{}{"": {}, StyleAlias: {}, StyleTyped: {}, StyleGeneric: {}, StyleInt: {}, StyleBitmask: {}},
+		},
+		{
+			Name:  "bindings",
+			Value: f.Bindings,
+			OneOf: map[string]struct{}{"": {}, BindingsGin: {}, BindingsEcho: {}},
+		},
+		{
+			Name:  "fold-markers",
+			Value: f.FoldMarkers,
+			OneOf: map[string]struct{}{"": {}, FoldRegion: {}, FoldEditorFold: {}},
+		},
+		{
+			Name:  "iter-style",
+			Value: f.IterStyle,
+			OneOf: map[string]struct{}{"": {}, IterArray: {}, IterTypedArray: {}, IterSeq: {}},
+		},
+		{
+			Name:  "mod-mode",
+			Value: f.ModMode,
+			OneOf: map[string]struct{}{"": {}, ModModeReadonly: {}, ModModeVendor: {}, ModModeMod: {}},
+		},
+		{
+			Name:     "struct",
+			Value:    f.SourceStruct,
+			Required: !f.AllStructs,
+		},
+		{
+			Name:     "src-dir",
+			Value:    f.SourceStructDir,
+			NotEmpty: true,
+		},
+	}
+
+	var err error
+	for _, v := range validations {
+		if v.Required && v.Value == "" {
+			err = fmt.Errorf("--%s is required\n%s", v.Name, err)
+		}
+
+		if v.NotEmpty && v.Value == "" {
+			err = fmt.Errorf("--%s must not be empty\n%s", v.Name, err)
+		}
+
+		if v.OneOf != nil {
+			_, ok := v.OneOf[v.Value]
+			if !ok {
+				err = fmt.Errorf("--%s must be one of %+v\n%s", v.Name, v.OneOf, err)
+			}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,56 @@
+package sfgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateStyleFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{
+			name:    "method flags require a style declaring a type",
+			args:    "--parse",
+			wantErr: "invalid style default: the default style cannot be used with the --parse flag, as it declares no methods",
+		},
+		{
+			name:    "the first flag not supported by the style is reported",
+			args:    "--style alias --is-valid --gostring",
+			wantErr: "invalid style alias: the alias style cannot be used with the --is-valid flag, as it declares no methods",
+		},
+		{
+			name:    "iter is not supported by the alias style",
+			args:    "--style alias --iter",
+			wantErr: "invalid style alias: the alias style cannot be used with the --iter flag",
+		},
+		{
+			name:    "shared types are not supported by the generic style",
+			args:    "--style generic --gen-set",
+			wantErr: "invalid style generic: the generic style cannot be used with the --gen-set flag, as the constants of each field have their own type",
+		},
+		{
+			name: "flags supported by the style are accepted",
+			args: "--style typed --parse --is-valid --gen-set --iter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f FlagOptions
+			err := f.ParseString("--struct User --tag json " + tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("failed to parse %q: %v", tt.args, err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package sfgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Styles of the --fold-markers wrapping the code generated by each command, so that editors can collapse it.
+const (
+	// FoldRegion markers are folded by VS Code and the JetBrains IDEs, e.g. GoLand.
+	FoldRegion = "region"
+	// FoldEditorFold markers are folded by the JetBrains IDEs, which also show their description when collapsed.
+	FoldEditorFold = "editor-fold"
+)
+
+// foldCode returns code wrapped in the --fold-markers of f, titled title. The markers are separated from code by blank
+// lines, so they are not mistaken for the doc comment of its first declaration.
+func foldCode(f FlagOptions, title, code string) string {
+	var begin, end string
+	switch f.FoldMarkers {
+	case FoldRegion:
+		begin, end = "//region "+title, "//endregion"
+	case FoldEditorFold:
+		begin, end = fmt.Sprintf("//<editor-fold desc=%q>", title), "//</editor-fold>"
+	default:
+		return code
+	}
+
+	return begin + "\n\n" + strings.TrimSpace(code) + "\n\n" + end + "\n"
+}
@@ -0,0 +1,62 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// foldLookupFuncName returns the name of the --fold-lookup function of the type baseName, e.g. UserFieldFromValueFold,
+// exported along with the type.
+func foldLookupFuncName(f FlagOptions, baseName string) string {
+	return casedIdentifier(baseName+"FromValueFold", f.Export)
+}
+
+// writeFoldLookup writes a function returning the constant holding a value regardless of case, e.g. for query
+// parameters and CSV headers, along with the map of lower-cased values it looks them up in. When several constants
+// share a value regardless of case, the first one is returned. The function returns strings for the alias style and
+// the constants declared without a style, and, with the generic style, values converted to the type parameter of the
+// caller. It returns the imports the function requires.
+func writeFoldLookup(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField) []string {
+	var (
+		funcName = foldLookupFuncName(f, baseName)
+		mapName  = casedIdentifier(baseName+"FoldValues", false)
+		typeName = baseName
+		elemType = baseName
+		seen     = make(map[string]struct{}, len(fields))
+	)
+	switch {
+	case f.Style == StyleGeneric:
+		typeName += "[T]"
+		elemType = "string"
+	case !f.hasMethods():
+		typeName, elemType = "string", "string"
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s maps the lower-cased values of the constants to the constants, see [%s].\n", mapName, funcName))
+	buf.WriteString(fmt.Sprintf("var %s = map[string]%s{\n", mapName, elemType))
+	for _, field := range fields {
+		key := strings.ToLower(field.constValue)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		value := field.constName
+		if f.Style == StyleGeneric {
+			value = fmt.Sprintf("%q", field.constValue)
+		}
+		buf.WriteString(fmt.Sprintf("%q: %s,\n", key, value))
+	}
+	buf.WriteString("}\n")
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns the constant holding the value s regardless of case, and whether there is one.\n", funcName))
+	if f.Style == StyleGeneric {
+		buf.WriteString(fmt.Sprintf("func %s[T any](s string) (%s, bool) {\n", funcName, typeName))
+		buf.WriteString(fmt.Sprintf("v, ok := %s[strings.ToLower(s)]\nreturn %s(v), ok\n}\n", mapName, typeName))
+	} else {
+		buf.WriteString(fmt.Sprintf("func %s(s string) (%s, bool) {\n", funcName, typeName))
+		buf.WriteString(fmt.Sprintf("v, ok := %s[strings.ToLower(s)]\nreturn v, ok\n}\n", mapName))
+	}
+	return []string{"strings"}
+}
@@ -0,0 +1,110 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+// funcOptionsNames returns the names of the --functional-options constructor and option type of the source struct,
+// e.g. NewUser and UserOption, exported along with the constants.
+func funcOptionsNames(f FlagOptions) (ctorName, optionType string) {
+	return casedIdentifier("New"+casedIdentifier(f.SourceStruct, true), f.Export), casedIdentifier(f.SourceStruct+"Option", f.Export)
+}
+
+// writeFuncOptions writes a constructor of the source struct taking functional options, e.g. NewUser(opts
+// ...UserOption), along with a With[field] option for each exported field the constants are generated from, named
+// after the field as the constants are, e.g. WithEmail, or WithUserEmail with --include-struct-name. Fields that cannot
+// be reached without a pointer indirection, or whose type cannot be referenced from the output package, are skipped
+// with a warning. It returns the imports the declarations require.
+func writeFuncOptions(buf *bytes.Buffer, f FlagOptions, structPkg *types.Package, s *types.Struct, fields []parsedField, warn *warnings) []string {
+	var (
+		ctorName, optionType = funcOptionsNames(f)
+		structType           = f.SourceStruct
+		external             = structPkg.Name() != f.OutputPackage
+		imports              []string
+	)
+	if external {
+		structType = structPkg.Name() + "." + structType
+		imports = append(imports, structPkg.Path())
+	}
+	qualifier := func(p *types.Package) string {
+		if p == structPkg && !external {
+			return ""
+		}
+		imports = append(imports, p.Path())
+		return p.Name()
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s sets a field of the [%s] returned by [%s].\n", optionType, structType, ctorName))
+	buf.WriteString(fmt.Sprintf("type %s func(*%s)\n", optionType, structType))
+	buf.WriteString(fmt.Sprintf("\n// %s returns a new %s with opts applied in order.\n", ctorName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("func %s(opts ...%s) *%s {\n", ctorName, optionType, structType))
+	buf.WriteString(fmt.Sprintf("s := new(%s)\nfor _, opt := range opts {\nopt(s)\n}\nreturn s\n}\n", structType))
+
+	for _, field := range fields {
+		// Only exported fields get an option, whether or not the struct belongs to the output package
+		selectors, ok := offsetSelectors(s, field.index, true)
+		if !ok {
+			warn.add("skipped the functional option of %s field %s, as it is unexported or cannot be reached without a pointer indirection",
+				f.SourceStruct, field.fieldName)
+			continue
+		}
+
+		fieldType := fieldByIndex(s, field.index).Type()
+		if external && !referenceable(fieldType) {
+			warn.add("skipped the functional option of %s field %s, as its type cannot be referenced from package %s", f.SourceStruct, field.fieldName, f.OutputPackage)
+			continue
+		}
+
+		name := "With" + field.identName
+		if f.UseStructName {
+			name = "With" + casedIdentifier(f.SourceStruct, true) + field.identName
+		}
+		name = casedIdentifier(name, f.Export)
+		buf.WriteString(fmt.Sprintf("\n// %s returns the [%s] setting the %s field.\n", name, optionType, strings.Join(selectors, ".")))
+		buf.WriteString(fmt.Sprintf("func %s(value %s) %s {\n", name, types.TypeString(fieldType, qualifier), optionType))
+		buf.WriteString(fmt.Sprintf("return func(s *%s) {\ns.%s = value\n}\n}\n", structType, strings.Join(selectors, ".")))
+	}
+	return imports
+}
+
+// checkFuncOptionNames returns an error if the --functional-options of different structs generated into the same
+// package declare the same option, e.g. WithEmail for both User and Account, which would not compile. Such structs
+// need --include-struct-name to name their options after the struct.
+func checkFuncOptionNames(files []FileResult) error {
+	declared := make(map[string]string)
+	for _, file := range files {
+		for _, target := range file.Targets {
+			f := target.Options
+			if !f.FunctionalOptions || f.UseStructName {
+				continue
+			}
+
+			names, err := declaredIdentifiers(target.Code)
+			if err != nil {
+				return fmt.Errorf("failed to check the functional options of %s: %w", f.SourceStruct, err)
+			}
+
+			structName := f.SourceStruct
+			if f.SourcePackage != "" {
+				structName = f.SourcePackage + "." + structName
+			}
+			for _, name := range names {
+				if !strings.HasPrefix(name, casedIdentifier("With", f.Export)) {
+					continue
+				}
+
+				key := filepath.Dir(file.Path) + "\x00" + name
+				if other, ok := declared[key]; ok && other != structName {
+					return fmt.Errorf("the functional option %s of %s is also generated for %s in package %s, use --include-struct-name to name the options after their struct",
+						name, structName, other, file.Package)
+				}
+				declared[key] = structName
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1093 @@
+package sfgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/fatih/structtag"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// generateFileGroup calls generateCodeForFileGroup, converting any panic into an error so that embedders of the
+// library never need to recover from one. The stack of the panic is logged, so that it can still be reported.
+func (g *Generator) generateFileGroup(ctx context.Context, flagOptions []FlagOptions) (files []FileResult, removed []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.logger.Printf("panic generating %s: %v\n%s", flagOptions[0].OutputFile, r, debug.Stack())
+			files, removed, err = nil, nil, fmt.Errorf("internal error generating %s: %v", flagOptions[0].OutputFile, r)
+		}
+	}()
+
+	return g.generateCodeForFileGroup(ctx, flagOptions)
+}
+
+// generateCodeForFileGroup generates the code for all the options sharing a single output file. The output file is
+// returned first, followed by its other --max-file-lines parts, its --unsafe-offsets file, and the --stable-ids files of
+// its commands, if any. The surplus parts of a --max-file-lines output that shrank are returned as removed.
+func (g *Generator) generateCodeForFileGroup(ctx context.Context, flagOptions []FlagOptions) ([]FileResult, []string, error) {
+	if len(flagOptions) == 0 {
+		return nil, nil, nil
+	}
+
+	var (
+		err      error
+		outPkg   = flagOptions[0].OutputPackage
+		outFile  = flagOptions[0].OutputFile
+		imports  = make([][]string, len(flagOptions))
+		contents = make([][]byte, len(flagOptions))
+		members  []namespaceMember
+		targets  = make([]TargetResult, len(flagOptions))
+		offsets  unsafeOffsetsFile
+		sidecars []FileResult
+	)
+
+	for i, fOpt := range flagOptions {
+		if err = ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		var target parsedTarget
+		if target, err = g.parsePackage(ctx, fOpt); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse struct: %w", err)
+		}
+
+		if w := target.result.Warnings; fOpt.Strict && len(w) > 0 {
+			return nil, nil, fmt.Errorf("%s: %d warning(s) treated as errors by --strict: %s",
+				fOpt.SourceStruct, len(w), strings.Join(w, "; "))
+		}
+
+		contents[i], imports[i], targets[i] = target.code, target.imports, target.result
+		if err = offsets.add(fOpt, target); err != nil {
+			return nil, nil, err
+		}
+		if target.member != nil {
+			members = append(members, *target.member)
+		}
+		if fOpt.StableIDs != "" {
+			sidecars = append(sidecars, FileResult{Path: fOpt.StableIDs, Content: target.stableIDs})
+		}
+	}
+
+	blocks := make([]ownedBlock, 0, len(flagOptions)+1)
+	for i, fOpt := range flagOptions {
+		blocks = append(blocks, ownedBlock{
+			owner:   blockOwner(fOpt),
+			target:  targetBlockID(fOpt),
+			imports: imports[i],
+			code:    foldCode(fOpt, fOpt.SourceStruct+" "+calculateBaseName(fOpt), string(contents[i])),
+		})
+	}
+
+	shared := new(bytes.Buffer)
+	writeInterfaces(shared, flagOptions)
+	if err = writeNamespaceVars(shared, members); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate namespace: %w", err)
+	}
+	if shared.Len() > 0 {
+		blocks = append(blocks, ownedBlock{
+			owner:  blockOwner(flagOptions[0]),
+			target: sharedBlockTarget,
+			code:   foldCode(flagOptions[0], "shared declarations", shared.String()),
+		})
+	}
+
+	existing, ok, err := readFile(g.fs, outFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read existing out file %s: %w", outFile, err)
+	}
+
+	// The blocks of the other owners of a --max-file-lines output may be in any of its parts
+	var parts [][]byte
+	if flagOptions[0].MaxFileLines > 0 {
+		if parts, err = readParts(g.fs, outFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if ok {
+		if blocks, err = mergeOwnedBlocks(bytes.Join(append([][]byte{existing}, parts...), nil), outPkg, blocks); err != nil {
+			return nil, nil, fmt.Errorf("failed to merge into %s: %w", outFile, err)
+		}
+	}
+
+	var content []byte
+	if flagOptions[0].ManagedRegion {
+		if !ok {
+			return nil, nil, fmt.Errorf("--managed-region out file %s does not exist", outFile)
+		}
+
+		if content, err = replaceManagedRegion(existing, blocks); err != nil {
+			return nil, nil, fmt.Errorf("failed to update the managed region of %s: %w", outFile, err)
+		}
+	} else if ok && bytes.Contains(existing, []byte(regionBegin)) {
+		return nil, nil, fmt.Errorf("out file %s has a managed region, use --managed-region to write to it", outFile)
+	} else if content, err = writeOutputFile(flagOptions, outPkg, blocks); err != nil {
+		return nil, nil, err
+	}
+
+	// Formatting in process avoids depending on the go command being available, e.g. within build sandboxes
+	formatStart := time.Now()
+	formatted, err := format.Source(content)
+	formatTime := time.Since(formatStart)
+	for i := range targets {
+		targets[i].Timings.Format = formatTime
+	}
+	if err != nil && flagOptions[0].NoFormat {
+		targets[0].Warnings = append(targets[0].Warnings,
+			fmt.Sprintf("failed to format generated code for %s, writing it unformatted: %v", outFile, err))
+		formatted = content
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to format generated code for %s, use --no-format to write it unformatted for inspection: %w", outFile, err)
+	}
+
+	files := []FileResult{{
+		Path:    outFile,
+		Package: outPkg,
+		Content: formatted,
+		Targets: targets,
+	}}
+	var removed []string
+	if flagOptions[0].MaxFileLines > 0 {
+		// Surplus parts are removed, or kept without blocks when the FS cannot remove files, so they still compile
+		existingParts := len(parts)
+		if canRemove(g.fs) {
+			existingParts = 0
+		}
+
+		if files, err = splitOutputFile(flagOptions, outPkg, blocks, formatted, targets, existingParts); err != nil {
+			return nil, nil, err
+		}
+		for n := len(files) + 1; n <= len(parts)+1; n++ {
+			removed = append(removed, partPath(outFile, n))
+		}
+	}
+
+	if offsets.code.Len() > 0 {
+		offsetsFile, err := offsets.generate(outFile, outPkg)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, offsetsFile)
+	}
+
+	return append(files, sidecars...), removed, nil
+}
+
+// writeOutputFile returns the complete content of an output file holding blocks, generated with flagOptions.
+func writeOutputFile(flagOptions []FlagOptions, outPkg string, blocks []ownedBlock) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("// Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT.\n")
+	for _, fOpt := range flagOptions {
+		if !fOpt.Stamp {
+			continue
+		}
+
+		stamp, err := newStamp(flagOptions)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(stamp.String() + "\n")
+		break
+	}
+	buf.WriteByte('\n')
+	if srcFiles := flagOptions[0].SourceFiles; len(srcFiles) > 0 {
+		buf.WriteString(fmt.Sprintf("// Source %s\n\n", strings.Join(srcFiles, ", ")))
+	} else if source := flagOptions[0].source; source != "" {
+		buf.WriteString(fmt.Sprintf("// Source %s\n\n", source))
+	} else {
+		buf.WriteString(fmt.Sprintf("// Source %s.%s:%s\n\n",
+			os.Getenv("GOPACKAGE"), os.Getenv("GOFILE"), os.Getenv("GOLINE")))
+	}
+	buf.WriteString(fmt.Sprintf("package %s\n", outPkg))
+	seenImport := make(map[string]struct{})
+	hasWrittenImportHeader := false
+	for _, block := range blocks {
+	InnerLoop:
+		for _, imp := range block.imports {
+			if _, ok := seenImport[imp]; ok {
+				continue InnerLoop
+			}
+
+			seenImport[imp] = struct{}{}
+			if !hasWrittenImportHeader {
+				buf.WriteString("\nimport (\n")
+				hasWrittenImportHeader = true
+			}
+
+			buf.WriteByte('"')
+			buf.WriteString(imp)
+			buf.WriteByte('"')
+			buf.WriteByte('\n')
+		}
+
+	}
+	if hasWrittenImportHeader {
+		buf.WriteString(")\n")
+	}
+
+	for _, block := range blocks {
+		writeOwnedBlock(buf, block)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parsedTarget is the generated code of a single generate command, along with its description.
+type parsedTarget struct {
+	code    []byte
+	imports []string
+	member  *namespaceMember
+	result  TargetResult
+	// offsets is the code of the --unsafe-offsets file, if any
+	offsets        []byte
+	offsetsImports []string
+	// stableIDs is the updated content of the --stable-ids file, if any
+	stableIDs []byte
+}
+
+func (g *Generator) parsePackage(ctx context.Context, f FlagOptions) (parsedTarget, error) {
+	// Options may be generated without being parsed by library users
+	if err := f.validateStyleFlags(); err != nil {
+		return parsedTarget{}, err
+	}
+
+	start := time.Now()
+	structPkg, s, err := g.loadStruct(f.SourceStructDir, f.SourcePackage, f.SourceStruct)
+	if err != nil {
+		return parsedTarget{}, err
+	}
+	structPackage := structPkg.Path()
+
+	var (
+		// the imports of the type declaration and its methods, of the constants, and of the declarations following
+		// them, which are kept apart for the partials of a --template
+		typeImports    []string
+		constImports   []string
+		declImports    []string
+		warn           = append(warnings(nil), g.loadWarnings[f.SourceStructDir]...)
+		outBuf         bytes.Buffer
+		constBuf       bytes.Buffer
+		closeConstants = func() {
+			constBuf.WriteString("\n)")
+		}
+	)
+
+	baseName := calculateBaseName(f)
+	if baseName == "" && f.Style != "" {
+		return parsedTarget{}, fmt.Errorf("--prefix of %s must not be empty when using the %s style", f.SourceStruct, f.Style)
+	}
+
+	receiver := receiverName(f, baseName)
+
+	if f.Style != "" {
+		outBuf.WriteString(fmt.Sprintf("// %s is a strong type generated from %s. Its type is used for all of its related generated constants.\n", baseName, f.SourceStruct))
+	}
+
+	switch f.Style {
+	case StyleAlias:
+		outBuf.WriteString(fmt.Sprintf("type %s = string\n", baseName))
+	case StyleTyped:
+		outBuf.WriteString(fmt.Sprintf("type %s string\n", baseName))
+		typeImports = append(typeImports, writeStringer(&outBuf, f, baseName, receiver)...)
+		if f.Interface != "" {
+			outBuf.WriteString(fmt.Sprintf("// %s implements the [%s] interface\n", interfaceMarkerMethod(f.Interface), f.Interface))
+			outBuf.WriteString(fmt.Sprintf("func (%s) %s() {}\n", baseName, interfaceMarkerMethod(f.Interface)))
+		}
+	case StyleInt, StyleBitmask:
+		// The String method is written once the fields are known, as it switches on their constants
+		if f.Style == StyleBitmask {
+			outBuf.WriteString(fmt.Sprintf("type %s uint64\n", baseName))
+		} else {
+			outBuf.WriteString(fmt.Sprintf("type %s int\n", baseName))
+		}
+		if f.Interface != "" {
+			outBuf.WriteString(fmt.Sprintf("// %s implements the [%s] interface\n", interfaceMarkerMethod(f.Interface), f.Interface))
+			outBuf.WriteString(fmt.Sprintf("func (%s) %s() {}\n", baseName, interfaceMarkerMethod(f.Interface)))
+		}
+	case StyleGeneric:
+		outBuf.WriteString(fmt.Sprintf("type %s[T any] string\n", baseName))
+		typeImports = append(typeImports, writeStringer(&outBuf, f, baseName+"[T]", receiver)...)
+		if f.Interface != "" {
+			outBuf.WriteString(fmt.Sprintf("// %s implements the [%s] interface\n", interfaceMarkerMethod(f.Interface), f.Interface))
+			outBuf.WriteString(fmt.Sprintf("func (%s[T]) %s() {}\n", baseName, interfaceMarkerMethod(f.Interface)))
+		}
+	}
+
+	fields, err := g.parseStructFields(f, structPackage, baseName, s, &warn)
+	if err != nil {
+		return parsedTarget{}, err
+	}
+
+	if len(f.AddFields) > 0 {
+		added, err := g.parseAddedStructFields(f, structPackage, baseName, fields, &warn)
+		if err != nil {
+			return parsedTarget{}, err
+		}
+		fields = append(fields, added...)
+	}
+
+	if err = checkValuePattern(f, fields); err != nil {
+		return parsedTarget{}, err
+	}
+
+	if err = checkSchema(ctx, f, fields); err != nil {
+		return parsedTarget{}, err
+	}
+
+	if f.Style == StyleBitmask && len(fields) > maxBitmaskFields {
+		return parsedTarget{}, fmt.Errorf("%s has %d fields, but the %s style holds at most %d", f.SourceStruct, len(fields), StyleBitmask, maxBitmaskFields)
+	}
+	parseTime := time.Since(start)
+
+	seenValues := make(map[string]string, len(fields))
+	for _, field := range fields {
+		if other, ok := seenValues[field.constValue]; ok {
+			warn.add("%s fields %s and %s both generate the value %q", f.SourceStruct, other, field.fieldName, field.constValue)
+			continue
+		}
+		seenValues[field.constValue] = field.fieldName
+	}
+
+	for i, field := range fields {
+		if f.Style == StyleGeneric {
+			constImports = append(constImports, field.requiredImports...)
+		}
+
+		if constBuf.Len() == 0 {
+			constBuf.WriteByte('\n')
+			constBuf.WriteString(fmt.Sprintf("// Constants generated from [%s] struct field\n", f.SourceStruct))
+			constBuf.WriteString("const (")
+		} else {
+			constBuf.WriteByte('\n')
+		}
+
+		if f.intBased() {
+			constBuf.WriteString(intConstSpec(f, field, i))
+		} else {
+			constBuf.WriteString(constSpec(f, field, field.constName, field.constValue))
+		}
+		if f.SourceMap {
+			constBuf.WriteString(" // " + sourceMapLocation(f.OutputDir, field.position))
+		}
+		if i == len(fields)-1 {
+			closeConstants()
+		}
+	}
+
+	switch f.Style {
+	case StyleInt:
+		typeImports = append(typeImports, writeIntStringer(&outBuf, f, baseName, receiver, fields)...)
+	case StyleBitmask:
+		typeImports = append(typeImports, writeBitmaskMethods(&outBuf, f, baseName, receiver, fields)...)
+	}
+
+	if f.Iter {
+		typeName := baseName
+		if f.Style == StyleGeneric {
+			typeName += "[T]"
+		}
+		typeImports = append(typeImports, writeAll(&outBuf, f, baseName, typeName, receiver, fields)...)
+		typeImports = append(typeImports, writeNamesAndValues(&outBuf, f, baseName, typeName, receiver, fields)...)
+	}
+
+	if f.GoString {
+		typeName := baseName
+		if f.Style == StyleGeneric {
+			typeName += "[T]"
+		}
+		typeImports = append(typeImports, writeGoString(&outBuf, f, typeName, receiver, fields)...)
+	}
+
+	if f.IsValid {
+		typeName := baseName
+		if f.Style == StyleGeneric {
+			typeName += "[T]"
+		}
+		writeIsValid(&outBuf, f, typeName, receiver, fields)
+	}
+
+	if f.Provenance {
+		typeName := baseName
+		if f.Style == StyleGeneric {
+			typeName += "[T]"
+		}
+		writeSource(&outBuf, f, typeName, receiver, fields)
+	}
+
+	if f.TextMarshaler {
+		typeName := baseName
+		if f.Style == StyleGeneric {
+			typeName += "[T]"
+		}
+		typeImports = append(typeImports, writeTextMarshaler(&outBuf, f, baseName, typeName, receiver, fields)...)
+	}
+
+	if f.JSONMarshaler {
+		typeName := baseName
+		if f.Style == StyleGeneric {
+			typeName += "[T]"
+		}
+		typeImports = append(typeImports, writeJSONMarshaler(&outBuf, f, baseName, typeName, receiver, fields)...)
+	}
+
+	for _, link := range f.LinkTags {
+		typeName := baseName
+		if f.Style == StyleGeneric {
+			typeName += "[T]"
+		}
+		writeLinkTag(&outBuf, f, link, typeName, receiver, fields)
+	}
+
+	typeEnd := outBuf.Len()
+	if _, err = constBuf.WriteTo(&outBuf); err != nil {
+		return parsedTarget{}, fmt.Errorf("failed to write full contents in memory: %w", err)
+	}
+
+	constEnd := outBuf.Len()
+
+	writeFormerValueConstants(&outBuf, f, fields)
+
+	if f.FieldIndex {
+		writeFieldIndex(&outBuf, f, baseName, fields)
+	}
+
+	if f.IndexConsts {
+		writeIndexConsts(&outBuf, f, baseName, fields)
+	}
+
+	if f.FieldMeta {
+		writeFieldMeta(&outBuf, f, baseName, fields)
+	}
+
+	var stableIDs []byte
+	if f.StableIDs != "" {
+		var ids []int
+		if ids, stableIDs, err = g.assignStableIDs(f, fields); err != nil {
+			return parsedTarget{}, err
+		}
+		writeStableIDs(&outBuf, f, baseName, fields, ids)
+	}
+
+	if f.JSONPointer {
+		if err = g.writeJSONPointers(&outBuf, f, structPackage, s, fields, &warn); err != nil {
+			return parsedTarget{}, err
+		}
+	}
+
+	if f.TagOptions {
+		writeTagOptionsFunc(&outBuf, f, baseName, fields)
+	}
+
+	if f.ParseFunc {
+		declImports = append(declImports, writeParseFunc(&outBuf, f, baseName, fields)...)
+	}
+
+	if f.FoldLookup {
+		declImports = append(declImports, writeFoldLookup(&outBuf, f, baseName, fields)...)
+	}
+
+	for _, m := range f.MapTags {
+		writeTagMapping(&outBuf, f, m, baseName, fields)
+	}
+
+	if f.GenSet {
+		writeSet(&outBuf, f, baseName)
+	}
+
+	if f.SQLColumns {
+		writeSQLColumns(&outBuf, f, fields)
+	}
+
+	if f.SQLInsert {
+		writeSQLInsert(&outBuf, f, fields)
+	}
+
+	if f.ScanDest {
+		declImports = append(declImports, writeScanDest(&outBuf, f, baseName, structPkg, s, fields)...)
+	}
+
+	if f.Bind {
+		declImports = append(declImports, writeBinder(&outBuf, f, baseName, structPkg, s, fields, &warn)...)
+	}
+
+	if f.Builder {
+		declImports = append(declImports, writeBuilder(&outBuf, f, structPkg, s, fields, &warn)...)
+	}
+
+	if f.FunctionalOptions {
+		declImports = append(declImports, writeFuncOptions(&outBuf, f, structPkg, s, fields, &warn)...)
+	}
+
+	if f.Accessors {
+		imports, err := writeAccessors(&outBuf, f, baseName, structPkg, s, fields, &warn)
+		if err != nil {
+			return parsedTarget{}, err
+		}
+		declImports = append(declImports, imports...)
+	}
+
+	if f.MirrorExport {
+		writeMirroredConstants(&outBuf, f, baseName, fields)
+	}
+
+	var (
+		offsets        bytes.Buffer
+		offsetsImports []string
+	)
+	if f.UnsafeOffsets != "" {
+		offsetsImports = writeUnsafeOffsets(&offsets, f, baseName, structPkg, s, fields)
+	}
+
+	var member *namespaceMember
+	if f.Namespace != "" {
+		outBuf.WriteByte('\n')
+		m := writeNamespaceType(&outBuf, f, fields)
+		member = &m
+	}
+
+	code, imports := outBuf.Bytes(), append(append(typeImports, constImports...), declImports...)
+
+	// A --template generates the code of the command, possibly from the built-in sections it does not override
+	if f.Template != "" {
+		code, imports, err = executeTemplate(f, baseName, fields, map[string]templateSection{
+			templateTypeSection:   {code: string(outBuf.Bytes()[:typeEnd]), imports: typeImports},
+			templateConstsSection: {code: string(outBuf.Bytes()[typeEnd:constEnd]), imports: constImports},
+			templateDeclsSection:  {code: string(outBuf.Bytes()[constEnd:]), imports: declImports},
+		})
+		if err != nil {
+			return parsedTarget{}, err
+		}
+	}
+
+	if err = checkIdentifierPattern(f, code, offsets.Bytes()); err != nil {
+		return parsedTarget{}, err
+	}
+
+	return parsedTarget{
+		code:    code,
+		imports: imports,
+		member:  member,
+		result: TargetResult{
+			Options:  f,
+			Fields:   generatedFields(fields),
+			Code:     formatDecls(code),
+			Imports:  uniqueSorted(imports),
+			Warnings: warn,
+			Timings: Timings{
+				Load:   g.loadTimes[f.SourceStructDir],
+				Parse:  parseTime,
+				Render: time.Since(start) - parseTime,
+			},
+		},
+		offsets:        offsets.Bytes(),
+		offsetsImports: offsetsImports,
+		stableIDs:      stableIDs,
+	}, nil
+}
+
+type parsedField struct {
+	parseFieldResult
+	baseName string
+	// index is the reflect index path of the field within the source struct, or nil if the field is not reachable
+	// through it, e.g. fields of oneof case wrappers
+	index []int
+	// sourceStruct is the struct an --add-fields field is declared in, or empty for the fields of the source struct
+	sourceStruct string
+}
+
+// constSpec returns the declaration of a constant named name with the type of field in the style of f. With the int
+// and bitmask styles, whose constants hold the index or bit of their field rather than value, it is declared as the
+// constant of field, see [intConstSpec].
+func constSpec(f FlagOptions, field parsedField, name, value string) string {
+	switch f.Style {
+	case StyleInt, StyleBitmask:
+		return fmt.Sprintf("%s = %s", name, field.constName)
+	case StyleAlias, StyleTyped:
+		return fmt.Sprintf("%s %s = %q", name, field.baseName, value)
+	case StyleGeneric:
+		return fmt.Sprintf("%s %s[%s] = %q", name, field.baseName, field.fieldType, value)
+	default:
+		return fmt.Sprintf("%s = %q", name, value)
+	}
+}
+
+func fieldIsEmbeddedStruct(f *types.Var) (*types.Struct, bool) {
+	if !f.Embedded() {
+		return nil, false
+	}
+
+	return underlyingStruct(f.Type())
+}
+
+// underlyingStruct resolves pointers and named types to the struct type they refer to, if any.
+func underlyingStruct(t types.Type) (*types.Struct, bool) {
+	for {
+		switch v := t.(type) {
+		case *types.Pointer:
+			t = v.Elem()
+		case *types.Named:
+			t = t.Underlying()
+		case *types.Struct:
+			return v, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+func (g *Generator) parseStructFields(f FlagOptions, structPackage, baseName string, s *types.Struct, warn *warnings) ([]parsedField, error) {
+	var (
+		topLevelFields = make(map[string]struct{})
+		fields         []parsedField
+		embeddedFields []parsedField
+	)
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		if !f.IncludeUnexportedFields && !field.Exported() {
+			continue
+		}
+
+		if f.skipsField(field.Name()) {
+			continue
+		}
+
+		tag := s.Tag(i)
+		parseFieldResult, err := g.parseField(structPackage, field, tag, baseName, f, warn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s field %s at %s: %w", f.SourceStruct, field.Name(), g.objectPosition(field), err)
+		}
+
+		if parseFieldResult.constValue == "-" { // Handle the case that the field is ignored
+			continue
+		}
+
+		if wrappers, ok := oneofWrappers(field, tag); ok && f.ExpandOneofs {
+			for _, w := range wrappers {
+				caseFields, err := g.parseStructFields(f, structPackage, baseName, w, warn)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, caseField := range caseFields {
+					caseField.index = nil
+					fields = append(fields, caseField)
+					topLevelFields[caseField.constName] = struct{}{}
+				}
+			}
+			continue
+		}
+
+		if fieldIsInlined(f, parseFieldResult.tagOptions) {
+			structType, ok := underlyingStruct(field.Type())
+			if !ok { // Inlined maps have no fixed keys to generate constants for
+				warn.add("skipped inlined %s field %s at %s, as it is not a struct", f.SourceStruct, field.Name(), g.objectPosition(field))
+				continue
+			}
+
+			embFields, err := g.parseStructFields(f, structPackage, baseName, structType, warn)
+			if err != nil {
+				return nil, err
+			}
+
+			embeddedFields = append(embeddedFields, nestedFields(i, embFields)...)
+			continue
+		}
+
+		if structType, ok := fieldIsEmbeddedStruct(field); ok {
+			embFields, err := g.parseStructFields(f, structPackage, baseName, structType, warn)
+			if err != nil {
+				return nil, err
+			}
+
+			embeddedFields = append(embeddedFields, nestedFields(i, embFields)...)
+			continue
+		}
+
+		if _, ok := reflect.StructTag(tag).Lookup(f.Tag); f.taggedOnly && !ok {
+			continue
+		}
+
+		baseName = casedIdentifier(baseName, f.Export)
+		fields = append(fields, parsedField{
+			parseFieldResult: parseFieldResult,
+			baseName:         baseName,
+			index:            []int{i},
+		})
+		topLevelFields[parseFieldResult.constName] = struct{}{}
+	}
+
+	for _, field := range embeddedFields {
+		_, ok := topLevelFields[field.constName]
+		if ok {
+			continue
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// nestedFields prefixes the index paths of the fields of the struct field at index i with i.
+func nestedFields(i int, fields []parsedField) []parsedField {
+	for j, field := range fields {
+		if field.index != nil {
+			fields[j].index = append([]int{i}, field.index...)
+		}
+	}
+	return fields
+}
+
+type parseFieldResult struct {
+	// identName is the field name as used within generated identifiers, see [FlagOptions.identifier]
+	fieldName, identName, fieldType, constName, constValue string
+	requiredImports, tagOptions, formerValues              []string
+	position                                               token.Position
+	goType                                                 types.Type
+	// tags are the parsed struct tags of the field, e.g. to look up the values of --link-tag tags
+	tags *structtag.Tags
+}
+
+func (g *Generator) parseField(structPackage string, field *types.Var, tag, baseName string, f FlagOptions, warn *warnings) (parseFieldResult, error) {
+	tags, err := structtag.Parse(tag)
+	if err != nil && f.LenientTags {
+		tags = lenientParseTags(tag, "sfgen", f.Tag)
+	} else if err != nil { // Degrade to the field name rather than failing the whole struct
+		warn.add("failed to parse struct tags of %s field %s at %s, falling back to the field name: %v",
+			f.SourceStruct, field.Name(), g.objectPosition(field), err)
+		tags = &structtag.Tags{}
+	}
+
+	var tagOptions []string
+	if f.Tag != "" {
+		if t, err := tags.Get(f.Tag); err == nil {
+			tagOptions = t.Options
+		}
+	}
+
+	fieldType, imps := parseTypeName(structPackage, field.Type())
+	formerValues := sfgenFormerValues(tags)
+	if sfgenTag, ok := sfgenTagName(f.Tag, tags); ok {
+		return parseFieldResult{
+			fieldName:       field.Name(),
+			fieldType:       fieldType,
+			identName:       f.identifier(field.Name()),
+			constName:       baseName + f.identifier(field.Name()),
+			constValue:      sfgenTag,
+			requiredImports: imps,
+			tagOptions:      tagOptions,
+			formerValues:    formerValues,
+			tags:            tags,
+			position:        g.fileSet.Position(field.Pos()),
+			goType:          field.Type(),
+		}, nil
+	}
+
+	tagNameValue := field.Name()
+	if f.Tag == xmlTag && f.TagNameRegex == "" {
+		tagNameValue = xmlTagName(field.Name(), tags)
+	} else if f.Tag != "" {
+		nameFromTag, err := tags.Get(f.Tag)
+		if err == nil && len(nameFromTag.Value()) > 0 && f.TagNameRegex != "" {
+			re, err := regexp.Compile(f.TagNameRegex)
+			if err != nil {
+				return parseFieldResult{}, fmt.Errorf("failed to compile regex expression %q: %w", f.TagNameRegex, err)
+			}
+
+			if matches := re.FindStringSubmatch(nameFromTag.Value()); len(matches) >= 2 {
+				tagNameValue = matches[1]
+			}
+		}
+
+		if err == nil && len(nameFromTag.Name) > 0 && f.TagNameRegex == "" {
+			tagNameValue = nameFromTag.Name
+		}
+	}
+
+	return parseFieldResult{
+		fieldName:       field.Name(),
+		fieldType:       fieldType,
+		identName:       f.identifier(field.Name()),
+		constName:       baseName + f.identifier(field.Name()),
+		constValue:      tagNameValue,
+		requiredImports: imps,
+		tagOptions:      tagOptions,
+		formerValues:    formerValues,
+		tags:            tags,
+		position:        g.fileSet.Position(field.Pos()),
+		goType:          field.Type(),
+	}, nil
+}
+
+func sfgenTagName(targetTagName string, tags *structtag.Tags) (string, bool) {
+	sfgenTag, err := tags.Get("sfgen")
+	if err != nil {
+		return "", false
+	}
+
+	tagValue := sfgenTag.Value()
+	if tagValue == "" {
+		return "", false
+	}
+
+	tagParts := strings.SplitN(strings.TrimSpace(tagValue), ",", 2)
+	tagName := tagParts[0] // We are guaranteed at least a slice with len(1)
+	if len(tagParts) == 1 {
+		return tagName, tagName != ""
+	}
+
+	// From here on we know that tagParts length is 2
+	tagSpecificValues := strings.Split(tagParts[1], " ")
+	for _, tagSpecificVal := range tagSpecificValues {
+		tagSpecificVal = strings.TrimSpace(tagSpecificVal)
+		if tagSpecificVal == "" {
+			continue
+		}
+
+		tagValParts := strings.SplitN(tagSpecificVal, ":", 2)
+		if len(tagValParts) != 2 || tagValParts[0] != targetTagName {
+			continue
+		}
+
+		if tagValParts[1] != "" {
+			tagName = tagValParts[1]
+			break
+		}
+	}
+
+	return tagName, tagName != ""
+}
+
+func calculateBaseName(f FlagOptions) string {
+	var (
+		tagName string
+		prefix  string
+	)
+
+	if f.UseStructName || f.Export {
+		tagName = strings.ToUpper(f.Tag)
+	} else {
+		tagName = strings.ToLower(f.Tag)
+	}
+
+	if f.Prefix == nil {
+		prefix = f.SourceStruct + tagName
+		if !f.UseStructName {
+			prefix = tagName
+		}
+
+		prefix += "Field"
+	} else {
+		prefix = *f.Prefix
+	}
+
+	return casedIdentifier(f.identifier(prefix), f.Export)
+}
+
+// receiverName returns the receiver of the methods of the type generated with f, the --receiver-name if provided, or
+// the lower-cased first character of its base name otherwise.
+func receiverName(f FlagOptions, baseName string) string {
+	if f.ReceiverName != "" {
+		return f.ReceiverName
+	}
+
+	for _, r := range baseName {
+		return string(unicode.ToLower(r))
+	}
+	return ""
+}
+
+// generatedFields returns the description of the constants generated from fields.
+func generatedFields(fields []parsedField) []GeneratedField {
+	generated := make([]GeneratedField, len(fields))
+	for i, field := range fields {
+		generated[i] = GeneratedField{Field: field.fieldName, Const: field.constName, Value: field.constValue, goType: field.goType}
+	}
+	return generated
+}
+
+// formatDecls returns the formatted form of the declarations in code, or code as is if it cannot be formatted, the
+// error being reported when formatting the output file.
+func formatDecls(code []byte) []byte {
+	formatted, err := format.Source(code)
+	if err != nil {
+		return append([]byte(nil), code...)
+	}
+	return formatted
+}
+
+// uniqueSorted returns the sorted unique values of s.
+func uniqueSorted(s []string) []string {
+	seen := make(map[string]struct{}, len(s))
+	var unique []string
+	for _, v := range s {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// casedIdentifier returns name with its first character upper-cased if exported is true, or lower-cased otherwise.
+// An empty name, e.g. from --prefix "", is returned as is.
+func casedIdentifier(name string, exported bool) string {
+	runes := []rune(name)
+	if len(runes) == 0 {
+		return name
+	}
+
+	if exported {
+		runes[0] = unicode.ToUpper(runes[0])
+	} else {
+		runes[0] = unicode.ToLower(runes[0])
+	}
+	return string(runes)
+}
+
+// loadStruct finds the struct with the provided name in the source package, returning the package it was found in
+// along with its type. Aliases are resolved to the struct type they refer to.
+func (g *Generator) loadStruct(source, pkgName, structName string) (*types.Package, *types.Struct, error) {
+	pkgs, ok := g.packagesForDir(source)
+	if !ok {
+		var a []string
+		for k := range g.packages {
+			a = append(a, k)
+		}
+		return nil, nil, fmt.Errorf("failed to find package scope: %s, %+v", source, a)
+	}
+
+	var (
+		foundObj  types.Object
+		foundPkgs []string
+	)
+	for _, pkg := range pkgs {
+		if pkgName != "" && pkg.Name() != pkgName {
+			continue
+		}
+
+		if obj := pkg.Scope().Lookup(structName); obj != nil { // *types.TypeName is returned here
+			foundObj = obj
+			foundPkgs = append(foundPkgs, pkg.Path())
+		}
+	}
+
+	if len(foundPkgs) > 1 {
+		return nil, nil, fmt.Errorf("type %s is ambiguous, it was found in packages %s. Qualify --struct with the package name",
+			structName, strings.Join(foundPkgs, ", "))
+	}
+
+	if foundObj == nil && pkgName != "" {
+		return nil, nil, fmt.Errorf("type %s.%s not found in %s", pkgName, structName, source)
+	}
+
+	if foundObj == nil {
+		return nil, nil, fmt.Errorf("type %s not found in package %s", structName, source)
+	}
+
+	n, ok := unalias(foundObj.Type()).(*types.Named)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot use type %s, only named struct types are supported", structName)
+	}
+
+	s, ok := n.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot use type %s, only named struct types are supported", structName)
+	}
+
+	return foundObj.Pkg(), s, nil
+}
+
+func parseNamedType(structPackage string, u types.Type) (string, []string) {
+	name := u.String()
+	dotIndex := strings.LastIndexByte(name, '.')
+	pkgPath := name
+	if dotIndex >= 0 {
+		pkgPath = name[:dotIndex]
+	}
+
+	if pkgPath == structPackage {
+		return name[dotIndex+1:], nil
+	}
+
+	// Unexported types of other packages, such as those found on the fields of a type defined over an external
+	// struct, cannot be referenced from the generated code.
+	if dotIndex >= 0 && !token.IsExported(name[dotIndex+1:]) {
+		return "any", nil
+	}
+
+	slashIndex := strings.LastIndexByte(name, '/')
+	newName := name
+	if slashIndex >= 0 {
+		newName = name[slashIndex+1:]
+	}
+
+	if dotIndex >= 0 {
+		return newName, []string{name[:dotIndex]}
+	}
+
+	return newName, nil
+}
+
+// qualifiedTypeString returns the name of t, e.g. a struct or interface literal, qualifying the types of packages other
+// than structPackage by their package name.
+func qualifiedTypeString(structPackage string, t types.Type) (string, []string) {
+	var imports []string
+	name := types.TypeString(t, func(pkg *types.Package) string {
+		if pkg.Path() == structPackage {
+			return ""
+		}
+		imports = append(imports, pkg.Path())
+		return pkg.Name()
+	})
+	return name, imports
+}
+
+func parseTypeNameSignature(structPackage string, u *types.Signature) (string, []string) {
+	var (
+		sb      strings.Builder
+		imports []string
+	)
+
+	sb.WriteString("func (")
+	for i := 0; i < u.Params().Len(); i++ {
+		param := u.Params().At(i)
+		paramType, imps := parseTypeName(structPackage, param.Type())
+		imports = append(imports, imps...)
+		if i > 0 && i < u.Params().Len() {
+			sb.WriteByte(',')
+
+		}
+		sb.WriteString(paramType)
+	}
+	sb.WriteByte(')')
+
+	if u.Results().Len() > 1 {
+		sb.WriteByte('(')
+	}
+	for i := 0; i < u.Results().Len(); i++ {
+		param := u.Results().At(i)
+		paramType, imps := parseTypeName(structPackage, param.Type())
+		imports = append(imports, imps...)
+		if i > 0 && i < u.Results().Len() {
+			sb.WriteByte(',')
+
+		}
+		sb.WriteString(paramType)
+	}
+	if u.Results().Len() > 1 {
+		sb.WriteByte(')')
+	}
+
+	return sb.String(), imports
+}
@@ -0,0 +1,285 @@
+package sfgen
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+const (
+	testSrcDir  = "/src/models"
+	testOutFile = "/out/models_generated.go"
+)
+
+// testLoader is a [Loader] type checking a single source file held in memory as the package of every source dir.
+type testLoader struct {
+	src string
+}
+
+func (l testLoader) Load(_ context.Context, fset *token.FileSet, _ PackageSource) ([]*types.Package, error) {
+	file, err := parser.ParseFile(fset, testSrcDir+"/models.go", l.src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg types.Config
+	pkg, err := cfg.Check("example.com/models", fset, []*ast.File{file}, nil)
+	return []*types.Package{pkg}, err
+}
+
+// testCommand is a generate command run from the config file or go:generate directive of owner.
+type testCommand struct {
+	owner string
+	args  string
+}
+
+// generateTest generates the exported constants of the commands, named after their struct, from src into fsys and
+// writes the result, as a run of go-sfgen would.
+func generateTest(t *testing.T, fsys *MemFS, src string, commands ...testCommand) error {
+	t.Helper()
+
+	flagOptions := make([]FlagOptions, len(commands))
+	for i, c := range commands {
+		if err := flagOptions[i].ParseString(c.args + " --include-struct-name --export --src-dir " + testSrcDir + " --out-dir /out --out-pkg models"); err != nil {
+			t.Fatalf("failed to parse %q: %v", c.args, err)
+		}
+		flagOptions[i].owner = c.owner
+	}
+
+	g := NewGenerator(fsys, log.New(io.Discard, "", 0), testLoader{src: src})
+	result, err := g.Generate(context.Background(), flagOptions)
+	if err != nil {
+		return err
+	}
+	return g.Write(context.Background(), result)
+}
+
+// readTestFile returns the content of name in fsys with runs of whitespace collapsed, so it can be matched regardless of
+// the alignment of gofmt, failing the test if it does not exist.
+func readTestFile(t *testing.T, fsys *MemFS, name string) string {
+	t.Helper()
+
+	content, err := fsys.ReadFile(ioFSPath(name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return strings.Join(strings.Fields(string(content)), " ")
+}
+
+const testModels = `package models
+
+type User struct {
+	ID    string ` + "`json:\"id\"`" + `
+	Email string ` + "`json:\"email\"`" + `
+}
+
+type Account struct {
+	ID    int64  ` + "`json:\"id\"`" + `
+	Owner string ` + "`json:\"owner\"`" + `
+}
+`
+
+func TestGenerateOwnedBlocks(t *testing.T) {
+	var (
+		user    = "--struct User --tag json --out-file " + testOutFile
+		account = "--struct Account --tag json --out-file " + testOutFile
+	)
+
+	tests := []struct {
+		name    string
+		runs    [][]testCommand
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "other owners are kept",
+			runs: [][]testCommand{
+				{{owner: "/src/models/user.go", args: user}},
+				{{owner: "/src/models/account.go", args: account}},
+			},
+			want: []string{
+				"// sfgen:begin owner=../src/models/user.go target=User/UserJSONField",
+				"// sfgen:begin owner=../src/models/account.go target=Account/AccountJSONField",
+				"UserJSONFieldEmail",
+				"AccountJSONFieldOwner",
+			},
+		},
+		{
+			name: "blocks no longer generated by their owner are pruned",
+			runs: [][]testCommand{
+				{{owner: "/src/models/models.go", args: user}, {owner: "/src/models/models.go", args: account}},
+				{{owner: "/src/models/models.go", args: user}},
+			},
+			want:    []string{"target=User/UserJSONField", "UserJSONFieldEmail"},
+			notWant: []string{"target=Account/AccountJSONField", "AccountJSONFieldOwner"},
+		},
+		{
+			name: "blocks of the owner are replaced",
+			runs: [][]testCommand{
+				{{owner: "/src/models/models.go", args: user}},
+				{{owner: "/src/models/models.go", args: user + " --prefix Key"}},
+			},
+			want:    []string{"KeyEmail"},
+			notWant: []string{"UserJSONFieldEmail"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := new(MemFS)
+			for _, run := range tt.runs {
+				if err := generateTest(t, fsys, testModels, run...); err != nil {
+					t.Fatalf("failed to generate: %v", err)
+				}
+			}
+
+			content := readTestFile(t, fsys, testOutFile)
+			for _, s := range tt.want {
+				if !strings.Contains(content, s) {
+					t.Errorf("missing %q in: %s", s, content)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(content, s) {
+					t.Errorf("unexpected %q in: %s", s, content)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateStableIDs(t *testing.T) {
+	const args = "--struct User --tag json --stable-ids /src/models/user_ids.json"
+
+	tests := []struct {
+		name    string
+		sources []string
+		want    []string
+	}{
+		{
+			name: "new fields are assigned the next ID",
+			sources: []string{
+				"package models\n\ntype User struct {\n\tID string `json:\"id\"`\n\tEmail string `json:\"email\"`\n}\n",
+				"package models\n\ntype User struct {\n\tEmail string `json:\"email\"`\n\tName string `json:\"name\"`\n}\n",
+			},
+			want: []string{"UserJSONFieldStableIDEmail = 2", "UserJSONFieldStableIDName = 3"},
+		},
+		{
+			name: "removed IDs are restored",
+			sources: []string{
+				"package models\n\ntype User struct {\n\tID string `json:\"id\"`\n\tEmail string `json:\"email\"`\n}\n",
+				"package models\n\ntype User struct {\n\tEmail string `json:\"email\"`\n}\n",
+				"package models\n\ntype User struct {\n\tEmail string `json:\"email\"`\n\tID string `json:\"id\"`\n}\n",
+			},
+			want: []string{"UserJSONFieldStableIDID = 1", "UserJSONFieldStableIDEmail = 2"},
+		},
+		{
+			name: "renamed fields keep their ID",
+			sources: []string{
+				"package models\n\ntype User struct {\n\tEmail string `json:\"email\"`\n}\n",
+				"package models\n\ntype User struct {\n\tMail string `json:\"email\"`\n}\n",
+			},
+			want: []string{"UserJSONFieldStableIDMail = 1"},
+		},
+		{
+			name: "fields with a new value keep their ID",
+			sources: []string{
+				"package models\n\ntype User struct {\n\tEmail string `json:\"email\"`\n}\n",
+				"package models\n\ntype User struct {\n\tEmail string `json:\"mail\"`\n}\n",
+			},
+			want: []string{"UserJSONFieldStableIDEmail = 1"},
+		},
+		{
+			name: "former values given by was keep their ID",
+			sources: []string{
+				"package models\n\ntype User struct {\n\tName string `json:\"name\"`\n\tEmail string `json:\"email\"`\n}\n",
+				"package models\n\ntype User struct {\n\tName string `json:\"name\"`\n\tMail string `json:\"mail\" sfgen:\",was:email\"`\n}\n",
+			},
+			want: []string{"UserJSONFieldStableIDName = 1", "UserJSONFieldStableIDMail = 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := new(MemFS)
+			for _, src := range tt.sources {
+				if err := generateTest(t, fsys, src, testCommand{args: args}); err != nil {
+					t.Fatalf("failed to generate: %v", err)
+				}
+			}
+
+			content := readTestFile(t, fsys, "/out/user_userjsonfield_generated.go")
+			for _, s := range tt.want {
+				if !strings.Contains(content, s) {
+					t.Errorf("missing %q in: %s", s, content)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateAddFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		want    []string
+		notWant []string
+		wantErr string
+	}{
+		{
+			name:    "colliding constants are an error",
+			args:    "--add-fields Account",
+			wantErr: "User.ID and Account.ID both generate the constant UserJSONFieldID, skip one with --skip-fields",
+		},
+		{
+			name: "skipped fields may be replaced",
+			args: "--skip-fields ID --add-fields Account.ID,Account.Owner",
+			want: []string{"UserJSONFieldID", "UserJSONFieldOwner", "UserJSONFieldEmail"},
+		},
+		{
+			name:    "skipped fields are skipped for the added structs",
+			args:    "--skip-fields ID --add-fields Account",
+			want:    []string{"UserJSONFieldOwner", "UserJSONFieldEmail"},
+			notWant: []string{"UserJSONFieldID"},
+		},
+		{
+			name:    "unknown fields are an error",
+			args:    "--add-fields Account.Name",
+			wantErr: "--add-fields Account.Name: Account has no field Name generating a constant",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := new(MemFS)
+			err := generateTest(t, fsys, testModels, testCommand{args: "--struct User --tag json " + tt.args})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+
+			content := readTestFile(t, fsys, "/out/user_userjsonfield_generated.go")
+			for _, s := range tt.want {
+				if !strings.Contains(content, s) {
+					t.Errorf("missing %q in: %s", s, content)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(content, s) {
+					t.Errorf("unexpected %q in: %s", s, content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package sfgen
+
+import (
+	"errors"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+// FS is the file system generated code is written to. If it also has a Stat method, like [OSFS], or implements
+// [io/fs.FS], like [MemFS], files that are only written when absent, such as a --guard-test, are skipped when present.
+// If it can also read files, through a ReadFile method or by implementing [io/fs.FS], generated code is merged into
+// existing output files shared with other config files or go:generate directives. If it has a Remove method, like
+// [OSFS] and [MemFS], the files no longer generated, such as the surplus parts of a --max-file-lines output, are removed
+// by [Generator.Write]. The files of an [io/fs.FS] are addressed by their written path in slash separated form, without
+// the leading slash or volume name.
+type FS interface {
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+}
+
+// OSFS is the default [FS], writing to the host file system.
+type OSFS struct{}
+
+// MkdirAll implements the [FS] interface.
+func (OSFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// WriteFile implements the [FS] interface.
+func (OSFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// Stat implements the optional Stat method of an [FS], used to skip files that are only written when absent.
+func (OSFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// fileExists reports whether name exists in fsys. An [FS] with neither a Stat method nor an [io/fs.FS] implementation
+// is assumed to contain no files.
+func fileExists(fsys FS, name string) (bool, error) {
+	var err error
+	switch r := fsys.(type) {
+	case fs.FS:
+		_, err = fs.Stat(r, ioFSPath(name))
+	case interface {
+		Stat(name string) (os.FileInfo, error)
+	}:
+		_, err = r.Stat(name)
+	default:
+		return false, nil
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReadFile implements the optional ReadFile method of an [FS], used to merge into existing output files.
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// readFile returns the contents of name in fsys, reporting false if it does not exist. An [FS] with neither a ReadFile
+// method nor an [io/fs.FS] implementation is assumed to contain no files.
+func readFile(fsys FS, name string) ([]byte, bool, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch r := fsys.(type) {
+	case fs.FS:
+		data, err = fs.ReadFile(r, ioFSPath(name))
+	case interface {
+		ReadFile(name string) ([]byte, error)
+	}:
+		data, err = r.ReadFile(name)
+	default:
+		return nil, false, nil
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	return data, err == nil, err
+}
+
+// Remove implements the optional Remove method of an [FS], used to remove the files no longer generated.
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// fileRemover is the optional Remove method of an [FS].
+type fileRemover interface {
+	Remove(name string) error
+}
+
+// canRemove reports whether files can be removed from fsys.
+func canRemove(fsys FS) bool {
+	_, ok := fsys.(fileRemover)
+	return ok
+}
+
+// removeFile removes name from fsys, if it exists. It is a no-op for an [FS] without a Remove method.
+func removeFile(fsys FS, name string) error {
+	r, ok := fsys.(fileRemover)
+	if !ok {
+		return nil
+	}
+
+	if err := r.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Logger receives the warnings emitted during generation. A [*log.Logger] satisfies the interface.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// Generator generates constants from struct fields. Its dependencies are injected through [NewGenerator], so it can
+// be composed with other generators in a single process, e.g. by DI frameworks such as wire or fx.
+// A Generator must not be used concurrently.
+type Generator struct {
+	fs     FS
+	logger Logger
+	loader Loader
+
+	fileSet  *token.FileSet
+	packages map[string][]*types.Package
+	// loadTimes holds the duration of loading the packages of each source dir, see [Timings]
+	loadTimes map[string]time.Duration
+	// loadWarnings holds the warnings of loading the packages of each source dir, such as --src-files imports failing to
+	// resolve
+	loadWarnings map[string][]string
+}
+
+// NewGenerator returns a Generator writing to fs, logging warnings to logger, and loading packages with loader.
+// Nil dependencies are replaced by their defaults: [OSFS], a logger writing to stderr, and [PackagesLoader].
+// If loader also has a FileSet method, positions are recorded in the file set it returns rather than in a new one,
+// e.g. for loaders returning packages type checked beforehand, such as that of a go/analysis pass.
+func NewGenerator(fs FS, logger Logger, loader Loader) *Generator {
+	if fs == nil {
+		fs = OSFS{}
+	}
+
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	if loader == nil {
+		loader = PackagesLoader{}
+	}
+
+	fileSet := token.NewFileSet()
+	if fl, ok := loader.(interface{ FileSet() *token.FileSet }); ok {
+		fileSet = fl.FileSet()
+	}
+
+	return &Generator{
+		fs:      fs,
+		logger:  logger,
+		loader:  loader,
+		fileSet: fileSet,
+	}
+}
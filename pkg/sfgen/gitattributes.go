@@ -0,0 +1,89 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// gitattributesFile is the name of the file git reads the attributes of the paths of its directory from.
+const gitattributesFile = ".gitattributes"
+
+// gitattributesPatterns returns the patterns of the Go files generated with f, relative to their directory: the
+// --out-file, along with its --max-file-lines parts, --unsafe-offsets file, and --guard-test, if any.
+func gitattributesPatterns(f FlagOptions) []string {
+	base := filepath.Base(f.OutputFile)
+	patterns := []string{base}
+	if f.MaxFileLines > 0 {
+		patterns = append(patterns, strings.TrimSuffix(base, ".go")+"_*.go")
+	}
+	if f.UnsafeOffsets != "" {
+		patterns = append(patterns, strings.TrimSuffix(base, ".go")+"_unsafe.go")
+	}
+	if f.GuardTest {
+		patterns = append(patterns, filepath.Base(guardTestPath(f.OutputFile)))
+	}
+	return patterns
+}
+
+// gitattributesFiles returns the .gitattributes files of the directories of the --gitattributes targets of files,
+// marking their Go files linguist-generated. Entries are appended to the existing content of the files, and patterns
+// already assigning the attribute, e.g. to unset it, are left as is.
+func (g *Generator) gitattributesFiles(files []FileResult) ([]FileResult, error) {
+	var (
+		dirs     []string
+		patterns = make(map[string][]string)
+	)
+	for _, file := range files {
+		for _, target := range file.Targets {
+			if !target.Options.Gitattributes {
+				continue
+			}
+
+			dir := filepath.Dir(target.Options.OutputFile)
+			if _, ok := patterns[dir]; !ok {
+				dirs = append(dirs, dir)
+			}
+			patterns[dir] = append(patterns[dir], gitattributesPatterns(target.Options)...)
+		}
+	}
+
+	attributes := make([]FileResult, 0, len(dirs))
+	for _, dir := range dirs {
+		path := filepath.Join(dir, gitattributesFile)
+		existing, _, err := readFile(g.fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read existing %s: %w", path, err)
+		}
+
+		marked := make(map[string]struct{})
+		for _, line := range strings.Split(string(existing), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			for _, attr := range fields[1:] {
+				name, _, _ := strings.Cut(strings.TrimLeft(attr, "-!"), "=")
+				if name == "linguist-generated" {
+					marked[fields[0]] = struct{}{}
+				}
+			}
+		}
+
+		content := bytes.NewBuffer(existing)
+		if content.Len() > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+			content.WriteByte('\n')
+		}
+		for _, pattern := range uniqueSorted(patterns[dir]) {
+			if _, ok := marked[pattern]; !ok {
+				content.WriteString(pattern + " linguist-generated=true\n")
+			}
+		}
+
+		attributes = append(attributes, FileResult{Path: path, Content: content.Bytes()})
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,34 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// writeGoString writes a GoString method returning the name of the constant holding the value, so that the %#v verb
+// prints e.g. UserFieldEmail rather than "email" in test failures. Values not held by a constant are quoted, as %#v
+// prints strings, or written as a conversion with the int and bitmask styles. When several constants share a value, the first one
+// is named.
+func writeGoString(buf *bytes.Buffer, f FlagOptions, typeName, receiver string, fields []parsedField) []string {
+	buf.WriteString("// GoString implements the [fmt.GoStringer] interface, returning the name of the constant holding the value\n")
+	buf.WriteString(fmt.Sprintf("func (%s %s) GoString() string {\n", receiver, typeName))
+	if f.intBased() {
+		writeIntSwitch(buf, typeName, receiver, fields, func(field parsedField) string { return field.constName })
+		return []string{"strconv"}
+	}
+
+	if len(fields) > 0 {
+		buf.WriteString(fmt.Sprintf("switch (string)(%s) {\n", receiver))
+		seen := make(map[string]struct{}, len(fields))
+		for _, field := range fields {
+			if _, ok := seen[field.constValue]; ok {
+				continue
+			}
+			seen[field.constValue] = struct{}{}
+			buf.WriteString(fmt.Sprintf("case %q:\nreturn %q\n", field.constValue, field.constName))
+		}
+		buf.WriteString("}\n")
+	}
+	buf.WriteString(fmt.Sprintf("return strconv.Quote((string)(%s))\n}\n", receiver))
+	return []string{"strconv"}
+}
@@ -0,0 +1,68 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// guardTestFiles returns the --guard-test files of files, each asserting the values its constants were generated with.
+func guardTestFiles(files []FileResult) ([]FileResult, error) {
+	var guards []FileResult
+	for _, file := range files {
+		buf := new(bytes.Buffer)
+		for _, target := range file.Targets {
+			if target.Options.GuardTest {
+				writeGuardTest(buf, target)
+			}
+		}
+
+		if buf.Len() == 0 {
+			continue
+		}
+
+		path := guardTestPath(file.Path)
+		header := fmt.Sprintf("// Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT.\n// Delete this file and regenerate to accept changed values.\n\npackage %s\n\nimport \"testing\"\n", file.Package)
+		formatted, err := format.Source(append([]byte(header), buf.Bytes()...))
+		if err != nil {
+			return nil, fmt.Errorf("failed to format generated code for %s: %w", path, err)
+		}
+
+		guards = append(guards, FileResult{Path: path, Package: file.Package, Content: formatted, CreateOnly: true})
+	}
+
+	return guards, nil
+}
+
+// guardTestPath returns the path of the guard test of the out file at path, e.g. user_field_guard_test.go for
+// user_field_generated.go.
+func guardTestPath(path string) string {
+	base := strings.TrimSuffix(path, ".go")
+	base = strings.TrimSuffix(base, "_generated")
+	return base + "_guard_test.go"
+}
+
+// writeGuardTest writes a test asserting the current values of the constants of target.
+func writeGuardTest(buf *bytes.Buffer, target TargetResult) {
+	name := casedIdentifier(calculateBaseName(target.Options), true)
+	if name == "" {
+		name = target.Options.SourceStruct
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// Test%sGuard fails if the values generated from [%s] change.\n", name, target.Options.SourceStruct))
+	buf.WriteString(fmt.Sprintf("func Test%sGuard(t *testing.T) {\n", name))
+	buf.WriteString("tests := []struct{ name, got, want string }{\n")
+	for _, field := range target.Fields {
+		got := fmt.Sprintf("string(%s)", field.Const)
+		if target.Options.intBased() { // the constants hold the index or bit of their field, and String its value
+			got = field.Const + ".String()"
+		}
+		buf.WriteString(fmt.Sprintf("{%q, %s, %q},\n", field.Const, got, field.Value))
+	}
+	buf.WriteString("}\n")
+	buf.WriteString("for _, tt := range tests {\n")
+	buf.WriteString("if tt.got != tt.want {\n")
+	buf.WriteString("t.Errorf(\"%s = %q, want %q\", tt.name, tt.got, tt.want)\n")
+	buf.WriteString("}\n}\n}\n")
+}
@@ -0,0 +1,84 @@
+package sfgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// checkIdentifierPattern returns an error listing the package level identifiers declared by the generated code of a
+// command, or by its --namespace and --interface, that do not match the --identifier-pattern. Methods are not
+// checked, as their names are fixed, e.g. String.
+func checkIdentifierPattern(f FlagOptions, code ...[]byte) error {
+	if f.IdentifierPattern == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(f.IdentifierPattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile --identifier-pattern %q: %w", f.IdentifierPattern, err)
+	}
+
+	var names []string
+	for _, shared := range []string{f.Namespace, f.Interface} {
+		if shared != "" {
+			names = append(names, shared)
+		}
+	}
+
+	for _, c := range code {
+		declared, err := declaredIdentifiers(c)
+		if err != nil {
+			return fmt.Errorf("failed to check generated identifiers of %s: %w", f.SourceStruct, err)
+		}
+		names = append(names, declared...)
+	}
+
+	var violations []string
+	for _, name := range names {
+		if !re.MatchString(name) {
+			violations = append(violations, name)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("identifiers generated from %s do not match --identifier-pattern %q: %s",
+			f.SourceStruct, f.IdentifierPattern, strings.Join(violations, ", "))
+	}
+
+	return nil
+}
+
+// declaredIdentifiers returns the names of the package level constants, variables, types, and functions declared by
+// the generated code.
+func declaredIdentifiers(code []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", append([]byte("package p\n"), code...), parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names = append(names, decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+					}
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				}
+			}
+		}
+	}
+	return names, nil
+}
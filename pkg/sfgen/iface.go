@@ -0,0 +1,36 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+)
+
+// interfaceMarkerMethod returns the unexported method used to restrict implementations of the --interface type to
+// the generated types.
+func interfaceMarkerMethod(interfaceName string) string {
+	name := []rune(interfaceName)
+	name[0] = unicode.ToUpper(name[0])
+	return "is" + string(name)
+}
+
+// writeInterfaces writes each distinct --interface declared by the provided commands once.
+func writeInterfaces(buf *bytes.Buffer, flagOptions []FlagOptions) {
+	seen := make(map[string]struct{})
+	for _, fOpt := range flagOptions {
+		if fOpt.Interface == "" {
+			continue
+		}
+
+		if _, ok := seen[fOpt.Interface]; ok {
+			continue
+		}
+		seen[fOpt.Interface] = struct{}{}
+
+		buf.WriteString(fmt.Sprintf("\n// %s is implemented by every type generated with --interface %s.\n", fOpt.Interface, fOpt.Interface))
+		buf.WriteString(fmt.Sprintf("type %s interface {\n", fOpt.Interface))
+		buf.WriteString("String() string\n")
+		buf.WriteString(fmt.Sprintf("%s()\n", interfaceMarkerMethod(fOpt.Interface)))
+		buf.WriteString("}\n")
+	}
+}
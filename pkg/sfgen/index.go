@@ -0,0 +1,77 @@
+package sfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// symbolIndex is the document written to a --symbol-index file.
+type symbolIndex struct {
+	Symbols []indexedSymbol `json:"symbols"`
+}
+
+// indexedSymbol describes a single generated constant. File is relative to the directory of the index.
+type indexedSymbol struct {
+	Const   string `json:"const"`
+	Package string `json:"package"`
+	File    string `json:"file"`
+	Struct  string `json:"struct"`
+	Field   string `json:"field"`
+	Tag     string `json:"tag,omitempty"`
+	Value   string `json:"value"`
+}
+
+// symbolIndexFiles returns the --symbol-index files describing the targets of files, which must be sorted by path so
+// the indexes are stable across runs.
+func symbolIndexFiles(files []FileResult) ([]FileResult, error) {
+	var (
+		indexes = make(map[string]*symbolIndex)
+		paths   []string
+	)
+
+	for _, file := range files {
+		for _, target := range file.Targets {
+			path := target.Options.SymbolIndex
+			if path == "" {
+				continue
+			}
+
+			index, ok := indexes[path]
+			if !ok {
+				index = &symbolIndex{Symbols: []indexedSymbol{}}
+				indexes[path] = index
+				paths = append(paths, path)
+			}
+
+			relFile, err := filepath.Rel(filepath.Dir(path), file.Path)
+			if err != nil {
+				relFile = file.Path
+			}
+
+			for _, field := range target.Fields {
+				index.Symbols = append(index.Symbols, indexedSymbol{
+					Const:   field.Const,
+					Package: file.Package,
+					File:    filepath.ToSlash(relFile),
+					Struct:  target.Options.SourceStruct,
+					Field:   field.Field,
+					Tag:     target.Options.Tag,
+					Value:   field.Value,
+				})
+			}
+		}
+	}
+
+	results := make([]FileResult, 0, len(paths))
+	for _, path := range paths {
+		content, err := json.MarshalIndent(indexes[path], "", "\t")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode symbol index %s: %w", path, err)
+		}
+
+		results = append(results, FileResult{Path: path, Content: append(content, '\n')})
+	}
+
+	return results, nil
+}
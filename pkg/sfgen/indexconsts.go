@@ -0,0 +1,55 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// writeIndexConsts writes an int constant holding the reflect field index of each field declared directly in the
+// source struct, e.g. UserFieldIndexEmail = 2 for use with reflect.Value.Field, along with a map from the generated
+// constants to those indices, so that reordering the struct fields updates them. Fields promoted from embedded structs
+// and those not reachable through an index, such as the fields of oneof case wrappers, are omitted, as they have no
+// index of their own; --field-index holds their index paths.
+func writeIndexConsts(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField) {
+	var (
+		varName = baseName + "Indices"
+		keyType = "string"
+		direct  []parsedField
+	)
+	if f.Style == StyleAlias || f.Style == StyleTyped || f.intBased() {
+		keyType = baseName
+	}
+	for _, field := range fields {
+		if len(field.index) == 1 {
+			direct = append(direct, field)
+		}
+	}
+
+	if len(direct) > 0 {
+		buf.WriteString(fmt.Sprintf("\n// Reflect field indices of the [%s] struct fields the constants are generated from\n", f.SourceStruct))
+		buf.WriteString("const (")
+		for _, field := range direct {
+			buf.WriteString(fmt.Sprintf("\n%sIndex%s = %d", baseName, field.identName, field.index[0]))
+		}
+		buf.WriteString("\n)\n")
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s maps the constants generated from [%s] to the reflect field indices of their fields.\n",
+		varName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("var %s = map[%s]int{", varName, keyType))
+
+	seenValues := make(map[string]struct{})
+	for _, field := range direct {
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+
+		key := field.constName
+		if f.Style == StyleGeneric {
+			key = fmt.Sprintf("string(%s)", field.constName)
+		}
+		buf.WriteString(fmt.Sprintf("\n%s: %sIndex%s,", key, baseName, field.identName))
+	}
+	buf.WriteString("\n}\n")
+}
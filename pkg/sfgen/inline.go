@@ -0,0 +1,24 @@
+package sfgen
+
+// inlineTagOptions maps a tag to the option that promotes the fields of a struct field into its parent, the same way
+// embedding a struct does.
+var inlineTagOptions = map[string]string{
+	"yaml":         "inline",
+	"mapstructure": "squash",
+}
+
+// fieldIsInlined reports whether the --tag options of a field request its fields be promoted into the parent struct.
+func fieldIsInlined(f FlagOptions, tagOptions []string) bool {
+	inlineOpt, ok := inlineTagOptions[f.Tag]
+	if !ok {
+		return false
+	}
+
+	for _, opt := range tagOptions {
+		if opt == inlineOpt {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// intConstSpec returns the declaration of the constant of field within the iota based const block of the int and
+// bitmask styles. Only the first constant, at index 0, spells out its type and value, e.g. 1 << iota for bitmask.
+func intConstSpec(f FlagOptions, field parsedField, index int) string {
+	if index == 0 && f.Style == StyleBitmask {
+		return fmt.Sprintf("%s %s = 1 << iota", field.constName, field.baseName)
+	}
+	if index == 0 {
+		return fmt.Sprintf("%s %s = iota", field.constName, field.baseName)
+	}
+	return field.constName
+}
+
+// writeIntStringer writes the String method of the type of the int style, returning the value the constant was
+// generated from, formatted with the --stringer-format if provided. It returns the imports the method requires.
+func writeIntStringer(buf *bytes.Buffer, f FlagOptions, typeName, receiver string, fields []parsedField) []string {
+	if f.NoStringer {
+		return nil
+	}
+
+	buf.WriteString("// String implements the [fmt.Stringer] interface\n")
+	buf.WriteString(fmt.Sprintf("func (%s %s) String() string {\n", receiver, typeName))
+	writeIntSwitch(buf, typeName, receiver, fields, func(field parsedField) string {
+		if f.StringerFormat != "" {
+			return fmt.Sprintf(f.StringerFormat, field.constValue)
+		}
+		return field.constValue
+	})
+	return []string{"strconv"}
+}
+
+// writeIntSwitch writes a switch returning the result of value for the constant of each field, followed by the
+// closing of the method. Values other than those of the constants are returned as a conversion, e.g. jsonField(7).
+func writeIntSwitch(buf *bytes.Buffer, typeName, receiver string, fields []parsedField, value func(parsedField) string) {
+	if len(fields) > 0 {
+		buf.WriteString(fmt.Sprintf("switch %s {\n", receiver))
+		for _, field := range fields {
+			buf.WriteString(fmt.Sprintf("case %s:\nreturn %q\n", field.constName, value(field)))
+		}
+		buf.WriteString("}\n")
+	}
+	buf.WriteString(fmt.Sprintf("return %q + strconv.Itoa(int(%s)) + \")\"\n}\n", typeName+"(", receiver))
+}
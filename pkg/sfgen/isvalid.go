@@ -0,0 +1,46 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// writeIsValid writes an IsValid method reporting whether the value is held by one of the generated constants, e.g. to
+// validate the sort or filter fields of a request without maintaining a parallel list. With the bitmask style, values
+// combining the bits of several constants are valid, as long as no other bit is set.
+func writeIsValid(buf *bytes.Buffer, f FlagOptions, typeName, receiver string, fields []parsedField) {
+	buf.WriteString("// IsValid reports whether the value is held by one of the generated constants\n")
+	buf.WriteString(fmt.Sprintf("func (%s %s) IsValid() bool {\n", receiver, typeName))
+	if len(fields) == 0 {
+		buf.WriteString("return false\n}\n")
+		return
+	}
+
+	var (
+		cases = make([]string, 0, len(fields))
+		seen  = make(map[string]struct{}, len(fields))
+	)
+	for _, field := range fields {
+		c := field.constName
+		if !f.intBased() {
+			c = fmt.Sprintf("%q", field.constValue)
+		}
+
+		if _, ok := seen[c]; !ok {
+			seen[c] = struct{}{}
+			cases = append(cases, c)
+		}
+	}
+
+	switch f.Style {
+	case StyleBitmask:
+		buf.WriteString(fmt.Sprintf("return %s != 0 && %s&^(%s) == 0\n}\n", receiver, receiver, strings.Join(cases, " | ")))
+		return
+	case StyleInt:
+		buf.WriteString(fmt.Sprintf("switch %s {\n", receiver))
+	default:
+		buf.WriteString(fmt.Sprintf("switch (string)(%s) {\n", receiver))
+	}
+	buf.WriteString(fmt.Sprintf("case %s:\nreturn true\n}\nreturn false\n}\n", strings.Join(cases, ", ")))
+}
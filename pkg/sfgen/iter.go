@@ -0,0 +1,113 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Styles of the --iter-style, the return type of the All method generated with --iter.
+const (
+	// IterArray returns an array of the values of the constants, e.g. [2]string.
+	IterArray = "array"
+	// IterTypedArray returns an array of the constants, e.g. [2]jsonField, sparing the conversions of their values.
+	IterTypedArray = "typed-array"
+	// IterSeq returns an iter.Seq of the constants, which requires Go 1.23 or later.
+	IterSeq = "seq"
+)
+
+// writeAll writes the All method of the generated type typeName, e.g. field[T] for the generic style, returning the
+// imports it requires. baseName is the name of the type without its type parameters.
+func writeAll(buf *bytes.Buffer, f FlagOptions, baseName, typeName, receiver string, fields []parsedField) []string {
+	if f.IterStyle == "" || f.IterStyle == IterArray {
+		buf.WriteString(fmt.Sprintf("// All was generated from the [%s] struct. It returns an array of all [%s]'s associated constant values.\n", f.SourceStruct, baseName))
+
+		var sb strings.Builder
+		for _, field := range fields {
+			sb.WriteByte('\n')
+			sb.WriteByte('"')
+			sb.WriteString(field.constValue)
+			sb.WriteByte('"')
+			sb.WriteByte(',')
+		}
+		buf.WriteString(fmt.Sprintf("func (%s %s) All() [%d]string { return [%d]string{%s} }\n", receiver, typeName, len(fields), len(fields), sb.String()))
+		return nil
+	}
+
+	if f.IterStyle == IterTypedArray {
+		// The constants of the generic style each have their own type argument, so their values are converted instead
+		var sb strings.Builder
+		for _, field := range fields {
+			sb.WriteByte('\n')
+			if f.Style == StyleGeneric {
+				sb.WriteString(fmt.Sprintf("%s(%q)", typeName, field.constValue))
+			} else {
+				sb.WriteString(field.constName)
+			}
+			sb.WriteByte(',')
+		}
+
+		buf.WriteString(fmt.Sprintf("// All was generated from the [%s] struct. It returns an array of all [%s]'s associated constants.\n", f.SourceStruct, baseName))
+		buf.WriteString(fmt.Sprintf("func (%s %s) All() [%d]%s { return [%d]%s{%s} }\n", receiver, typeName, len(fields), typeName, len(fields), typeName, sb.String()))
+		return nil
+	}
+
+	// The constants of the generic style each have their own type argument, so its values are converted instead
+	elemType, elem := typeName, "v"
+	if f.Style == StyleGeneric {
+		elemType, elem = "string", typeName+"(v)"
+	}
+
+	elems := make([]string, len(fields))
+	for i, field := range fields {
+		elems[i] = field.constName
+		if f.Style == StyleGeneric {
+			elems[i] = fmt.Sprintf("%q", field.constValue)
+		}
+	}
+
+	buf.WriteString(fmt.Sprintf("// All was generated from the [%s] struct. It returns an iterator over all [%s]'s associated constants.\n", f.SourceStruct, baseName))
+	buf.WriteString(fmt.Sprintf("func (%s %s) All() iter.Seq[%s] {\n", receiver, typeName, typeName))
+	buf.WriteString(fmt.Sprintf("return func(yield func(%s) bool) {\n", typeName))
+	buf.WriteString(fmt.Sprintf("for _, v := range [...]%s{%s} {\n", elemType, strings.Join(elems, ", ")))
+	buf.WriteString(fmt.Sprintf("if !yield(%s) {\nreturn\n}\n}\n}\n}\n", elem))
+	return []string{"iter"}
+}
+
+// writeNamesAndValues writes the Names and Values methods generated along with All, returning the names of the
+// constants and their values as strings, as an array or, with --iter-style seq, an iter.Seq. It returns the imports
+// they require.
+func writeNamesAndValues(buf *bytes.Buffer, f FlagOptions, baseName, typeName, receiver string, fields []parsedField) []string {
+	names := make([]string, len(fields))
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		names[i], values[i] = field.constName, field.constValue
+	}
+
+	writeStrings := func(method, description string, elems []string) {
+		quoted := make([]string, len(elems))
+		for i, elem := range elems {
+			quoted[i] = fmt.Sprintf("%q", elem)
+		}
+
+		if f.IterStyle != IterSeq {
+			buf.WriteString(fmt.Sprintf("// %s was generated from the [%s] struct. It returns an array of %s.\n", method, f.SourceStruct, description))
+			buf.WriteString(fmt.Sprintf("func (%s %s) %s() [%d]string { return [%d]string{%s} }\n", receiver, typeName, method,
+				len(elems), len(elems), strings.Join(quoted, ", ")))
+			return
+		}
+
+		buf.WriteString(fmt.Sprintf("// %s was generated from the [%s] struct. It returns an iterator over %s.\n", method, f.SourceStruct, description))
+		buf.WriteString(fmt.Sprintf("func (%s %s) %s() iter.Seq[string] {\n", receiver, typeName, method))
+		buf.WriteString("return func(yield func(string) bool) {\n")
+		buf.WriteString(fmt.Sprintf("for _, v := range [...]string{%s} {\n", strings.Join(quoted, ", ")))
+		buf.WriteString("if !yield(v) {\nreturn\n}\n}\n}\n}\n")
+	}
+
+	writeStrings("Names", fmt.Sprintf("the names of all [%s]'s associated constants", baseName), names)
+	writeStrings("Values", fmt.Sprintf("the values of all [%s]'s associated constants", baseName), values)
+	if f.IterStyle == IterSeq {
+		return []string{"iter"}
+	}
+	return nil
+}
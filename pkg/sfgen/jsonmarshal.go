@@ -0,0 +1,108 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// writeJSONMarshaler writes the MarshalJSON and UnmarshalJSON methods implementing [encoding/json.Marshaler] and
+// [encoding/json.Unmarshaler], so the generated type may be used directly in API request and response structs. Values
+// are marshaled as JSON strings of the value their constant was generated from, and both methods reject values no
+// constant holds. With the bitmask style, the values of the set bits are marshaled as an array. JSON null is ignored
+// by UnmarshalJSON, as by the types of encoding/json. It returns the imports the methods require.
+func writeJSONMarshaler(buf *bytes.Buffer, f FlagOptions, baseName, typeName, receiver string, fields []parsedField) []string {
+	if f.Style == StyleBitmask {
+		writeBitmaskJSONMarshaler(buf, baseName, typeName, receiver, fields)
+		return []string{"encoding/json", "fmt"}
+	}
+
+	var (
+		seen   = make(map[string]struct{}, len(fields))
+		unique []parsedField
+	)
+	for _, field := range fields {
+		if _, ok := seen[field.constValue]; ok && !f.intBased() {
+			continue
+		}
+		seen[field.constValue] = struct{}{}
+		unique = append(unique, field)
+	}
+
+	buf.WriteString("// MarshalJSON implements the [json.Marshaler] interface, rejecting values no constant holds\n")
+	buf.WriteString(fmt.Sprintf("func (%s %s) MarshalJSON() ([]byte, error) {\n", receiver, typeName))
+	if len(unique) > 0 {
+		if f.intBased() {
+			buf.WriteString(fmt.Sprintf("switch %s {\n", receiver))
+		} else {
+			buf.WriteString(fmt.Sprintf("switch (string)(%s) {\n", receiver))
+		}
+		for _, field := range unique {
+			c := field.constName
+			if !f.intBased() {
+				c = fmt.Sprintf("%q", field.constValue)
+			}
+			buf.WriteString(fmt.Sprintf("case %s:\nreturn []byte(%q), nil\n", c, jsonString(field.constValue)))
+		}
+		buf.WriteString("}\n")
+	}
+	if f.intBased() {
+		buf.WriteString(fmt.Sprintf("return nil, fmt.Errorf(\"invalid %s %%d\", int(%s))\n}\n", baseName, receiver))
+	} else {
+		buf.WriteString(fmt.Sprintf("return nil, fmt.Errorf(\"invalid %s %%q\", (string)(%s))\n}\n", baseName, receiver))
+	}
+
+	buf.WriteString("\n// UnmarshalJSON implements the [json.Unmarshaler] interface, rejecting values no constant holds\n")
+	buf.WriteString(fmt.Sprintf("func (%s *%s) UnmarshalJSON(data []byte) error {\n", receiver, typeName))
+	buf.WriteString("if string(data) == \"null\" {\nreturn nil\n}\n")
+	buf.WriteString("var s string\nif err := json.Unmarshal(data, &s); err != nil {\n")
+	buf.WriteString(fmt.Sprintf("return fmt.Errorf(\"invalid %s: %%w\", err)\n}\n", baseName))
+	if len(unique) > 0 {
+		buf.WriteString("switch s {\n")
+		if f.intBased() {
+			for _, field := range unique {
+				buf.WriteString(fmt.Sprintf("case %q:\n*%s = %s\nreturn nil\n", field.constValue, receiver, field.constName))
+			}
+		} else {
+			values := make([]string, len(unique))
+			for i, field := range unique {
+				values[i] = fmt.Sprintf("%q", field.constValue)
+			}
+			buf.WriteString(fmt.Sprintf("case %s:\n*%s = %s(s)\nreturn nil\n", strings.Join(values, ", "), receiver, typeName))
+		}
+		buf.WriteString("}\n")
+	}
+	buf.WriteString(fmt.Sprintf("return fmt.Errorf(\"invalid %s %%q\", s)\n}\n", baseName))
+	return []string{"encoding/json", "fmt"}
+}
+
+// writeBitmaskJSONMarshaler writes the MarshalJSON and UnmarshalJSON methods of the bitmask style, see
+// [writeJSONMarshaler], marshaling the values of the set bits as an array.
+func writeBitmaskJSONMarshaler(buf *bytes.Buffer, baseName, typeName, receiver string, fields []parsedField) {
+	consts := make([]string, len(fields))
+	buf.WriteString("// MarshalJSON implements the [json.Marshaler] interface, marshaling the values of the set bits as an array\n")
+	buf.WriteString(fmt.Sprintf("func (%s %s) MarshalJSON() ([]byte, error) {\n", receiver, typeName))
+	buf.WriteString("values := []string{}\n")
+	for i, field := range fields {
+		buf.WriteString(fmt.Sprintf("if %s&%s != 0 {\nvalues = append(values, %q)\n}\n", receiver, field.constName, field.constValue))
+		consts[i] = field.constName
+	}
+	rest := receiver
+	if len(consts) > 0 {
+		rest = fmt.Sprintf("%s &^ (%s)", receiver, strings.Join(consts, " | "))
+	}
+	buf.WriteString(fmt.Sprintf("if %s != 0 {\nreturn nil, fmt.Errorf(\"invalid %s %%d\", uint64(%s))\n}\n", rest, baseName, receiver))
+	buf.WriteString("return json.Marshal(values)\n}\n")
+
+	buf.WriteString("\n// UnmarshalJSON implements the [json.Unmarshaler] interface, rejecting values no constant holds\n")
+	buf.WriteString(fmt.Sprintf("func (%s *%s) UnmarshalJSON(data []byte) error {\n", receiver, typeName))
+	buf.WriteString("if string(data) == \"null\" {\nreturn nil\n}\n")
+	buf.WriteString("var values []string\nif err := json.Unmarshal(data, &values); err != nil {\n")
+	buf.WriteString(fmt.Sprintf("return fmt.Errorf(\"invalid %s: %%w\", err)\n}\n", baseName))
+	buf.WriteString(fmt.Sprintf("var v %s\nfor _, s := range values {\nswitch s {\n", typeName))
+	for _, field := range fields {
+		buf.WriteString(fmt.Sprintf("case %q:\nv |= %s\n", field.constValue, field.constName))
+	}
+	buf.WriteString(fmt.Sprintf("default:\nreturn fmt.Errorf(\"invalid %s %%q\", s)\n}\n}\n", baseName))
+	buf.WriteString(fmt.Sprintf("*%s = v\nreturn nil\n}\n", receiver))
+}
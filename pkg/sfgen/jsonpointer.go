@@ -0,0 +1,88 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// jsonPointerEscaper escapes the reference tokens of a JSON Pointer, as specified by RFC 6901.
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// jsonPointer is the constant holding the JSON Pointer of a field.
+type jsonPointer struct {
+	name, value string
+}
+
+// writeJSONPointers writes a [const]Pointer constant holding the RFC 6901 JSON Pointer of each field, e.g. /address for
+// the Address field, for use with JSON Patch libraries. Fields of struct types are followed by the pointers of their
+// own fields, e.g. /address/city, parsed with the same flags as the --struct. Recursive types are only followed once.
+func (g *Generator) writeJSONPointers(buf *bytes.Buffer, f FlagOptions, structPackage string, s *types.Struct, fields []parsedField, warn *warnings) error {
+	pointers, err := g.jsonPointers(f, structPackage, "", s, fields, map[*types.Struct]struct{}{s: {}}, warn)
+	if err != nil || len(pointers) == 0 {
+		return err
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// JSON Pointers of the [%s] struct fields, see RFC 6901\n", f.SourceStruct))
+	buf.WriteString("const (")
+	seen := make(map[string]struct{}, len(pointers))
+	for _, p := range pointers {
+		if _, ok := seen[p.name]; ok {
+			continue
+		}
+		seen[p.name] = struct{}{}
+		buf.WriteString(fmt.Sprintf("\n%s = %q", p.name, p.value))
+	}
+	buf.WriteString("\n)\n")
+	return nil
+}
+
+// jsonPointers returns the pointers of fields, the fields of s, below the pointer parent. visited holds the structs
+// being followed, so recursive types end the recursion.
+func (g *Generator) jsonPointers(f FlagOptions, structPackage, parent string, s *types.Struct, fields []parsedField,
+	visited map[*types.Struct]struct{}, warn *warnings) ([]jsonPointer, error) {
+	var pointers []jsonPointer
+	for _, field := range fields {
+		pointer := parent + "/" + jsonPointerEscaper.Replace(field.constValue)
+		pointers = append(pointers, jsonPointer{name: field.constName + "Pointer", value: pointer})
+
+		// Fields of oneof case wrappers cannot be reached from s
+		if field.index == nil {
+			continue
+		}
+
+		fieldStruct, ok := underlyingStruct(fieldByIndex(s, field.index).Type())
+		if !ok {
+			continue
+		}
+
+		if _, ok := visited[fieldStruct]; ok {
+			continue
+		}
+
+		nested, err := g.parseStructFields(f, structPackage, field.constName, fieldStruct, warn)
+		if err != nil {
+			return nil, err
+		}
+
+		visited[fieldStruct] = struct{}{}
+		nestedPointers, err := g.jsonPointers(f, structPackage, pointer, fieldStruct, nested, visited, warn)
+		delete(visited, fieldStruct)
+		if err != nil {
+			return nil, err
+		}
+		pointers = append(pointers, nestedPointers...)
+	}
+	return pointers, nil
+}
+
+// fieldByIndex returns the field of s at the reflect index path index, following embedded structs.
+func fieldByIndex(s *types.Struct, index []int) *types.Var {
+	field := s.Field(index[0])
+	for _, i := range index[1:] {
+		embedded, _ := underlyingStruct(field.Type())
+		field = embedded.Field(i)
+	}
+	return field
+}
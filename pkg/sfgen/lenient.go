@@ -0,0 +1,34 @@
+package sfgen
+
+import (
+	"fmt"
+	"github.com/fatih/structtag"
+	"regexp"
+	"strings"
+)
+
+// lenientParseTags extracts each of the provided keys from a struct tag that failed strict parsing, using a simple
+// key:"value" match rather than requiring the entire tag to be well-formed.
+func lenientParseTags(tag string, keys ...string) *structtag.Tags {
+	tags := &structtag.Tags{}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+
+		re := regexp.MustCompile(fmt.Sprintf(`(?:^|\s)%s:"((?:[^"\\]|\\.)*)"`, regexp.QuoteMeta(key)))
+		matches := re.FindStringSubmatch(tag)
+		if len(matches) < 2 {
+			continue
+		}
+
+		parts := strings.Split(matches[1], ",")
+		_ = tags.Set(&structtag.Tag{
+			Key:     key,
+			Name:    parts[0],
+			Options: parts[1:],
+		})
+	}
+
+	return tags
+}
@@ -0,0 +1,76 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fatih/structtag"
+	"go/token"
+	"strings"
+)
+
+// LinkTag generates a method of the generated type returning the value of another tag of the field a constant is
+// generated from, linking the representations of the same field, e.g. its JSON name and database column.
+type LinkTag struct {
+	// Tag is the struct tag the method returns the values of, e.g. db.
+	Tag string
+	// Method is the name of the generated method, e.g. Column.
+	Method string
+}
+
+// parseLinkTag parses a --link-tag value of the form tag[:Method]. The method is named after the tag by default, e.g.
+// Db for db.
+func parseLinkTag(s string) (LinkTag, error) {
+	tag, method, ok := strings.Cut(s, ":")
+	if !ok {
+		method = casedIdentifier(tag, true)
+	}
+	if tag == "" || !token.IsIdentifier(method) || !token.IsExported(method) {
+		return LinkTag{}, fmt.Errorf("invalid --link-tag value %q, expected tag[:Method], e.g. db:Column", s)
+	}
+	return LinkTag{Tag: tag, Method: method}, nil
+}
+
+// linkedTagValue returns the value of a field named fieldName for tag, as the generated constants would hold it: the
+// name of the tag, or the field name if the field lacks the tag or its name is empty. Fields excluded from tag, with
+// a name of -, have no value.
+func linkedTagValue(fieldName string, tags *structtag.Tags, tag string) string {
+	t, err := tags.Get(tag)
+	switch {
+	case err != nil || t.Name == "":
+		return fieldName
+	case t.Name == "-":
+		return ""
+	default:
+		return t.Name
+	}
+}
+
+// writeLinkTag writes the method of link, returning the value of the tag of link for the field the constant holding
+// the value is generated from, or an empty string if there is none. When several constants share a value, the first
+// one is used.
+func writeLinkTag(buf *bytes.Buffer, f FlagOptions, link LinkTag, typeName, receiver string, fields []parsedField) {
+	buf.WriteString(fmt.Sprintf("// %s returns the %s tag of the field the constant holding the value is generated from\n", link.Method, link.Tag))
+	buf.WriteString(fmt.Sprintf("func (%s %s) %s() string {\n", receiver, typeName, link.Method))
+	if len(fields) > 0 {
+		if f.intBased() {
+			buf.WriteString(fmt.Sprintf("switch %s {\n", receiver))
+		} else {
+			buf.WriteString(fmt.Sprintf("switch (string)(%s) {\n", receiver))
+		}
+
+		seen := make(map[string]struct{}, len(fields))
+		for _, field := range fields {
+			c := field.constName
+			if !f.intBased() {
+				c = fmt.Sprintf("%q", field.constValue)
+			}
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			seen[c] = struct{}{}
+			buf.WriteString(fmt.Sprintf("case %s:\nreturn %q\n", c, linkedTagValue(field.fieldName, field.tags, link.Tag)))
+		}
+		buf.WriteString("}\n")
+	}
+	buf.WriteString("return \"\"\n}\n")
+}
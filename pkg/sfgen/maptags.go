@@ -0,0 +1,72 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// TagMapping generates a function translating the constants of one tag into those of another, generated from the same
+// struct by another command, e.g. API sort fields into SQL columns.
+type TagMapping struct {
+	// From is the tag the constants are translated from, which must be the --tag of the command.
+	From string
+	// To is the tag of the constants translated into.
+	To string
+}
+
+// parseTagMapping parses a --map-tags value of the form from:to.
+func parseTagMapping(s string) (TagMapping, error) {
+	from, to, ok := strings.Cut(s, ":")
+	if !ok || from == "" || to == "" || from == to {
+		return TagMapping{}, fmt.Errorf("invalid --map-tags value %q, expected from:to, e.g. json:db", s)
+	}
+	return TagMapping{From: from, To: to}, nil
+}
+
+// mapFuncName returns the name of the --map-tags function of m, e.g. JSONToDB, prefixed with the struct name along
+// with the types, e.g. UserJSONToDB, and unexported along with them, e.g. jsonToDB.
+func mapFuncName(f FlagOptions, m TagMapping) string {
+	name := strings.ToUpper(m.From) + "To" + strings.ToUpper(m.To)
+	if !f.Export && !f.UseStructName {
+		name = strings.ToLower(m.From) + "To" + strings.ToUpper(m.To)
+	}
+	if f.UseStructName {
+		name = f.SourceStruct + name
+	}
+	return casedIdentifier(f.identifier(name), f.Export)
+}
+
+// writeTagMapping writes the function of m, translating the constants of the command into those generated from the
+// same fields by a command with the To tag of m and otherwise the same naming flags, backed by a map of the constants.
+// Fields excluded from the To tag, with a name of -, have no counterpart and report false.
+func writeTagMapping(buf *bytes.Buffer, f FlagOptions, m TagMapping, baseName string, fields []parsedField) {
+	to, unexported := f, f
+	to.Tag, to.Prefix = m.To, nil
+	unexported.Export = false
+	var (
+		toBase   = calculateBaseName(to)
+		funcName = mapFuncName(f, m)
+		mapName  = mapFuncName(unexported, m) + "Values"
+		fromType = baseName
+		toType   = toBase
+	)
+	if !f.hasMethods() {
+		fromType, toType = "string", "string"
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s maps the constants generated from the %s tag to those of the %s tag, see [%s].\n", mapName, m.From, m.To, funcName))
+	buf.WriteString(fmt.Sprintf("var %s = map[%s]%s{\n", mapName, fromType, toType))
+	for _, field := range fields {
+		if linkedTagValue(field.fieldName, field.tags, m.To) == "" {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("%s: %s,\n", field.constName, toBase+field.identName))
+	}
+	buf.WriteString("}\n")
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns the %s constant generated from the field of the %s constant c, and whether there is one.\n",
+		funcName, m.To, m.From))
+	buf.WriteString(fmt.Sprintf("func %s(c %s) (%s, bool) {\n", funcName, fromType, toType))
+	buf.WriteString(fmt.Sprintf("v, ok := %s[c]\nreturn v, ok\n}\n", mapName))
+}
@@ -0,0 +1,212 @@
+package sfgen
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS is an in-memory [FS], e.g. for tests, for serving generated code with [net/http.FS], or for applying it as a
+// golang.org/x/tools/go/packages overlay. The zero value is ready to use.
+type MemFS struct {
+	mu sync.RWMutex
+	// files holds the written files keyed by their [io/fs.FS] path, see [ioFSPath]
+	files map[string]memFile
+}
+
+// memFile is a file written to a [MemFS], along with the path it was written to.
+type memFile struct {
+	path string
+	data []byte
+}
+
+var (
+	_ fs.ReadFileFS = (*MemFS)(nil)
+	_ fs.StatFS     = (*MemFS)(nil)
+)
+
+// MkdirAll implements the [FS] interface. Directories are implied by the files written, so it is a no-op.
+func (m *MemFS) MkdirAll(string, os.FileMode) error {
+	return nil
+}
+
+// WriteFile implements the [FS] interface.
+func (m *MemFS) WriteFile(name string, data []byte, _ os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string]memFile)
+	}
+
+	m.files[ioFSPath(name)] = memFile{path: name, data: append([]byte(nil), data...)}
+	return nil
+}
+
+// Remove implements the optional Remove method of an [FS], removing the contents written to name. Files are addressed
+// by their written path, as by [MemFS.WriteFile].
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[ioFSPath(name)]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	delete(m.files, ioFSPath(name))
+	return nil
+}
+
+// ReadFile implements the [io/fs.ReadFileFS] interface, returning the contents written to name. Files are addressed as
+// by [MemFS.Open].
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	file, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), file.data...), nil
+}
+
+// Stat implements the [io/fs.StatFS] interface, returning the file info of the contents written to name, or of the
+// directory implied by them. Files are addressed as by [MemFS.Open].
+func (m *MemFS) Stat(name string) (os.FileInfo, error) {
+	f, err := m.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.Stat()
+}
+
+// Open implements the [io/fs.FS] interface. Files are addressed by their written path in slash separated form,
+// without the leading slash or volume name, e.g. home/user/project/user_field_generated.go. The directories holding
+// them can be opened as well, and list the files and directories below them.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if file, ok := m.files[name]; ok {
+		return &memFileReader{
+			info:   memFileInfo{name: path.Base(name), size: int64(len(file.data))},
+			Reader: bytes.NewReader(file.data),
+		}, nil
+	}
+
+	// Directories are implied by the paths of the files below them
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	children := make(map[string]memFileInfo)
+	for p, file := range m.files {
+		rest := strings.TrimPrefix(p, prefix)
+		if rest == p && prefix != "" {
+			continue
+		}
+
+		child, _, isDir := strings.Cut(rest, "/")
+		children[child] = memFileInfo{name: child, size: int64(len(file.data)), dir: isDir}
+	}
+	if len(children) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, info := range children {
+		if info.dir {
+			info.size = 0
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return &memDir{info: memFileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// Overlay returns the written files keyed by their absolute path, in the form expected by
+// golang.org/x/tools/go/packages.Config.Overlay.
+func (m *MemFS) Overlay() map[string][]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	overlay := make(map[string][]byte, len(m.files))
+	for _, file := range m.files {
+		overlay[file.path] = file.data
+	}
+	return overlay
+}
+
+// ioFSPath converts a written file path to the unrooted, slash separated form used by [io/fs.FS].
+func ioFSPath(path string) string {
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	return strings.TrimPrefix(filepath.ToSlash(path), "/")
+}
+
+// memFileInfo describes a file or directory of a [MemFS].
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return i.dir }
+func (i memFileInfo) Sys() any           { return nil }
+
+func (i memFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// memFileReader is an open file of a [MemFS].
+type memFileReader struct {
+	*bytes.Reader
+	info memFileInfo
+}
+
+func (f *memFileReader) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFileReader) Close() error               { return nil }
+
+// memDir is an open directory of a [MemFS].
+type memDir struct {
+	info    memFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements the [io/fs.ReadDirFile] interface.
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	return rest, nil
+}
@@ -0,0 +1,34 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// writeMirroredConstants writes aliases for the generated type and constants using the opposite casing of --export,
+// so that both exported and unexported names are available from a single command.
+func writeMirroredConstants(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField) {
+	mirrored := f
+	mirrored.Export = !f.Export
+	mirroredBaseName := calculateBaseName(mirrored)
+	if mirroredBaseName == baseName {
+		return
+	}
+
+	switch f.Style {
+	case StyleAlias, StyleTyped, StyleInt, StyleBitmask:
+		buf.WriteString(fmt.Sprintf("\n// %s is an alias of [%s].\n", mirroredBaseName, baseName))
+		buf.WriteString(fmt.Sprintf("type %s = %s\n", mirroredBaseName, baseName))
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// Aliases of the constants generated from [%s] struct field\n", f.SourceStruct))
+	buf.WriteString("const (")
+	for _, field := range fields {
+		buf.WriteString(fmt.Sprintf("\n%s%s = %s", mirroredBaseName, field.identName, field.constName))
+	}
+	buf.WriteString("\n)\n")
+}
@@ -0,0 +1,97 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// namespaceMember is the contribution of a single generate command to a --namespace var.
+type namespaceMember struct {
+	namespace  string
+	structName string
+	typeName   string
+	value      string
+}
+
+// namespaceTypeName returns the name of the struct type holding the fields of structName within namespace.
+func namespaceTypeName(f FlagOptions) string {
+	return casedIdentifier(f.identifier(f.Namespace+f.SourceStruct), f.Export)
+}
+
+// writeNamespaceType writes the struct type declaration for the fields of the source struct, and returns the
+// member that should be added to the namespace var.
+func writeNamespaceType(buf *bytes.Buffer, f FlagOptions, fields []parsedField) namespaceMember {
+	typeName := namespaceTypeName(f)
+
+	var value strings.Builder
+	value.WriteString(fmt.Sprintf("%s{", typeName))
+	buf.WriteString(fmt.Sprintf("// %s holds the constants generated from [%s] under [%s].\n", typeName, f.SourceStruct, f.Namespace))
+	buf.WriteString(fmt.Sprintf("type %s struct {", typeName))
+	for _, field := range fields {
+		var fieldType string
+		switch f.Style {
+		case StyleAlias, StyleTyped, StyleInt, StyleBitmask:
+			fieldType = field.baseName
+		case StyleGeneric:
+			fieldType = fmt.Sprintf("%s[%s]", field.baseName, field.fieldType)
+		default:
+			fieldType = "string"
+		}
+
+		buf.WriteString(fmt.Sprintf("\n%s %s", field.identName, fieldType))
+		value.WriteString(fmt.Sprintf("\n%s: %s,", field.identName, field.constName))
+	}
+	buf.WriteString("\n}\n")
+	value.WriteString("\n}")
+
+	return namespaceMember{
+		namespace:  f.Namespace,
+		structName: f.identifier(f.SourceStruct),
+		typeName:   typeName,
+		value:      value.String(),
+	}
+}
+
+// writeNamespaceVars writes one var per namespace, nesting each member under its source struct name.
+func writeNamespaceVars(buf *bytes.Buffer, members []namespaceMember) error {
+	var (
+		namespaces       []string
+		membersByNs      = make(map[string][]namespaceMember)
+		seenStructsPerNs = make(map[string]map[string]struct{})
+	)
+
+	for _, m := range members {
+		if _, ok := membersByNs[m.namespace]; !ok {
+			namespaces = append(namespaces, m.namespace)
+			seenStructsPerNs[m.namespace] = make(map[string]struct{})
+		}
+
+		if _, ok := seenStructsPerNs[m.namespace][m.structName]; ok {
+			return fmt.Errorf("struct %s is used more than once within namespace %s", m.structName, m.namespace)
+		}
+
+		seenStructsPerNs[m.namespace][m.structName] = struct{}{}
+		membersByNs[m.namespace] = append(membersByNs[m.namespace], m)
+	}
+
+	for _, ns := range namespaces {
+		structNames := make([]string, 0, len(membersByNs[ns]))
+		for _, m := range membersByNs[ns] {
+			structNames = append(structNames, m.structName)
+		}
+
+		buf.WriteString(fmt.Sprintf("\n// %s groups the constants generated from the %s structs.\n", ns, strings.Join(structNames, ", ")))
+		buf.WriteString(fmt.Sprintf("var %s = struct {", ns))
+		for _, m := range membersByNs[ns] {
+			buf.WriteString(fmt.Sprintf("\n%s %s", m.structName, m.typeName))
+		}
+		buf.WriteString("\n}{")
+		for _, m := range membersByNs[ns] {
+			buf.WriteString(fmt.Sprintf("\n%s: %s,", m.structName, m.value))
+		}
+		buf.WriteString("\n}\n")
+	}
+
+	return nil
+}
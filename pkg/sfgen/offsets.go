@@ -0,0 +1,139 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// unsafeOffsetsFile accumulates the --unsafe-offsets code of the targets sharing an output file.
+type unsafeOffsetsFile struct {
+	constraint string
+	code       bytes.Buffer
+	imports    map[string]struct{}
+}
+
+// add adds the offsets of target, generated with f, to the file.
+func (u *unsafeOffsetsFile) add(f FlagOptions, target parsedTarget) error {
+	if f.UnsafeOffsets == "" {
+		return nil
+	}
+
+	if u.constraint != "" && u.constraint != f.UnsafeOffsets {
+		return fmt.Errorf("invalid --unsafe-offsets usage. Cannot use both %q and %q build constraints within output file %q",
+			u.constraint, f.UnsafeOffsets, f.OutputFile)
+	}
+
+	u.constraint = f.UnsafeOffsets
+	u.code.Write(target.offsets)
+	if u.imports == nil {
+		u.imports = make(map[string]struct{})
+	}
+	for _, imp := range target.offsetsImports {
+		u.imports[imp] = struct{}{}
+	}
+	return nil
+}
+
+// generate returns the file declaring the accumulated offsets, placed next to the output file at outFile.
+func (u *unsafeOffsetsFile) generate(outFile, outPkg string) (FileResult, error) {
+	path := strings.TrimSuffix(outFile, ".go") + "_unsafe.go"
+
+	imports := []string{`"unsafe"`}
+	for imp := range u.imports {
+		imports = append(imports, fmt.Sprintf("%q", imp))
+	}
+	sort.Strings(imports)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("//go:build %s\n\n", u.constraint))
+	buf.WriteString("// Code generated by github.com/rad12000/go-sfgen; DO NOT EDIT.\n\n")
+	buf.WriteString(fmt.Sprintf("package %s\n\nimport (\n%s\n)\n", outPkg, strings.Join(imports, "\n")))
+	buf.Write(u.code.Bytes())
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return FileResult{}, fmt.Errorf("failed to format generated code for %s: %w", path, err)
+	}
+
+	return FileResult{Path: path, Package: outPkg, Content: formatted}, nil
+}
+
+// writeUnsafeOffsets writes a [const]Offset constant holding the offset of the field of each generated constant
+// within the source struct, and returns the imports the constants require. Nothing is written if no field qualifies. Fields that cannot be reached without a
+// pointer indirection, or that cannot be referenced from the output package, are omitted.
+func writeUnsafeOffsets(buf *bytes.Buffer, f FlagOptions, baseName string, structPkg *types.Package, s *types.Struct, fields []parsedField) []string {
+	var (
+		structExpr = f.SourceStruct + "{}"
+		external   = structPkg.Name() != f.OutputPackage
+		imports    []string
+	)
+	if external {
+		structExpr = structPkg.Name() + "." + structExpr
+		imports = append(imports, structPkg.Path())
+	}
+
+	var specs []string
+	for _, field := range fields {
+		selectors, ok := offsetSelectors(s, field.index, external)
+		if !ok {
+			continue
+		}
+
+		// Offsetof is relative to the struct the field is declared in, so the offsets of each struct along the path
+		// are summed.
+		terms := make([]string, len(selectors))
+		for i := range selectors {
+			terms[i] = fmt.Sprintf("unsafe.Offsetof(%s.%s)", structExpr, strings.Join(selectors[:i+1], "."))
+		}
+		specs = append(specs, fmt.Sprintf("%sOffset = %s", field.constName, strings.Join(terms, " + ")))
+	}
+
+	if len(specs) == 0 {
+		return nil
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// Offsets of the [%s] struct fields the constants were generated from\n", f.SourceStruct))
+	buf.WriteString("const (\n")
+	buf.WriteString(strings.Join(specs, "\n"))
+	buf.WriteString("\n)\n")
+
+	return imports
+}
+
+// offsetSelectors returns the names of the fields along index within s, reporting false if the path goes through a
+// pointer, or through a field that cannot be referenced from another package when external is true.
+func offsetSelectors(s *types.Struct, index []int, external bool) ([]string, bool) {
+	if len(index) == 0 {
+		return nil, false
+	}
+
+	selectors := make([]string, len(index))
+	for i, idx := range index {
+		field := s.Field(idx)
+		if external && !token.IsExported(field.Name()) {
+			return nil, false
+		}
+		selectors[i] = field.Name()
+
+		if i == len(index)-1 {
+			break
+		}
+
+		if _, ok := field.Type().Underlying().(*types.Pointer); ok {
+			return nil, false
+		}
+
+		next, ok := underlyingStruct(field.Type())
+		if !ok {
+			return nil, false
+		}
+		s = next
+	}
+
+	return selectors, true
+}
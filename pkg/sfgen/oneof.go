@@ -0,0 +1,63 @@
+package sfgen
+
+import (
+	"github.com/fatih/structtag"
+	"go/types"
+	"sort"
+)
+
+const protobufOneofTag = "protobuf_oneof"
+
+// oneofWrappers returns the wrapper structs protoc-gen-go generates for each case of a oneof field, in declaration
+// order. The second return value is false if the field is not a oneof field.
+func oneofWrappers(field *types.Var, tag string) ([]*types.Struct, bool) {
+	tags, err := structtag.Parse(tag)
+	if err != nil {
+		return nil, false
+	}
+
+	if _, err = tags.Get(protobufOneofTag); err != nil {
+		return nil, false
+	}
+
+	named, ok := field.Type().(*types.Named)
+	if !ok {
+		return nil, false
+	}
+
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil, false
+	}
+
+	var (
+		scope    = named.Obj().Pkg().Scope()
+		wrappers []*types.TypeName
+	)
+
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() {
+			continue
+		}
+
+		if _, ok = typeName.Type().Underlying().(*types.Struct); !ok {
+			continue
+		}
+
+		if types.Implements(types.NewPointer(typeName.Type()), iface) {
+			wrappers = append(wrappers, typeName)
+		}
+	}
+
+	sort.Slice(wrappers, func(i, j int) bool {
+		return wrappers[i].Pos() < wrappers[j].Pos()
+	})
+
+	structs := make([]*types.Struct, len(wrappers))
+	for i, w := range wrappers {
+		structs[i] = w.Type().Underlying().(*types.Struct)
+	}
+
+	return structs, true
+}
@@ -0,0 +1,95 @@
+package sfgen
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// inferPackage returns the package the code generated into dir belongs to when --out-pkg is empty, e.g. when running
+// outside go generate: the package of the non-test Go files of dir, or, for a new package without any, the base name
+// of dir stripped of the characters a package name cannot hold, e.g. userfields for user-fields. The files of dir are
+// read from the [FS] of g, see [Generator.goFiles].
+func (g *Generator) inferPackage(dir string) (string, error) {
+	files, err := g.goFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	fileSet := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		content, ok, err := readFile(g.fs, file)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+
+		pkg, err := parser.ParseFile(fileSet, file, content, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		return pkg.Name.Name, nil
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(dir))
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("cannot infer the package of %s from its name, --out-pkg must be provided", dir)
+	}
+	return name, nil
+}
+
+// goFiles returns the sorted paths of the Go files of dir in the [FS] of g. Directories are listed through its
+// [io/fs.FS] implementation, or from the host file system for [OSFS]. Other file systems cannot be listed, so dir is
+// assumed to hold no files, as by [readFile].
+func (g *Generator) goFiles(dir string) ([]string, error) {
+	var (
+		entries []fs.DirEntry
+		err     error
+	)
+	switch fsys := g.fs.(type) {
+	case fs.FS:
+		name := ioFSPath(dir)
+		if name == "" {
+			name = "."
+		}
+		entries, err = fs.ReadDir(fsys, name)
+	case OSFS:
+		entries, err = os.ReadDir(dir)
+	default:
+		return nil, nil
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files of %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,60 @@
+package sfgen
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestGenerateInferredPackage(t *testing.T) {
+	t.Setenv("GOPACKAGE", "")
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "package of the existing files",
+			files: map[string]string{"/out/user-api/api.go": "package api\n"},
+			want:  "package api",
+		},
+		{
+			name:  "test files are ignored",
+			files: map[string]string{"/out/user-api/api_test.go": "package api_test\n"},
+			want:  "package userapi",
+		},
+		{
+			name: "new package",
+			want: "package userapi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := new(MemFS)
+			for name, content := range tt.files {
+				if err := fsys.WriteFile(name, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			var f FlagOptions
+			if err := f.ParseString("--struct User --tag json --src-dir " + testSrcDir + " --out-dir /out/user-api"); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			g := NewGenerator(fsys, log.New(io.Discard, "", 0), testLoader{src: testModels})
+			result, err := g.Generate(context.Background(), []FlagOptions{f})
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+
+			if content := string(result.Files[0].Content); !strings.Contains(content, "\n"+tt.want+"\n") {
+				t.Errorf("missing %q in:\n%s", tt.want, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package sfgen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	blockBeginPrefix = "// sfgen:begin "
+	blockEndPrefix   = "// sfgen:end "
+	// sharedBlockTarget is the target of the block holding the declarations shared by the targets of an owner, such as
+	// --interface types and --namespace vars.
+	sharedBlockTarget = "shared"
+	// defaultOwner owns the blocks of commands run neither from a config file nor from a go:generate directive.
+	defaultOwner = "-"
+)
+
+// ownedBlock is the code generated by a single target within an output file, delimited by markers recording the owner
+// that generated it. The owner is the config file or the file of the go:generate directive the target was run from,
+// so that when several owners share an output file, regenerating one of them only replaces its own blocks and prunes
+// the blocks it no longer produces.
+type ownedBlock struct {
+	owner   string
+	target  string
+	imports []string
+	code    string
+}
+
+// targetBlockID returns the identifier of the block of the target generated with f, unique within its owner.
+func targetBlockID(f FlagOptions) string {
+	id := f.SourceStruct + "/" + calculateBaseName(f)
+	if f.SourcePackage != "" {
+		id = f.SourcePackage + "." + id
+	}
+	return id
+}
+
+// blockOwner returns the owner of the blocks generated with f, relative to the output directory.
+func blockOwner(f FlagOptions) string {
+	owner := f.owner
+	if owner == "" {
+		owner = os.Getenv("GOFILE")
+	}
+
+	if owner == "" {
+		return defaultOwner
+	}
+
+	if abs, err := filepath.Abs(owner); err == nil {
+		if rel, err := filepath.Rel(f.OutputDir, abs); err == nil {
+			owner = rel
+		}
+	}
+	return filepath.ToSlash(owner)
+}
+
+// writeOwnedBlock writes b along with its markers. The imports are deduplicated and sorted, so that the markers do
+// not change with the order the code of the block was generated in.
+func writeOwnedBlock(buf *bytes.Buffer, b ownedBlock) {
+	buf.WriteString(fmt.Sprintf("\n%sowner=%s target=%s", blockBeginPrefix, b.owner, b.target))
+	if imports := uniqueSorted(b.imports); len(imports) > 0 {
+		buf.WriteString(" imports=" + strings.Join(imports, ","))
+	}
+	buf.WriteString("\n\n")
+	buf.WriteString(strings.TrimSpace(b.code))
+	buf.WriteString(fmt.Sprintf("\n\n%sowner=%s target=%s\n", blockEndPrefix, b.owner, b.target))
+}
+
+// parseOwnedBlocks returns the package and owned blocks of an existing output file, reporting false if it was not
+// written with block markers.
+func parseOwnedBlocks(content []byte) (pkg string, blocks []ownedBlock, ok bool) {
+	var (
+		scanner = bufio.NewScanner(bytes.NewReader(content))
+		current *ownedBlock
+		code    strings.Builder
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case pkg == "" && strings.HasPrefix(line, "package "):
+			pkg = strings.TrimSpace(strings.TrimPrefix(line, "package "))
+		case current == nil && strings.HasPrefix(line, blockBeginPrefix):
+			current = &ownedBlock{}
+			for _, attr := range strings.Fields(strings.TrimPrefix(line, blockBeginPrefix)) {
+				key, value, _ := strings.Cut(attr, "=")
+				switch key {
+				case "owner":
+					current.owner = value
+				case "target":
+					current.target = value
+				case "imports":
+					current.imports = strings.Split(value, ",")
+				}
+			}
+			code.Reset()
+		case current != nil && strings.HasPrefix(line, blockEndPrefix):
+			current.code = code.String()
+			blocks = append(blocks, *current)
+			current = nil
+		case current != nil:
+			code.WriteString(line)
+			code.WriteByte('\n')
+		}
+	}
+
+	return pkg, blocks, len(blocks) > 0
+}
+
+// mergeOwnedBlocks returns blocks along with the blocks of other owners found in existing, the current content of the
+// output file. Blocks of the owners of blocks that are not in blocks are pruned. The result is ordered by owner,
+// keeping the order of the blocks of each owner.
+func mergeOwnedBlocks(existing []byte, outPkg string, blocks []ownedBlock) ([]ownedBlock, error) {
+	pkg, existingBlocks, ok := parseOwnedBlocks(existing)
+	if !ok {
+		return blocks, nil
+	}
+
+	if pkg != outPkg {
+		return nil, fmt.Errorf("cannot merge into existing file of package %s, the generated code belongs to package %s", pkg, outPkg)
+	}
+
+	owners := make(map[string]struct{})
+	for _, b := range blocks {
+		owners[b.owner] = struct{}{}
+	}
+
+	var merged []ownedBlock
+	for _, b := range existingBlocks {
+		if _, ok := owners[b.owner]; !ok {
+			merged = append(merged, b)
+		}
+	}
+	merged = append(merged, blocks...)
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].owner < merged[j].owner
+	})
+	return merged, nil
+}
+
+// RemovedIdentifiers returns the package level identifiers that existing, the current content of the output file,
+// declares in the block of target, but that the regenerated file no longer declares, e.g. the constants of fields
+// deleted from the struct since the file was last generated.
+func (r FileResult) RemovedIdentifiers(target TargetResult, existing []byte) ([]string, error) {
+	owner, id := blockOwner(target.Options), targetBlockID(target.Options)
+	blockCode := func(content []byte) []byte {
+		_, blocks, _ := parseOwnedBlocks(content)
+		for _, b := range blocks {
+			if b.owner == owner && b.target == id {
+				return []byte(b.code)
+			}
+		}
+		return nil
+	}
+
+	before, err := declaredIdentifiers(blockCode(existing))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse existing code of %s: %w", target.Options.SourceStruct, err)
+	}
+
+	after, err := declaredIdentifiers(blockCode(r.Content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generated code of %s: %w", target.Options.SourceStruct, err)
+	}
+
+	kept := make(map[string]struct{}, len(after))
+	for _, name := range after {
+		kept[name] = struct{}{}
+	}
+
+	var removed []string
+	for _, name := range before {
+		if _, ok := kept[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,225 @@
+package sfgen
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PackageSource describes a source dir or set of source files to load, and how to load them.
+type PackageSource struct {
+	// Dir is the absolute source dir, or the joined Files if they are provided. It may be a pattern such as
+	// /abs/path/..., in which case multiple packages may be returned.
+	Dir        string
+	Files      []string
+	BuildFlags []string
+	Env        []string
+	Offline    bool
+}
+
+// Loader loads the type information of the packages structs are read from.
+type Loader interface {
+	// Load returns the packages described by src, recording positions in fset. It is called concurrently, and should
+	// stop loading once ctx is done.
+	Load(ctx context.Context, fset *token.FileSet, src PackageSource) ([]*types.Package, error)
+}
+
+// PackagesLoader is the default [Loader]. It loads packages with golang.org/x/tools/go/packages, or by type checking
+// the files directly when PackageSource.Files are provided.
+type PackagesLoader struct{}
+
+// Load implements the [Loader] interface.
+func (PackagesLoader) Load(ctx context.Context, fset *token.FileSet, src PackageSource) ([]*types.Package, error) {
+	if len(src.Files) > 0 {
+		pkg, err := loadFiles(ctx, fset, src.Files)
+		if err != nil {
+			return nil, err
+		}
+		return []*types.Package{pkg}, nil
+	}
+
+	// Only the source packages are type checked from their syntax. Without NeedDeps, the types of their dependencies
+	// are read from the export data go list produces, which the build cache keeps across runs, rather than by type
+	// checking every transitively imported package, e.g. large SDKs whose types are never inspected. NeedTypesInfo is
+	// left out as only the package scopes are used.
+	p := src.Dir
+	cfg := packages.Config{
+		Context:    ctx,
+		Mode:       packages.NeedName | packages.NeedTypes | packages.NeedSyntax,
+		Fset:       fset,
+		BuildFlags: src.BuildFlags,
+		Env:        src.Env,
+	}
+
+	loadedPkg, err := packages.Load(&cfg, p)
+	if err != nil && src.Offline {
+		return nil, fmt.Errorf("failed to load package %s offline, dependencies may be missing from the module cache "+
+			"(run go mod download, or use --mod-mode vendor): %w", p, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package %s: %w", p, err)
+	}
+
+	if !isPackagePattern(p) && len(loadedPkg) != 1 {
+		return nil, fmt.Errorf("failed to load package %s: expected to find 1 package, found %d", p, len(loadedPkg))
+	}
+
+	if len(loadedPkg) == 0 {
+		return nil, fmt.Errorf("failed to load package %s: no packages matched", p)
+	}
+
+	pkgs := make([]*types.Package, len(loadedPkg))
+	for i, pkg := range loadedPkg {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("failed to load package %s: %v", pkg.PkgPath, pkg.Errors)
+		}
+
+		if pkg.Types == nil || pkg.Types.Scope() == nil {
+			return nil, fmt.Errorf("failed to load package %s: could not load scope", pkg.PkgPath)
+		}
+
+		pkgs[i] = pkg.Types
+	}
+
+	return pkgs, nil
+}
+
+// loadPackageScopes concurrently loads all package scopes for the provided sources one time.
+// Note: this function should be called once per run, and is not thread safe.
+func (g *Generator) loadPackageScopes(ctx context.Context, sources []PackageSource) error {
+	var (
+		seenPackages = make(map[string]string)
+		errCh        = make(chan error, len(sources))
+		doneCh       = make(chan struct{})
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+	)
+
+	g.packages = make(map[string][]*types.Package)
+	g.loadTimes = make(map[string]time.Duration)
+	g.loadWarnings = make(map[string][]string)
+	for _, src := range sources {
+		p, buildFlags := src.Dir, strings.Join(append(src.BuildFlags, fmt.Sprintf("offline=%t", src.Offline)), " ")
+		if seenFlags, ok := seenPackages[p]; ok {
+			if seenFlags != buildFlags {
+				return fmt.Errorf("conflicting build flags for package %s: %q and %q", p, seenFlags, buildFlags)
+			}
+			continue
+		}
+
+		seenPackages[p] = buildFlags
+		wg.Add(1)
+		go func(src PackageSource) {
+			defer wg.Done()
+			start := time.Now()
+			pkgs, err := g.loader.Load(ctx, g.fileSet, src)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			g.packages[src.Dir] = pkgs
+			g.loadTimes[src.Dir] = time.Since(start)
+			if imports := unresolvedImports(pkgs); len(imports) > 0 && len(src.Files) > 0 {
+				g.loadWarnings[src.Dir] = []string{fmt.Sprintf("failed to import %s from %s, the fields of their types are generated without type information",
+					strings.Join(imports, ", "), src.Dir)}
+			}
+		}(src)
+	}
+
+	go func() {
+		wg.Wait()
+		close(doneCh)
+	}()
+
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+			return fmt.Errorf("failed to load packages: %w", ctx.Err())
+		case <-doneCh:
+			return nil
+		}
+	}
+}
+
+// packagesForDir should only be called after loadPackageScopes has been. A single package is returned, unless the
+// packageDir is a pattern such as ./...
+func (g *Generator) packagesForDir(packageDir string) ([]*types.Package, bool) {
+	p, ok := g.packages[packageDir]
+	return p, ok
+}
+
+// isPackagePattern reports whether the source dir is a pattern matching all packages below it, e.g. ./...
+func isPackagePattern(packageDir string) bool {
+	return packageDir == "..." || strings.HasSuffix(packageDir, string(filepath.Separator)+"...") ||
+		strings.HasSuffix(packageDir, "/...")
+}
+
+// absPackageDir returns the absolute form of the source dir, preserving a trailing /... pattern.
+func absPackageDir(packageDir string) (string, error) {
+	if !isPackagePattern(packageDir) {
+		return filepath.Abs(packageDir)
+	}
+
+	abs, err := filepath.Abs(strings.TrimSuffix(packageDir, "..."))
+	if err != nil {
+		return "", err
+	}
+
+	return abs + string(filepath.Separator) + "...", nil
+}
+
+// structPackageDir returns the directory and name of the package declaring the --struct of f, to which --per-package
+// commands write. The directory is that of the file declaring the struct, as the loaded packages only record the
+// positions of their declarations.
+func (g *Generator) structPackageDir(f FlagOptions) (string, string, error) {
+	pkg, _, err := g.loadStruct(f.SourceStructDir, f.SourcePackage, f.SourceStruct)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse struct: %w", err)
+	}
+
+	obj := pkg.Scope().Lookup(f.SourceStruct)
+	file := g.fileSet.Position(obj.Pos()).Filename
+	if file == "" {
+		return "", "", fmt.Errorf("failed to find the directory of package %s", pkg.Path())
+	}
+
+	return filepath.Dir(file), pkg.Name(), nil
+}
+
+// mirroredDir returns the directory mirroring the package directory dir below the --generated-root root, at the path of
+// dir within its module, e.g. root/internal/models for the internal/models package of a module.
+func mirroredDir(root, dir string) (string, error) {
+	for modRoot := dir; ; {
+		if _, err := os.Stat(filepath.Join(modRoot, "go.mod")); err == nil {
+			rel, err := filepath.Rel(modRoot, dir)
+			if err != nil {
+				return "", fmt.Errorf("failed to mirror %s under --generated-root: %w", dir, err)
+			}
+			return filepath.Join(root, rel), nil
+		}
+
+		parent := filepath.Dir(modRoot)
+		if parent == modRoot {
+			return "", fmt.Errorf("failed to mirror %s under --generated-root, as it is not within a module", dir)
+		}
+		modRoot = parent
+	}
+}
+
+// objectPosition returns the file:line:column position of obj in the loaded packages.
+func (g *Generator) objectPosition(obj types.Object) string {
+	return g.fileSet.Position(obj.Pos()).String()
+}
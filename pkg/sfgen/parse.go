@@ -0,0 +1,60 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseFuncName returns the name of the --parse function of the type baseName, e.g. ParseUserField, unexported along
+// with the type.
+func parseFuncName(f FlagOptions, baseName string) string {
+	return casedIdentifier("Parse"+casedIdentifier(baseName, true), f.Export)
+}
+
+// writeParseFunc writes a function returning the constant holding a value, or an error if none does, e.g. to validate
+// values read from requests or configuration files. When several constants share a value, the first one is returned.
+// With the generic style, values are converted to the type parameter of the caller, as the constants of each field
+// have their own. It returns the imports the function requires.
+func writeParseFunc(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField) []string {
+	var (
+		funcName = parseFuncName(f, baseName)
+		typeName = baseName
+		seen     = make(map[string]struct{}, len(fields))
+	)
+	if f.Style == StyleGeneric {
+		typeName += "[T]"
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns the [%s] constant holding the value s, or an error if none does.\n", funcName, baseName))
+	if f.Style == StyleGeneric {
+		buf.WriteString(fmt.Sprintf("func %s[T any](s string) (%s, error) {\n", funcName, typeName))
+	} else {
+		buf.WriteString(fmt.Sprintf("func %s(s string) (%s, error) {\n", funcName, typeName))
+	}
+
+	if len(fields) > 0 {
+		buf.WriteString("switch s {\n")
+		var values []string
+		for _, field := range fields {
+			if _, ok := seen[field.constValue]; ok {
+				continue
+			}
+			seen[field.constValue] = struct{}{}
+
+			if f.Style == StyleGeneric {
+				values = append(values, fmt.Sprintf("%q", field.constValue))
+				continue
+			}
+			buf.WriteString(fmt.Sprintf("case %q:\nreturn %s, nil\n", field.constValue, field.constName))
+		}
+		if f.Style == StyleGeneric {
+			buf.WriteString(fmt.Sprintf("case %s:\nreturn %s(s), nil\n", strings.Join(values, ", "), typeName))
+		}
+		buf.WriteString("}\n")
+	}
+
+	buf.WriteString(fmt.Sprintf("var zero %s\n", typeName))
+	buf.WriteString(fmt.Sprintf("return zero, fmt.Errorf(\"invalid %s %%q\", s)\n}\n", baseName))
+	return []string{"fmt"}
+}
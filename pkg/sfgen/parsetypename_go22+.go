@@ -1,11 +1,10 @@
 //go:build go1.22
 
-package main
+package sfgen
 
 import (
 	"fmt"
 	"go/types"
-	"log"
 )
 
 func parseTypeName(structPackage string, t types.Type) (fieldType string, importPath []string) {
@@ -41,8 +40,6 @@ func parseTypeName(structPackage string, t types.Type) (fieldType string, import
 		return "any", nil
 	case *types.Alias, *types.Named:
 		return parseNamedType(structPackage, u)
-	default:
-		log.Fatalf("unhandled type %T: %s", t, t)
 	}
-	return "", nil
+	return qualifiedTypeString(structPackage, t)
 }
@@ -0,0 +1,46 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// writeSource writes a Source method returning the struct, Go field, and tag the constant holding the value is generated
+// from, e.g. to report an invalid sort field along with the struct it applies to, without maintaining a separate map.
+// The zero value is returned for values not held by a constant, including combined bits of the bitmask style. When
+// several constants share a value, the first one is described. The fields of --add-fields are described along with
+// their own struct.
+func writeSource(buf *bytes.Buffer, f FlagOptions, typeName, receiver string, fields []parsedField) {
+	buf.WriteString("// Source returns the struct field the constant holding the value is generated from, or the zero value if there is none\n")
+	buf.WriteString(fmt.Sprintf("func (%s %s) Source() (source struct{ Struct, GoField, Tag string }) {\n", receiver, typeName))
+	if len(fields) == 0 {
+		buf.WriteString("return source\n}\n")
+		return
+	}
+
+	if f.intBased() {
+		buf.WriteString(fmt.Sprintf("switch %s {\n", receiver))
+	} else {
+		buf.WriteString(fmt.Sprintf("switch (string)(%s) {\n", receiver))
+	}
+
+	seen := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		c := field.constName
+		if !f.intBased() {
+			c = fmt.Sprintf("%q", field.constValue)
+		}
+		if _, ok := seen[c]; ok {
+			continue
+		}
+		seen[c] = struct{}{}
+		if field.sourceStruct != "" {
+			buf.WriteString(fmt.Sprintf("case %s:\nsource.Struct, source.GoField, source.Tag = %q, %q, %q\nreturn source\n",
+				c, field.sourceStruct, field.fieldName, f.Tag))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("case %s:\nsource.GoField = %q\n", c, field.fieldName))
+	}
+	buf.WriteString("default:\nreturn source\n}\n")
+	buf.WriteString(fmt.Sprintf("source.Struct, source.Tag = %q, %q\nreturn source\n}\n", f.SourceStruct, f.Tag))
+}
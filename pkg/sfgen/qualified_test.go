@@ -0,0 +1,129 @@
+package sfgen
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// packagesLoader is a [Loader] type checking the source file of each package path held in memory, returning all of
+// them for every source dir, as for a pattern such as ./...
+type packagesLoader map[string]string
+
+func (l packagesLoader) Load(_ context.Context, fset *token.FileSet, _ PackageSource) ([]*types.Package, error) {
+	paths := make([]string, 0, len(l))
+	for path := range l {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	pkgs := make([]*types.Package, 0, len(paths))
+	for _, path := range paths {
+		file, err := parser.ParseFile(fset, "/src/"+path+"/types.go", l[path], 0)
+		if err != nil {
+			return nil, err
+		}
+
+		var cfg types.Config
+		pkg, err := cfg.Check("example.com/"+path, fset, []*ast.File{file}, nil)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+func TestGenerateQualifiedStruct(t *testing.T) {
+	loader := packagesLoader{
+		"models":  "package models\n\ntype User struct {\n\tEmail string `json:\"email\"`\n}\n",
+		"admin":   "package admin\n\ntype User struct {\n\tRole string `json:\"role\"`\n}\n",
+		"billing": "package billing\n\ntype Invoice struct {\n\tTotal int `json:\"total\"`\n}\n",
+	}
+
+	tests := []struct {
+		name    string
+		structs string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "unqualified name found in a single package",
+			structs: "Invoice",
+			want:    []string{`JSONFieldTotal = "total"`},
+		},
+		{
+			name:    "ambiguous unqualified name",
+			structs: "User",
+			wantErr: "type User is ambiguous, it was found in packages example.com/admin, example.com/models. Qualify --struct with the package name",
+		},
+		{
+			name:    "qualifier selecting among several packages",
+			structs: "admin.User",
+			want:    []string{`JSONFieldRole = "role"`},
+		},
+		{
+			name:    "other qualifier selecting among several packages",
+			structs: "models.User",
+			want:    []string{`JSONFieldEmail = "email"`},
+		},
+		{
+			name:    "unknown qualifier",
+			structs: "accounts.User",
+			wantErr: "type accounts.User not found in /src/...",
+		},
+		{
+			name:    "qualifier of another package",
+			structs: "billing.User",
+			wantErr: "type billing.User not found in /src/...",
+		},
+		{
+			name:    "invalid qualified name",
+			structs: "models.User.Email",
+			wantErr: `--struct must be of the form [package.]Struct, got "models.User.Email"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				f    FlagOptions
+				fsys = new(MemFS)
+			)
+			err := f.ParseString("--struct " + tt.structs + " --tag json --export --src-dir /src/... --out-dir /out --out-pkg out --out-file /out/out_generated.go")
+			if err == nil {
+				var (
+					g      = NewGenerator(fsys, log.New(io.Discard, "", 0), loader)
+					result *Result
+				)
+				if result, err = g.Generate(context.Background(), []FlagOptions{f}); err == nil {
+					err = g.Write(context.Background(), result)
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+
+			content := readTestFile(t, fsys, "/out/out_generated.go")
+			for _, s := range tt.want {
+				if !strings.Contains(content, s) {
+					t.Errorf("missing %q in: %s", s, content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	regionBegin = "// sfgen:region begin"
+	regionEnd   = "// sfgen:region end"
+)
+
+// replaceManagedRegion returns existing, a file maintained by hand or by another generator, with the lines between its
+// region markers replaced by blocks. The imports required by blocks must already be imported by the file, as the rest
+// of it is left untouched.
+func replaceManagedRegion(existing []byte, blocks []ownedBlock) ([]byte, error) {
+	var (
+		begin        = bytes.Index(existing, []byte(regionBegin))
+		end          = bytes.Index(existing, []byte(regionEnd))
+		beginLineEnd = -1
+		endLineStart = 0
+	)
+	if begin >= 0 && end > begin {
+		beginLineEnd = bytes.IndexByte(existing[begin:end], '\n') + begin
+		endLineStart = bytes.LastIndexByte(existing[:end], '\n') + 1
+	}
+
+	if beginLineEnd < begin || endLineStart <= beginLineEnd {
+		return nil, fmt.Errorf("no managed region found, add %q and %q lines where the generated code should be placed",
+			regionBegin, regionEnd)
+	}
+
+	if err := checkRegionImports(existing, blocks); err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(existing[:beginLineEnd+1])
+	for _, block := range blocks {
+		writeOwnedBlock(buf, block)
+	}
+	buf.WriteByte('\n')
+	buf.Write(existing[endLineStart:])
+	return buf.Bytes(), nil
+}
+
+// checkRegionImports returns an error listing the imports required by blocks that are missing from existing.
+func checkRegionImports(existing []byte, blocks []ownedBlock) error {
+	file, err := parser.ParseFile(token.NewFileSet(), "", existing, parser.ImportsOnly)
+	if err != nil {
+		return fmt.Errorf("failed to parse imports: %w", err)
+	}
+
+	imported := make(map[string]struct{}, len(file.Imports))
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+			imported[path] = struct{}{}
+		}
+	}
+
+	missing := make(map[string]struct{})
+	for _, block := range blocks {
+		for _, imp := range block.imports {
+			if _, ok := imported[imp]; !ok {
+				missing[imp] = struct{}{}
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(missing))
+	for path := range missing {
+		paths = append(paths, strconv.Quote(path))
+	}
+	sort.Strings(paths)
+	return fmt.Errorf("the generated code requires the imports %s, add them to the file", strings.Join(paths, ", "))
+}
@@ -0,0 +1,109 @@
+package sfgen
+
+import (
+	"fmt"
+	"go/types"
+	"time"
+)
+
+// Result describes the output of a generation run, without anything having been written.
+type Result struct {
+	// Files holds one entry per output file, sorted by path.
+	Files []FileResult
+	// Skipped holds the commands that were skipped, as the conditions of their --min-go or --platform flags are not
+	// met.
+	Skipped []SkippedTarget
+	// Removed holds the paths of the files that are no longer generated, such as the surplus parts of a
+	// --max-file-lines output that shrank, sorted. They are removed by [Generator.Write].
+	Removed []string
+}
+
+// FileResult describes a single generated file.
+type FileResult struct {
+	// Path is the absolute path the file is written to.
+	Path string
+	// Package is the package clause of the file. It is empty for files other than Go source, e.g. a --symbol-index.
+	Package string
+	// Content is the formatted content of the file.
+	Content []byte
+	// CreateOnly is true for files that are only written when absent, such as a --guard-test, so they keep their
+	// original contents across runs.
+	CreateOnly bool
+	// Targets holds one entry per generate command writing Go source to the file, in the order they were provided.
+	Targets []TargetResult
+}
+
+// TargetResult describes the output of a single generate command.
+type TargetResult struct {
+	// Options are the options of the command, with paths made absolute and defaults applied.
+	Options FlagOptions
+	// Fields are the constants generated from the fields of the --struct.
+	Fields []GeneratedField
+	// Code holds the declarations generated by the command, without the package clause, imports, or the declarations
+	// shared by the commands of the file, such as --interface types and --namespace vars. It may be embedded in files
+	// other than the output file, e.g. by codegen pipelines assembling their own files.
+	Code []byte
+	// Imports are the sorted import paths the Code depends on.
+	Imports []string
+	// Warnings are the non-fatal problems encountered while generating the command.
+	Warnings []string
+	// Timings are the durations of the phases of the command, logged by --timings.
+	Timings Timings
+}
+
+// Timings holds the durations of the phases of a single generate command. Phases shared by several commands are
+// reported in full for each of them.
+type Timings struct {
+	// Load is the duration of loading the package of the --struct. Commands loading the same package share it, as it
+	// is loaded once.
+	Load time.Duration
+	// Parse is the duration of looking up the --struct and parsing its fields.
+	Parse time.Duration
+	// Render is the duration of generating the code of the command from the parsed fields.
+	Render time.Duration
+	// Format is the duration of formatting the output file, shared by the commands writing to it.
+	Format time.Duration
+	// Write is the duration of writing the output file, shared by the commands writing to it. It is zero until the
+	// file is written by [Generator.Write].
+	Write time.Duration
+}
+
+// GeneratedField describes a constant generated from a struct field.
+type GeneratedField struct {
+	// Field is the name of the struct field.
+	Field string
+	// Const is the name of the generated constant.
+	Const string
+	// Value is the value of the generated constant.
+	Value string
+
+	// goType is the type of the struct field, as represented by the --emit languages, see [typeMap]
+	goType types.Type
+}
+
+// Warnings returns the warnings of every target of the result, followed by a notice for each skipped command.
+func (r *Result) Warnings() []string {
+	var all []string
+	for _, file := range r.Files {
+		for _, target := range file.Targets {
+			all = append(all, target.Warnings...)
+		}
+	}
+
+	for _, s := range r.Skipped {
+		name := s.Options.SourceStruct
+		if s.Options.AllStructs {
+			name = "the structs of " + s.Options.SourceStructDir
+		}
+		all = append(all, fmt.Sprintf("skipped generating %s: %s", name, s.Reason))
+	}
+
+	return all
+}
+
+// warnings collects the warnings of a single generate command.
+type warnings []string
+
+func (w *warnings) add(format string, v ...any) {
+	*w = append(*w, fmt.Sprintf(format, v...))
+}
@@ -0,0 +1,58 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// writeScanDest writes a [prefix]ScanDest function returning pointers to the fields of the source struct selected by
+// a list of generated constants, in order, so database/sql row scanning can be driven by the same constants used to
+// build the SELECT list. It returns the imports the function requires. Fields that cannot be reached without a pointer
+// indirection, or that cannot be referenced from the output package, are omitted, as are duplicate values.
+func writeScanDest(buf *bytes.Buffer, f FlagOptions, baseName string, structPkg *types.Package, s *types.Struct, fields []parsedField) []string {
+	var (
+		funcName   = baseName + "ScanDest"
+		structType = f.SourceStruct
+		external   = structPkg.Name() != f.OutputPackage
+		keyType    = "string"
+		imports    []string
+	)
+	if external {
+		structType = structPkg.Name() + "." + structType
+		imports = append(imports, structPkg.Path())
+	}
+
+	if f.Style == StyleAlias || f.Style == StyleTyped || f.intBased() {
+		keyType = baseName
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns pointers to the fields of s the provided constants were generated from, in order, e.g. for use\n", funcName))
+	buf.WriteString(fmt.Sprintf("// with [database/sql.Rows.Scan]. The entry of a value not generated from a field of [%s] is nil.\n", f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("func %s(s *%s, fields []%s) []any {\n", funcName, structType, keyType))
+	buf.WriteString("dest := make([]any, len(fields))\nfor i, field := range fields {\nswitch field {")
+
+	seenValues := make(map[string]struct{})
+	for _, field := range fields {
+		selectors, ok := offsetSelectors(s, field.index, external)
+		if !ok {
+			continue
+		}
+
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+
+		if f.Style == StyleGeneric {
+			buf.WriteString(fmt.Sprintf("\ncase %q:", field.constValue))
+		} else {
+			buf.WriteString(fmt.Sprintf("\ncase %s:", field.constName))
+		}
+		buf.WriteString(fmt.Sprintf("\ndest[i] = &s.%s", strings.Join(selectors, ".")))
+	}
+	buf.WriteString("\n}\n}\nreturn dest\n}\n")
+
+	return imports
+}
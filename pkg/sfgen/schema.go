@@ -0,0 +1,361 @@
+package sfgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of --schema the values of the constants are checked against.
+const (
+	// SchemaSQL reads the columns of a CREATE TABLE statement of SQL DDL.
+	SchemaSQL = "sql"
+	// SchemaJSON reads the properties of a JSON Schema, or of one of its $defs or definitions.
+	SchemaJSON = "jsonschema"
+	// SchemaProto reads the fields of a message of a .proto file.
+	SchemaProto = "proto"
+	// SchemaDB introspects the columns of a table of a live database, whose DSN is held by the Path of the
+	// [SchemaSource], see [dbColumns].
+	SchemaDB = "db"
+)
+
+// SchemaSource is an external schema the constants of a command must match, see [FlagOptions.Schema].
+type SchemaSource struct {
+	// Kind is the kind of schema, one of SchemaSQL, SchemaJSON, SchemaProto, or SchemaDB.
+	Kind string
+	// Path is the file holding the schema, or the DSN of the database for SchemaDB.
+	Path string
+	// Name is the table, definition, or message of the file describing the struct. It may be empty if the file holds a
+	// single table or message, or for the root of a JSON Schema.
+	Name string
+}
+
+// parseSchemaSource parses a --schema value of the form kind:path[#name].
+func parseSchemaSource(s string) (SchemaSource, error) {
+	kind, rest, ok := strings.Cut(s, ":")
+	if !ok || rest == "" {
+		return SchemaSource{}, fmt.Errorf("invalid --schema value %q, expected kind:path[#name]", s)
+	}
+
+	path, name, _ := strings.Cut(rest, "#")
+	switch kind {
+	case SchemaSQL, SchemaJSON, SchemaProto, SchemaDB:
+		return SchemaSource{Kind: kind, Path: path, Name: name}, nil
+	default:
+		return SchemaSource{}, fmt.Errorf("invalid --schema kind %q. Valid options are: %s, %s, %s, %s", kind, SchemaSQL, SchemaJSON, SchemaProto, SchemaDB)
+	}
+}
+
+// String returns the --schema value of s, with the password of a SchemaDB DSN redacted.
+func (s SchemaSource) String() string {
+	path := s.Path
+	if u, err := url.Parse(path); err == nil && s.Kind == SchemaDB {
+		path = u.Redacted()
+	}
+
+	if s.Name == "" {
+		return s.Kind + ":" + path
+	}
+	return s.Kind + ":" + path + "#" + s.Name
+}
+
+// checkSchema returns an error listing the values of the constants generated from fields that are not columns or
+// properties of the --schema of f, and those of the schema no constant holds, as the struct drifted from it. Databases
+// are no longer queried once ctx is done.
+func checkSchema(ctx context.Context, f FlagOptions, fields []parsedField) error {
+	if f.Schema.Kind == "" {
+		return nil
+	}
+
+	var (
+		names   []string
+		content []byte
+		err     error
+	)
+	if f.Schema.Kind != SchemaDB {
+		if content, err = os.ReadFile(f.Schema.Path); err != nil {
+			return fmt.Errorf("failed to read --schema %s: %w", f.Schema.Path, err)
+		}
+	}
+
+	switch f.Schema.Kind {
+	case SchemaDB:
+		names, err = dbColumns(ctx, f.Schema.Path, f.Schema.Name)
+	case SchemaSQL:
+		names, err = sqlColumns(string(content), f.Schema.Name)
+	case SchemaJSON:
+		names, err = jsonSchemaProperties(content, f.Schema.Name)
+	case SchemaProto:
+		names, err = protoFields(string(content), f.Schema.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read --schema %s: %w", f.Schema, err)
+	}
+
+	inSchema := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		inSchema[name] = struct{}{}
+	}
+
+	var (
+		inStruct    = make(map[string]struct{}, len(fields))
+		notInSchema []string
+		notInStruct []string
+	)
+	for _, field := range fields {
+		inStruct[field.constValue] = struct{}{}
+		if _, ok := inSchema[field.constValue]; !ok {
+			notInSchema = append(notInSchema, fmt.Sprintf("%s (%s)", field.constValue, field.fieldName))
+		}
+	}
+	for _, name := range names {
+		if _, ok := inStruct[name]; !ok {
+			notInStruct = append(notInStruct, name)
+		}
+	}
+	sort.Strings(notInStruct)
+
+	if len(notInSchema) == 0 && len(notInStruct) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s does not match --schema %s", f.SourceStruct, f.Schema))
+	if len(notInSchema) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\tmissing from the schema: %s", strings.Join(notInSchema, ", ")))
+	}
+	if len(notInStruct) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\tmissing from the struct: %s", strings.Join(notInStruct, ", ")))
+	}
+	return fmt.Errorf("%s", sb.String())
+}
+
+var (
+	// sqlCommentPattern matches the line and block comments of SQL.
+	sqlCommentPattern = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	// createTablePattern matches the start of a CREATE TABLE statement, up to the parenthesis opening its definitions.
+	createTablePattern = regexp.MustCompile(`(?i)\bCREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)\s*\(`)
+	// sqlConstraintPattern matches the table constraints listed along with the columns of a CREATE TABLE statement.
+	sqlConstraintPattern = regexp.MustCompile(`(?i)^(CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|KEY|INDEX|FULLTEXT|SPATIAL|EXCLUDE|LIKE)\b`)
+)
+
+// sqlColumns returns the columns of the CREATE TABLE statement of the table named table in ddl, which may be qualified
+// by its schema, e.g. public.users. An empty table selects the only statement of ddl.
+func sqlColumns(ddl, table string) ([]string, error) {
+	ddl = sqlCommentPattern.ReplaceAllString(ddl, "")
+
+	matches := createTablePattern.FindAllStringSubmatchIndex(ddl, -1)
+	if table == "" && len(matches) > 1 {
+		return nil, errors.New("several tables are declared, select one with #table")
+	}
+
+	var tables []string
+	for _, match := range matches {
+		name := unquoteSQLIdentifier(ddl[match[2]:match[3]])
+		tables = append(tables, name)
+
+		_, unqualified, qualified := strings.Cut(name, ".")
+		if table != "" && name != table && !(qualified && unqualified == table) {
+			continue
+		}
+
+		body, ok := enclosed(ddl[match[1]-1:], '(', ')')
+		if !ok {
+			return nil, fmt.Errorf("unterminated CREATE TABLE statement of %s", name)
+		}
+
+		var columns []string
+		for _, def := range splitTopLevel(body, ',') {
+			def = strings.TrimSpace(def)
+			if def == "" || sqlConstraintPattern.MatchString(def) {
+				continue
+			}
+
+			column := strings.Fields(def)[0]
+			if closing, ok := map[byte]string{'"': `"`, '`': "`", '[': "]"}[def[0]]; ok {
+				end := strings.Index(def[1:], closing)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated identifier in %q", def)
+				}
+				column = def[1 : end+1]
+			}
+			columns = append(columns, column)
+		}
+		return columns, nil
+	}
+
+	if table == "" {
+		return nil, errors.New("no CREATE TABLE statement found")
+	}
+	return nil, fmt.Errorf("table %s not found, found: %s", table, strings.Join(tables, ", "))
+}
+
+// unquoteSQLIdentifier removes the quotes of each part of the possibly qualified SQL identifier name.
+func unquoteSQLIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(part, "\"`[]")
+	}
+	return strings.Join(parts, ".")
+}
+
+// jsonSchemaProperties returns the names of the properties of the JSON Schema in content, or of its definition named
+// name, looked up in $defs and definitions.
+func jsonSchemaProperties(content []byte, name string) ([]string, error) {
+	type schema struct {
+		Properties  map[string]json.RawMessage `json:"properties"`
+		Defs        map[string]json.RawMessage `json:"$defs"`
+		Definitions map[string]json.RawMessage `json:"definitions"`
+	}
+
+	var root schema
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+
+	selected := root
+	if name != "" {
+		raw, ok := root.Defs[name]
+		if !ok {
+			raw, ok = root.Definitions[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("definition %s not found in $defs or definitions", name)
+		}
+
+		selected = schema{}
+		if err := json.Unmarshal(raw, &selected); err != nil {
+			return nil, fmt.Errorf("invalid definition %s: %w", name, err)
+		}
+	}
+
+	if selected.Properties == nil {
+		return nil, errors.New("no properties declared")
+	}
+
+	names := make([]string, 0, len(selected.Properties))
+	for property := range selected.Properties {
+		names = append(names, property)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+var (
+	// protoCommentPattern matches the line and block comments of a .proto file.
+	protoCommentPattern = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	// protoMessagePattern matches the start of a message declaration, up to its opening brace.
+	protoMessagePattern = regexp.MustCompile(`\bmessage\s+([A-Za-z_][A-Za-z0-9_]*)\s*\{`)
+	// protoBlockPattern matches the start of a declaration nested within a message, up to its opening brace.
+	protoBlockPattern = regexp.MustCompile(`\b(message|enum|oneof|extend)\s+[A-Za-z_][A-Za-z0-9_.]*\s*\{`)
+	// protoFieldPattern matches a field declaration, including map fields, capturing the field name.
+	protoFieldPattern = regexp.MustCompile(`^(?:(?:repeated|optional|required)\s+)?(?:map\s*<[^>]*>|[A-Za-z_][A-Za-z0-9_.]*)\s+([A-Za-z_][A-Za-z0-9_]*)\s*=\s*\d+`)
+)
+
+// protoFields returns the names of the fields of the message named message in the .proto file content, including
+// those of its oneofs. Nested messages and enums are not part of it. An empty message selects the only message of the
+// file.
+func protoFields(content, message string) ([]string, error) {
+	content = protoCommentPattern.ReplaceAllString(content, "")
+
+	matches := protoMessagePattern.FindAllStringSubmatchIndex(content, -1)
+	if message == "" && len(matches) > 1 {
+		return nil, errors.New("several messages are declared, select one with #message")
+	}
+
+	var messages []string
+	for _, match := range matches {
+		name := content[match[2]:match[3]]
+		messages = append(messages, name)
+		if message != "" && name != message {
+			continue
+		}
+
+		body, ok := enclosed(content[match[1]-1:], '{', '}')
+		if !ok {
+			return nil, fmt.Errorf("unterminated message %s", name)
+		}
+
+		var fields []string
+		for _, statement := range splitTopLevel(flattenProtoMessage(body), ';') {
+			if m := protoFieldPattern.FindStringSubmatch(strings.TrimSpace(statement)); m != nil {
+				fields = append(fields, m[1])
+			}
+		}
+		return fields, nil
+	}
+
+	if message == "" {
+		return nil, errors.New("no message found")
+	}
+	return nil, fmt.Errorf("message %s not found, found: %s", message, strings.Join(messages, ", "))
+}
+
+// flattenProtoMessage returns the body of a message without its nested messages, enums, and extensions, and with the
+// fields of its oneofs in place of their declaration, as they belong to the message.
+func flattenProtoMessage(body string) string {
+	for {
+		match := protoBlockPattern.FindStringSubmatchIndex(body)
+		if match == nil {
+			return body
+		}
+
+		inner, ok := enclosed(body[match[1]-1:], '{', '}')
+		if !ok {
+			return body[:match[0]]
+		}
+
+		var keep string
+		if body[match[2]:match[3]] == "oneof" {
+			keep = inner
+		}
+		body = body[:match[0]] + keep + ";" + body[match[1]+len(inner)+1:]
+	}
+}
+
+// enclosed returns the content of s between its first character, open, and the matching close.
+func enclosed(s string, open, close byte) (string, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return s[1:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitTopLevel splits s around each sep outside of parentheses, e.g. the column definitions of a CREATE TABLE
+// statement, but not the arguments of their types such as NUMERIC(10, 2).
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,121 @@
+package sfgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// dbColumns returns the columns of table, which may be qualified by its schema, e.g. public.users, as introspected
+// from the live database at dsn. Environment variables of dsn are expanded, so that credentials are not committed
+// along with the directive, e.g. $DATABASE_URL.
+//
+// No database driver is linked into go-sfgen, so the database is queried with the client of its engine, which must be
+// in PATH: psql for postgres:// and postgresql:// DSNs, mysql for mysql:// DSNs, and sqlite3 for sqlite:// DSNs. The
+// client is killed once ctx is done, e.g. by --timeout, so an unreachable database does not hang generation.
+func dbColumns(ctx context.Context, dsn, table string) ([]string, error) {
+	dsn = os.ExpandEnv(dsn)
+	if table == "" {
+		return nil, errors.New("a table must be selected with #table")
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+
+	schema, name, qualified := strings.Cut(table, ".")
+	if !qualified {
+		schema, name = "", table
+	}
+
+	var cmd *exec.Cmd
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		query := fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_schema = %s AND table_name = %s ORDER BY ordinal_position",
+			sqlLiteralOr(schema, "current_schema()"), sqlLiteral(name))
+		// The password is passed through the environment rather than the arguments, which other users may list
+		password, hasPassword := u.User.Password()
+		if hasPassword {
+			u.User = url.User(u.User.Username())
+		}
+		cmd = exec.CommandContext(ctx, "psql", u.String(), "--no-psqlrc", "--tuples-only", "--no-align", "--quiet", "--command", query)
+		if hasPassword {
+			cmd.Env = append(os.Environ(), "PGPASSWORD="+password)
+		}
+	case "mysql":
+		query := fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_schema = %s AND table_name = %s ORDER BY ordinal_position",
+			sqlLiteralOr(schema, "DATABASE()"), sqlLiteral(name))
+		args := []string{"--batch", "--skip-column-names", "--execute", query}
+		if host := u.Hostname(); host != "" {
+			args = append(args, "--host", host)
+		}
+		if port := u.Port(); port != "" {
+			args = append(args, "--port", port)
+		}
+		if user := u.User.Username(); user != "" {
+			args = append(args, "--user", user)
+		}
+		if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+			args = append(args, "--database", db)
+		}
+		cmd = exec.CommandContext(ctx, "mysql", args...)
+		if password, ok := u.User.Password(); ok {
+			cmd.Env = append(os.Environ(), "MYSQL_PWD="+password)
+		}
+	case "sqlite":
+		path := u.Opaque
+		if path == "" {
+			path = u.Host + u.Path
+		}
+		query := fmt.Sprintf("SELECT name FROM pragma_table_info(%s)", sqlLiteral(name))
+		if schema != "" {
+			query = fmt.Sprintf("SELECT name FROM pragma_table_info(%s, %s)", sqlLiteral(name), sqlLiteral(schema))
+		}
+		cmd = exec.CommandContext(ctx, "sqlite3", path, query)
+	default:
+		return nil, fmt.Errorf("unsupported DSN scheme %q. Valid schemes are: postgres, postgresql, mysql, sqlite", u.Scheme)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s is required to introspect %s databases, but was not found in PATH", cmd.Args[0], u.Scheme)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("failed to query the columns of %s with %s: %w", table, cmd.Args[0], ctxErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the columns of %s with %s: %w: %s", table, cmd.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	var columns []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if column := strings.TrimSpace(line); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	return columns, nil
+}
+
+// sqlLiteral returns s quoted as an SQL string literal.
+func sqlLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// sqlLiteralOr returns s quoted as an SQL string literal, or the expression fallback if s is empty.
+func sqlLiteralOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return sqlLiteral(s)
+}
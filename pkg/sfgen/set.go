@@ -0,0 +1,46 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// setTypeName returns the name of the --gen-set type of the type baseName, e.g. UserFieldSet, exported along with
+// the type.
+func setTypeName(f FlagOptions, baseName string) string {
+	return casedIdentifier(baseName+"Set", f.Export)
+}
+
+// writeSet writes a map backed set of the constants of baseName, with a constructor from constants and the Contains,
+// Add, and Delete methods, e.g. to hold the fields allowed in a sparse update. The set holds values of the generated
+// type, or strings for the constants of the alias style and those declared without a style.
+func writeSet(buf *bytes.Buffer, f FlagOptions, baseName string) {
+	var (
+		setName  = setTypeName(f, baseName)
+		ctorName = casedIdentifier("New"+casedIdentifier(setName, true), f.Export)
+		elemType = baseName
+	)
+	if !f.hasMethods() {
+		elemType = "string"
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s is a set of the constants generated from [%s].\n", setName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("type %s map[%s]struct{}\n", setName, elemType))
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns a [%s] holding values.\n", ctorName, setName))
+	buf.WriteString(fmt.Sprintf("func %s(values ...%s) %s {\n", ctorName, elemType, setName))
+	buf.WriteString(fmt.Sprintf("s := make(%s, len(values))\n", setName))
+	buf.WriteString("s.Add(values...)\nreturn s\n}\n")
+
+	buf.WriteString("\n// Contains reports whether s holds v.\n")
+	buf.WriteString(fmt.Sprintf("func (s %s) Contains(v %s) bool {\n", setName, elemType))
+	buf.WriteString("_, ok := s[v]\nreturn ok\n}\n")
+
+	buf.WriteString("\n// Add adds values to s.\n")
+	buf.WriteString(fmt.Sprintf("func (s %s) Add(values ...%s) {\n", setName, elemType))
+	buf.WriteString("for _, v := range values {\ns[v] = struct{}{}\n}\n}\n")
+
+	buf.WriteString("\n// Delete removes values from s.\n")
+	buf.WriteString(fmt.Sprintf("func (s %s) Delete(values ...%s) {\n", setName, elemType))
+	buf.WriteString("for _, v := range values {\ndelete(s, v)\n}\n}\n")
+}
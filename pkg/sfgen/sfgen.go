@@ -0,0 +1,434 @@
+// Package sfgen generates constants from struct fields. It backs the go-sfgen command, and may be used directly to run
+// generation without shelling out to the binary, e.g. from mage targets.
+package sfgen
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/google/shlex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run generates the code for each of the provided options using a [Generator] with the default dependencies.
+func Run(ctx context.Context, flagOptions []FlagOptions) error {
+	return NewGenerator(nil, nil, nil).Run(ctx, flagOptions)
+}
+
+// Generate generates the code for each of the provided options using a [Generator] with the default dependencies,
+// without writing it.
+func Generate(ctx context.Context, flagOptions []FlagOptions) (*Result, error) {
+	return NewGenerator(nil, nil, nil).Generate(ctx, flagOptions)
+}
+
+// Write writes the files of a result returned by [Generate] using a [Generator] with the default dependencies.
+func Write(ctx context.Context, result *Result) error {
+	return NewGenerator(nil, nil, nil).Write(ctx, result)
+}
+
+// Run generates the code for each of the provided options. Options sharing an output file are written to that file
+// together, warnings and --timings are logged, and the --chain commands are run once every file is written. Nothing is written once
+// ctx is done.
+func (g *Generator) Run(ctx context.Context, flagOptions []FlagOptions) error {
+	result, err := g.Generate(ctx, flagOptions)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range result.Warnings() {
+		g.logger.Printf("warning: %s", w)
+	}
+
+	if err = g.Write(ctx, result); err != nil {
+		return err
+	}
+	g.logTimings(result)
+
+	return g.runChains(ctx, result)
+}
+
+// Write writes the files of a result returned by [Generator.Generate], skipping the files only written when absent
+// that already exist, and then removes its files no longer generated. Nothing is written once ctx is done.
+func (g *Generator) Write(ctx context.Context, result *Result) error {
+	for i, file := range result.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if file.CreateOnly {
+			exists, err := fileExists(g.fs, file.Path)
+			if err != nil {
+				return fmt.Errorf("failed to check out file %s: %w", file.Path, err)
+			}
+
+			if exists {
+				continue
+			}
+		}
+
+		if err := g.fs.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		if err := g.fs.WriteFile(file.Path, file.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write to out file %s: %w", file.Path, err)
+		}
+		for j := range file.Targets {
+			result.Files[i].Targets[j].Timings.Write = time.Since(start)
+		}
+	}
+
+	for _, path := range result.Removed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := removeFile(g.fs, path); err != nil {
+			return fmt.Errorf("failed to remove out file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Generate generates the code for each of the provided options, returning the files rather than writing them, so
+// callers may post-process or aggregate the output. Options sharing an output file are generated into that file
+// together.
+//
+// The environment of the process is left untouched. Type aliases are only told apart from the types they refer to
+// with GODEBUG=gotypesalias=1, the default from Go 1.23, which the go-sfgen command sets on startup.
+func (g *Generator) Generate(ctx context.Context, flagOptions []FlagOptions) (*Result, error) {
+	var (
+		outputFileGroups = make(map[string][]FlagOptions)
+		packageSources   = make([]PackageSource, 0, len(flagOptions))
+		sharedDeclFiles  = make(map[string]string)
+		stableIDFiles    = make(map[string]string)
+	)
+
+	var (
+		skipped []SkippedTarget
+		targets = make([]FlagOptions, 0, len(flagOptions))
+	)
+	for _, fOpt := range flagOptions {
+		if reason := fOpt.skipReason(); reason != "" {
+			skipped = append(skipped, SkippedTarget{Options: fOpt, Reason: reason})
+			continue
+		}
+
+		src, err := fOpt.packageSource()
+		if err != nil {
+			return nil, err
+		}
+		packageSources = append(packageSources, src)
+		fOpt.SourceStructDir = src.Dir
+
+		// The owner is made absolute, so the options of the result identify their blocks from any directory
+		if fOpt.owner == "" {
+			fOpt.owner = os.Getenv("GOFILE")
+		}
+		if fOpt.owner != "" {
+			if fOpt.owner, err = filepath.Abs(fOpt.owner); err != nil {
+				return nil, fmt.Errorf("failed to get absolute path to %q: %w", fOpt.owner, err)
+			}
+		}
+		targets = append(targets, fOpt)
+	}
+
+	err := g.loadPackageScopes(ctx, packageSources)
+	if err != nil {
+		return nil, err
+	}
+
+	// The structs of --all-structs commands are only known once their packages are loaded
+	if targets, err = g.expandAllStructs(targets); err != nil {
+		return nil, err
+	}
+	if targets, err = g.expandBindings(targets); err != nil {
+		return nil, err
+	}
+
+	for _, fOpt := range targets {
+		if fOpt.PerPackage || fOpt.GeneratedRoot != "" {
+			if fOpt.OutputDir, fOpt.OutputPackage, err = g.structPackageDir(fOpt); err != nil {
+				return nil, err
+			}
+		}
+		if fOpt.GeneratedRoot != "" {
+			if fOpt.OutputDir, err = mirroredDir(fOpt.GeneratedRoot, fOpt.OutputDir); err != nil {
+				return nil, err
+			}
+		}
+
+		if fOpt.OutputFile == "" {
+			fOpt.OutputFile = fmt.Sprintf("%s_%s_generated.go", strings.ToLower(fOpt.SourceStruct), strings.ToLower(calculateBaseName(fOpt)))
+		}
+
+		absOutDir, err := filepath.Abs(fOpt.OutputDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path to out file %q: %w", fOpt.OutputFile, err)
+		}
+
+		absOut := filepath.Join(absOutDir, fOpt.OutputFile)
+		if filepath.IsAbs(fOpt.OutputFile) {
+			absOut, absOutDir = fOpt.OutputFile, filepath.Dir(fOpt.OutputFile)
+		}
+		fOpt.OutputDir = absOutDir
+		fOpt.OutputFile = absOut
+		if fOpt.SymbolIndex != "" {
+			if fOpt.SymbolIndex, err = filepath.Abs(fOpt.SymbolIndex); err != nil {
+				return nil, fmt.Errorf("failed to get absolute path to symbol index %q: %w", fOpt.SymbolIndex, err)
+			}
+		}
+		if fOpt.StableIDs != "" {
+			if fOpt.StableIDs, err = filepath.Abs(fOpt.StableIDs); err != nil {
+				return nil, fmt.Errorf("failed to get absolute path to stable IDs %q: %w", fOpt.StableIDs, err)
+			}
+
+			target := fOpt.SourceStruct + " " + calculateBaseName(fOpt)
+			if other, ok := stableIDFiles[fOpt.StableIDs]; ok {
+				return nil, fmt.Errorf("invalid --stable-ids usage. %q cannot be shared by %s and %s", fOpt.StableIDs, other, target)
+			}
+			stableIDFiles[fOpt.StableIDs] = target
+		}
+		emitters := make([]Emitter, len(fOpt.Emitters))
+		for i, e := range fOpt.Emitters {
+			emitters[i] = e
+			if emitters[i].Path, err = filepath.Abs(e.Path); err != nil {
+				return nil, fmt.Errorf("failed to get absolute path to emitter output %q: %w", e.Path, err)
+			}
+		}
+		fOpt.Emitters = emitters
+		currentOpts := outputFileGroups[absOut]
+		if len(currentOpts) > 0 && currentOpts[0].OutputPackage != fOpt.OutputPackage {
+			return nil, fmt.Errorf("invalid package values provided. Cannot use both %q and %q package values within output file %q",
+				currentOpts[0].OutputFile, fOpt.OutputPackage, fOpt.OutputFile)
+		}
+		if len(currentOpts) > 0 && currentOpts[0].ManagedRegion != fOpt.ManagedRegion {
+			return nil, fmt.Errorf("invalid --managed-region usage. Either all or none of the commands writing to %q must use it", absOut)
+		}
+		if len(currentOpts) > 0 && currentOpts[0].MaxFileLines != fOpt.MaxFileLines {
+			return nil, fmt.Errorf("invalid --max-file-lines usage. All of the commands writing to %q must use the same value", absOut)
+		}
+		for _, shared := range []string{fOpt.Namespace, fOpt.Interface} {
+			if shared == "" {
+				continue
+			}
+
+			if sharedFile, ok := sharedDeclFiles[shared]; ok && sharedFile != absOut {
+				return nil, fmt.Errorf("invalid --namespace or --interface usage. %q cannot be declared in both %q and %q",
+					shared, sharedFile, absOut)
+			}
+			sharedDeclFiles[shared] = absOut
+		}
+		outputFileGroups[absOut] = append(outputFileGroups[absOut], fOpt)
+	}
+
+	for _, group := range outputFileGroups {
+		for i, fOpt := range group {
+			if fOpt.OutputPackage != "" {
+				continue
+			}
+
+			// With --src-files, the package of the source files is used
+			if len(fOpt.SourceFiles) > 0 {
+				pkgs, ok := g.packagesForDir(fOpt.SourceStructDir)
+				if !ok || len(pkgs) == 0 {
+					return nil, fmt.Errorf("failed to find package scope: %s", fOpt.SourceStructDir)
+				}
+				group[i].OutputPackage = pkgs[0].Name()
+				continue
+			}
+
+			if group[i].OutputPackage, err = g.inferPackage(fOpt.OutputDir); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		result   = &Result{Skipped: skipped}
+	)
+	for _, group := range outputFileGroups {
+		wg.Add(1)
+		go func(group []FlagOptions) {
+			defer wg.Done()
+			files, removed, err := g.generateFileGroup(ctx, group)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			result.Files = append(result.Files, files...)
+			result.Removed = append(result.Removed, removed...)
+		}(group)
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	byPath := func(i, j int) bool {
+		return result.Files[i].Path < result.Files[j].Path
+	}
+	sort.Slice(result.Files, byPath)
+	sort.Strings(result.Removed)
+
+	if err := checkFuncOptionNames(result.Files); err != nil {
+		return nil, err
+	}
+
+	indexes, err := symbolIndexFiles(result.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	guards, err := guardTestFiles(result.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	emitted, err := emitterFiles(result.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := g.gitattributesFiles(result.Files)
+	if err != nil {
+		return nil, err
+	}
+	result.Files = append(append(append(append(result.Files, indexes...), guards...), emitted...), attributes...)
+	sort.Slice(result.Files, byPath)
+
+	return result, nil
+}
+
+// packageSource returns the source the --struct is loaded from. Its Dir is the absolute --src-dir, or the joined
+// absolute --src-files, which are loaded as a single package looked up by their joined paths in place of a source dir.
+func (f *FlagOptions) packageSource() (PackageSource, error) {
+	if len(f.SourceFiles) > 0 {
+		absFiles := make([]string, len(f.SourceFiles))
+		for i, file := range f.SourceFiles {
+			var err error
+			if absFiles[i], err = filepath.Abs(file); err != nil {
+				return PackageSource{}, fmt.Errorf("failed to parse source file: %s", file)
+			}
+		}
+
+		return PackageSource{Dir: strings.Join(absFiles, string(filepath.ListSeparator)), Files: absFiles}, nil
+	}
+
+	absSrcDir, err := absPackageDir(f.SourceStructDir)
+	if err != nil {
+		return PackageSource{}, fmt.Errorf("failed to parse source dir: %s", f.SourceStructDir)
+	}
+
+	return PackageSource{
+		Dir:        absSrcDir,
+		BuildFlags: f.BuildFlags(),
+		Env:        f.LoadEnv(),
+		Offline:    f.Offline,
+	}, nil
+}
+
+// RunConfig executes the generate commands listed in the config file at path using a [Generator] with the default
+// dependencies. Each non-empty line of the file accepts the same flags as a --gen string, and lines starting with #
+// are ignored. Relative --src-dir, --src-files, --out-dir, --symbol-index, and --emit paths are resolved against the
+// directory containing the config file. The flags of a [DefaultsFile] in that directory are merged under each line.
+//
+// Files with a .yaml, .yml, or .toml extension instead list one entry per generate command under a top level targets
+// key, whose keys are named after the flags they set, e.g. out-file. Keys set to a list are provided once per item,
+// as repeated flags.
+func RunConfig(path string) error {
+	return RunConfigContext(context.Background(), path)
+}
+
+// RunConfigContext is like [RunConfig], but stops generation once ctx is done.
+func RunConfigContext(ctx context.Context, path string) error {
+	return NewGenerator(nil, nil, nil).RunConfig(ctx, path)
+}
+
+// RunConfig executes the generate commands listed in the config file at path. See [RunConfig] for the file format.
+func (g *Generator) RunConfig(ctx context.Context, path string) error {
+	flagOptions, err := ParseConfig(path)
+	if err != nil {
+		return err
+	}
+
+	return g.Run(ctx, flagOptions)
+}
+
+// ParseConfig parses the generate commands listed in the config file at path. See [RunConfig] for the file format.
+func ParseConfig(path string) ([]FlagOptions, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file %s: %w", path, err)
+	}
+
+	var targets []configTarget
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		targets, err = parseYAMLConfig(string(content))
+	case ".toml":
+		targets, err = parseTOMLConfig(string(content))
+	default:
+		targets, err = parseLineConfig(string(content))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	configDir := filepath.Dir(path)
+	defaults, err := ReadDefaults(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	flagOptions := make([]FlagOptions, 0, len(targets))
+	for _, target := range targets {
+		f := FlagOptions{defaults: defaults}
+		if err = f.Parse(target.args); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, target.line, err)
+		}
+
+		f.resolvePaths(configDir)
+		f.owner = path
+		flagOptions = append(flagOptions, f)
+	}
+
+	return flagOptions, nil
+}
+
+// parseLineConfig parses the targets of a config file listing the flags of one target per line.
+func parseLineConfig(content string) ([]configTarget, error) {
+	var (
+		targets []configTarget
+		scanner = bufio.NewScanner(strings.NewReader(content))
+		lineNum int
+	)
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := shlex.Split(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: failed to parse flag string: %w", lineNum, err)
+		}
+		targets = append(targets, configTarget{line: lineNum, args: args})
+	}
+
+	return targets, scanner.Err()
+}
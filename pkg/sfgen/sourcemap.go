@@ -0,0 +1,18 @@
+package sfgen
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+)
+
+// sourceMapLocation returns the file:line of pos, relative to outDir when possible, so the generated output does not
+// depend on where the module is checked out.
+func sourceMapLocation(outDir string, pos token.Position) string {
+	file := pos.Filename
+	if rel, err := filepath.Rel(outDir, file); err == nil {
+		file = rel
+	}
+
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(file), pos.Line)
+}
@@ -0,0 +1,110 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// partPath returns the path of the nth part of the --max-file-lines output file at path, e.g. user_generated_2.go for
+// the second part of user_generated.go. The first part is the output file itself.
+func partPath(path string, n int) string {
+	if n == 1 {
+		return path
+	}
+	return fmt.Sprintf("%s_%d.go", strings.TrimSuffix(path, ".go"), n)
+}
+
+// readParts returns the contents of the existing parts of the output file at path following the first one, in order.
+func readParts(fsys FS, path string) ([][]byte, error) {
+	var parts [][]byte
+	for n := 2; ; n++ {
+		content, ok, err := readFile(fsys, partPath(path, n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read existing out file %s: %w", partPath(path, n), err)
+		}
+
+		if !ok {
+			return parts, nil
+		}
+		parts = append(parts, content)
+	}
+}
+
+// splitOutputFile returns the parts of the output file holding blocks, each below the --max-file-lines of flagOptions
+// unless a single block exceeds it. Blocks are never split, and keep their order across the parts. formatted is the
+// content of the whole file, returned as the only part when it is short enough. The targets are returned with the part
+// holding their block.
+//
+// The first existingParts parts are always returned, those no longer needed as the output shrank holding no blocks.
+func splitOutputFile(flagOptions []FlagOptions, outPkg string, blocks []ownedBlock, formatted []byte,
+	targets []TargetResult, existingParts int) ([]FileResult, error) {
+	var (
+		outFile  = flagOptions[0].OutputFile
+		maxLines = flagOptions[0].MaxFileLines
+		parts    [][]ownedBlock
+	)
+
+	render := func(blocks []ownedBlock) ([]byte, error) {
+		content, err := writeOutputFile(flagOptions, outPkg, blocks)
+		if err != nil {
+			return nil, err
+		}
+
+		// The whole file was already formatted, or written unformatted with a warning under --no-format
+		if formatted, err := format.Source(content); err == nil {
+			content = formatted
+		}
+		return content, nil
+	}
+
+	if bytes.Count(formatted, []byte("\n")) <= maxLines {
+		parts = [][]ownedBlock{blocks}
+	} else {
+		var current []ownedBlock
+		for _, b := range blocks {
+			candidate := append(append([]ownedBlock(nil), current...), b)
+			content, err := render(candidate)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(current) > 0 && bytes.Count(content, []byte("\n")) > maxLines {
+				parts = append(parts, current)
+				candidate = []ownedBlock{b}
+			}
+			current = candidate
+		}
+		parts = append(parts, current)
+	}
+
+	for len(parts) < existingParts+1 {
+		parts = append(parts, nil)
+	}
+
+	files := make([]FileResult, len(parts))
+	for i, part := range parts {
+		files[i] = FileResult{Path: partPath(outFile, i+1), Package: outPkg}
+		if len(parts) == 1 {
+			files[i].Content = formatted
+		} else {
+			content, err := render(part)
+			if err != nil {
+				return nil, err
+			}
+			files[i].Content = content
+		}
+
+		for _, target := range targets {
+			for _, b := range part {
+				if b.owner == blockOwner(target.Options) && b.target == targetBlockID(target.Options) {
+					files[i].Targets = append(files[i].Targets, target)
+					break
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,84 @@
+package sfgen
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestGenerateMaxFileLines(t *testing.T) {
+	var (
+		user    = testCommand{owner: "/src/models/models.go", args: "--struct User --tag json --max-file-lines 15 --out-file " + testOutFile}
+		account = testCommand{owner: "/src/models/models.go", args: "--struct Account --tag json --max-file-lines 15 --out-file " + testOutFile}
+		part2   = "/out/models_generated_2.go"
+	)
+
+	tests := []struct {
+		name      string
+		runs      [][]testCommand
+		wantParts []string
+		notWant   []string
+	}{
+		{
+			name:      "commands above the threshold are split",
+			runs:      [][]testCommand{{account, user}},
+			wantParts: []string{testOutFile, part2},
+		},
+		{
+			name:      "surplus parts are removed",
+			runs:      [][]testCommand{{account, user}, {user}},
+			wantParts: []string{testOutFile},
+			notWant:   []string{part2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := new(MemFS)
+			for _, run := range tt.runs {
+				if err := generateTest(t, fsys, testModels, run...); err != nil {
+					t.Fatalf("failed to generate: %v", err)
+				}
+			}
+
+			for _, name := range tt.wantParts {
+				if content := readTestFile(t, fsys, name); !strings.Contains(content, "JSONFieldID") {
+					t.Errorf("missing generated code in %s: %s", name, content)
+				}
+			}
+			for _, name := range tt.notWant {
+				if exists, err := fileExists(fsys, name); err != nil || exists {
+					t.Errorf("got %s existing %v with error %v, want it removed", name, exists, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMaxFileLinesSurplusParts(t *testing.T) {
+	fsys := new(MemFS)
+	if err := generateTest(t, fsys, testModels, testCommand{args: "--struct User --tag json --max-file-lines 1000 --out-file " + testOutFile}); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	if err := fsys.WriteFile("/out/models_generated_2.go", []byte("package models\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var flagOptions FlagOptions
+	if err := flagOptions.ParseString("--struct User --tag json --max-file-lines 1000 --out-file " + testOutFile +
+		" --include-struct-name --export --src-dir " + testSrcDir + " --out-dir /out --out-pkg models"); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGenerator(fsys, log.New(io.Discard, "", 0), testLoader{src: testModels})
+	stale, _, err := g.Check(context.Background(), []FlagOptions{flagOptions})
+	if err != nil {
+		t.Fatalf("failed to check: %v", err)
+	}
+
+	if len(stale) != 1 || stale[0].Path != "/out/models_generated_2.go" || stale[0].Reason != "no longer generated" {
+		t.Errorf("got stale files %+v, want the surplus part", stale)
+	}
+}
@@ -0,0 +1,70 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sqlColumnsNames returns the names of the --sql-columns constant and var of the source struct, e.g. UserColumns and
+// UserColumnNames, exported along with the constants.
+func sqlColumnsNames(f FlagOptions) (constName, varName string) {
+	return casedIdentifier(f.SourceStruct+"Columns", f.Export), casedIdentifier(f.SourceStruct+"ColumnNames", f.Export)
+}
+
+// writeSQLColumns writes a constant listing the values of the fields as comma separated SQL columns, e.g.
+// UserColumns = "id, email, created_at", ready to be spliced into SELECT statements, along with a var holding them as
+// a []string. The columns are in the declaration order of their fields, with the fields of embedded structs in place of
+// the embedded struct, and fields not reachable through an index, such as the fields of oneof case wrappers, last.
+func writeSQLColumns(buf *bytes.Buffer, f FlagOptions, fields []parsedField) {
+	var (
+		constName, varName = sqlColumnsNames(f)
+		columns            = columnValues(fields)
+	)
+
+	buf.WriteString(fmt.Sprintf("\n// %s lists the columns of the [%s] struct fields, in declaration order, e.g. for SELECT statements.\n",
+		constName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("const %s = %q\n", constName, strings.Join(columns, ", ")))
+	buf.WriteString(fmt.Sprintf("\n// %s holds the columns of [%s] in declaration order.\n", varName, constName))
+	buf.WriteString(fmt.Sprintf("var %s = []string{", varName))
+	for _, column := range columns {
+		buf.WriteString(fmt.Sprintf("\n%q,", column))
+	}
+	buf.WriteString("\n}\n")
+}
+
+// columnValues returns the unique values of fields in the declaration order of their fields, see [writeSQLColumns].
+func columnValues(fields []parsedField) []string {
+	var (
+		ordered    = append([]parsedField(nil), fields...)
+		columns    []string
+		seenValues = make(map[string]struct{})
+	)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return indexLess(ordered[i].index, ordered[j].index)
+	})
+	for _, field := range ordered {
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+		columns = append(columns, field.constValue)
+	}
+	return columns
+}
+
+// indexLess reports whether the field at the reflect index path a is declared before that at b. Nil paths, of fields
+// not reachable through an index, sort last.
+func indexLess(a, b []int) bool {
+	if a == nil || b == nil {
+		return a != nil
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
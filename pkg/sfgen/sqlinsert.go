@@ -0,0 +1,63 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// sqlPlaceholderDialects maps the dialects accepted by the --sql-insert placeholders function to the format of their
+// bind parameters, given the 1-based position of the parameter. Other dialects, e.g. mysql and sqlite, use ?.
+var sqlPlaceholderDialects = []struct {
+	names  []string
+	format string
+}{
+	{names: []string{"postgres", "pgx"}, format: "$%d"},
+	{names: []string{"sqlserver"}, format: "@p%d"},
+}
+
+// sqlInsertNames returns the names of the --sql-insert functions of the source struct, e.g. UserInsertColumns and
+// UserInsertPlaceholders, exported along with the constants.
+func sqlInsertNames(f FlagOptions) (columnsFunc, placeholdersFunc string) {
+	return casedIdentifier(f.SourceStruct+"InsertColumns", f.Export), casedIdentifier(f.SourceStruct+"InsertPlaceholders", f.Export)
+}
+
+// writeSQLInsert writes a function returning the parenthesized columns of the fields, e.g. (id, email), and another
+// returning as many parenthesized bind parameters in the syntax of a dialect, e.g. ($1, $2) or (?, ?), so the columns and
+// placeholders of an INSERT statement always match. The columns are ordered as those of --sql-columns.
+func writeSQLInsert(buf *bytes.Buffer, f FlagOptions, fields []parsedField) {
+	var (
+		columnsFunc, placeholdersFunc = sqlInsertNames(f)
+		columns                       = columnValues(fields)
+	)
+
+	// An empty format holds the unnumbered ? parameters
+	placeholders := func(format string) string {
+		params := make([]string, len(columns))
+		for i := range columns {
+			params[i] = "?"
+			if format != "" {
+				params[i] = fmt.Sprintf(format, i+1)
+			}
+		}
+		return "(" + strings.Join(params, ", ") + ")"
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns the parenthesized columns of the [%s] struct fields, in declaration order, e.g. for INSERT\n",
+		columnsFunc, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("// statements. They match the placeholders returned by [%s].\n", placeholdersFunc))
+	buf.WriteString(fmt.Sprintf("func %s() string {\nreturn %q\n}\n", columnsFunc, "("+strings.Join(columns, ", ")+")"))
+
+	buf.WriteString(fmt.Sprintf("\n// %s returns the parenthesized placeholders of the columns returned by [%s], in the bind\n",
+		placeholdersFunc, columnsFunc))
+	buf.WriteString("// parameter syntax of the dialect: $1 for postgres and pgx, @p1 for sqlserver, and ? otherwise, e.g. for mysql and sqlite.\n")
+	buf.WriteString(fmt.Sprintf("func %s(dialect string) string {\nswitch dialect {\n", placeholdersFunc))
+	for _, d := range sqlPlaceholderDialects {
+		quoted := make([]string, len(d.names))
+		for i, name := range d.names {
+			quoted[i] = fmt.Sprintf("%q", name)
+		}
+		buf.WriteString(fmt.Sprintf("case %s:\nreturn %q\n", strings.Join(quoted, ", "), placeholders(d.format)))
+	}
+	buf.WriteString(fmt.Sprintf("default:\nreturn %q\n}\n}\n", placeholders("")))
+}
@@ -0,0 +1,149 @@
+package sfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// stableIDsFile is the content of a --stable-ids file, recording the ID assigned to each field. Entries are never
+// removed, so the IDs of removed fields are not reassigned, and are restored if the fields are added back.
+type stableIDsFile struct {
+	IDs []stableID `json:"ids"`
+}
+
+// stableID is the ID of a field, along with the name and value it was last generated with.
+type stableID struct {
+	ID    int    `json:"id"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// assignStableIDs returns the stable ID of each of fields, read from the --stable-ids file of f, along with the updated
+// content of the file. A field keeps its ID when either its value or its name is changed, or both if the former value
+// is listed by a was option of its sfgen tag. Fields without an ID are assigned the next one.
+func (g *Generator) assignStableIDs(f FlagOptions, fields []parsedField) ([]int, []byte, error) {
+	var file stableIDsFile
+	content, ok, err := readFile(g.fs, f.StableIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read --stable-ids %s: %w", f.StableIDs, err)
+	}
+	if ok {
+		if err = json.Unmarshal(content, &file); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse --stable-ids %s: %w", f.StableIDs, err)
+		}
+	}
+
+	nextID := 1
+	seenIDs := make(map[int]struct{}, len(file.IDs))
+	for _, entry := range file.IDs {
+		if _, ok := seenIDs[entry.ID]; ok || entry.ID < 1 {
+			return nil, nil, fmt.Errorf("invalid --stable-ids %s: the ID %d must be positive and unique", f.StableIDs, entry.ID)
+		}
+		seenIDs[entry.ID] = struct{}{}
+		if entry.ID >= nextID {
+			nextID = entry.ID + 1
+		}
+	}
+
+	// Fields are matched by value first, then by former value, then by name, so a field taking over the value of
+	// another, renamed, field does not steal its ID
+	var (
+		ids     = make([]int, len(fields))
+		claimed = make([]bool, len(file.IDs))
+		matches = []func(field parsedField, entry stableID) bool{
+			func(field parsedField, entry stableID) bool { return entry.Value == field.constValue },
+			func(field parsedField, entry stableID) bool {
+				for _, value := range field.formerValues {
+					if entry.Value == value {
+						return true
+					}
+				}
+				return false
+			},
+			func(field parsedField, entry stableID) bool { return entry.Field == field.fieldName },
+		}
+	)
+	for _, match := range matches {
+		for i, field := range fields {
+			if ids[i] != 0 {
+				continue
+			}
+
+			for j, entry := range file.IDs {
+				if !claimed[j] && match(field, entry) {
+					ids[i], claimed[j] = entry.ID, true
+					file.IDs[j].Field, file.IDs[j].Value = field.fieldName, field.constValue
+					break
+				}
+			}
+		}
+	}
+
+	for i, field := range fields {
+		if ids[i] == 0 {
+			ids[i] = nextID
+			file.IDs = append(file.IDs, stableID{ID: nextID, Field: field.fieldName, Value: field.constValue})
+			nextID++
+		}
+	}
+
+	sort.Slice(file.IDs, func(i, j int) bool {
+		return file.IDs[i].ID < file.IDs[j].ID
+	})
+	if file.IDs == nil {
+		file.IDs = []stableID{}
+	}
+	if content, err = json.MarshalIndent(file, "", "\t"); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode --stable-ids %s: %w", f.StableIDs, err)
+	}
+
+	return ids, append(content, '\n'), nil
+}
+
+// writeStableIDs writes an int constant holding the stable ID of each field, e.g. UserFieldStableIDEmail = 3, along
+// with maps from the generated constants to their IDs and back, so that values persisted by ID, such as stored filter
+// definitions, survive renaming the fields.
+func writeStableIDs(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField, ids []int) {
+	var (
+		idsName   = baseName + "StableIDs"
+		byIDName  = baseName + "ByStableID"
+		constType = "string"
+	)
+	if f.Style == StyleAlias || f.Style == StyleTyped || f.intBased() {
+		constType = baseName
+	}
+
+	if len(fields) > 0 {
+		buf.WriteString(fmt.Sprintf("\n// Stable IDs of the [%s] struct fields the constants are generated from, persisted in %s\n",
+			f.SourceStruct, filepath.Base(f.StableIDs)))
+		buf.WriteString("const (")
+		for i, field := range fields {
+			buf.WriteString(fmt.Sprintf("\n%sStableID%s = %d", baseName, field.identName, ids[i]))
+		}
+		buf.WriteString("\n)\n")
+	}
+
+	var toIDs, fromIDs bytes.Buffer
+	seenValues := make(map[string]struct{})
+	for _, field := range fields {
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+
+		constant := field.constName
+		if f.Style == StyleGeneric {
+			constant = fmt.Sprintf("string(%s)", field.constName)
+		}
+		toIDs.WriteString(fmt.Sprintf("\n%s: %sStableID%s,", constant, baseName, field.identName))
+		fromIDs.WriteString(fmt.Sprintf("\n%sStableID%s: %s,", baseName, field.identName, constant))
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// %s maps the constants generated from [%s] to the stable IDs of their fields.\n", idsName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("var %s = map[%s]int{%s\n}\n", idsName, constType, toIDs.String()))
+	buf.WriteString(fmt.Sprintf("\n// %s maps the stable IDs of the [%s] struct fields back to the constants generated from them.\n", byIDName, f.SourceStruct))
+	buf.WriteString(fmt.Sprintf("var %s = map[int]%s{%s\n}\n", byIDName, constType, fromIDs.String()))
+}
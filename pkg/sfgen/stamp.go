@@ -0,0 +1,152 @@
+package sfgen
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	modulePath  = "github.com/rad12000/go-sfgen"
+	stampPrefix = "// sfgen:stamp "
+	// develVersion is the version reported for builds that are not from a tagged module version.
+	develVersion = "(devel)"
+)
+
+// Stamp identifies the generator version and flags a file was generated with. It is written to the header of files
+// generated with --stamp.
+type Stamp struct {
+	Version   string
+	FlagsHash string
+}
+
+// String returns the stamp in the form written to generated files.
+func (s Stamp) String() string {
+	return fmt.Sprintf("%sversion=%s flags=%s", stampPrefix, s.Version, s.FlagsHash)
+}
+
+// ParseStamp returns the stamp found in the header of a generated file, reporting false if there is none.
+func ParseStamp(content []byte) (Stamp, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+
+		if !strings.HasPrefix(line, stampPrefix) {
+			continue
+		}
+
+		var s Stamp
+		for _, field := range strings.Fields(strings.TrimPrefix(line, stampPrefix)) {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "version":
+				s.Version = value
+			case "flags":
+				s.FlagsHash = value
+			}
+		}
+		return s, true
+	}
+
+	return Stamp{}, false
+}
+
+// Compatible returns an error if a file stamped with s must be regenerated to match the current stamp. Development
+// builds are compatible with any version.
+func (s Stamp) Compatible(current Stamp) error {
+	if s.Version != current.Version && s.Version != develVersion && current.Version != develVersion {
+		return fmt.Errorf("generated by go-sfgen %s, but the current version is %s", s.Version, current.Version)
+	}
+
+	if s.FlagsHash != current.FlagsHash {
+		return fmt.Errorf("generated with different flags (%s), current flags hash to %s", s.FlagsHash, current.FlagsHash)
+	}
+
+	return nil
+}
+
+// generatorVersion returns the version of the go-sfgen module in the running binary.
+func generatorVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return develVersion
+	}
+
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+
+	return develVersion
+}
+
+// newStamp returns the stamp of a file generated from flagOptions. Paths are made relative to the output directory,
+// and options that only affect how packages are loaded or what runs after writing, such as --chain and --timings, are ignored, so
+// the hash is the same across checkouts and machines.
+func newStamp(flagOptions []FlagOptions) (Stamp, error) {
+	normalized := make([]FlagOptions, len(flagOptions))
+	for i, f := range flagOptions {
+		rel := func(path string) string {
+			if r, err := filepath.Rel(f.OutputDir, path); err == nil {
+				return filepath.ToSlash(r)
+			}
+			return path
+		}
+
+		f.SourceStructDir = rel(f.SourceStructDir)
+		f.OutputFile = rel(f.OutputFile)
+		f.SourceFiles = append([]string(nil), f.SourceFiles...)
+		for j, file := range f.SourceFiles {
+			f.SourceFiles[j] = rel(file)
+		}
+		if f.SymbolIndex != "" {
+			f.SymbolIndex = rel(f.SymbolIndex)
+		}
+		if f.StableIDs != "" {
+			f.StableIDs = rel(f.StableIDs)
+		}
+		if f.Template != "" {
+			f.Template = rel(f.Template)
+		}
+		if f.GeneratedRoot != "" {
+			f.GeneratedRoot = rel(f.GeneratedRoot)
+		}
+		if f.TypeMap != "" {
+			f.TypeMap = rel(f.TypeMap)
+		}
+		if f.Schema.Path != "" && f.Schema.Kind != SchemaDB {
+			f.Schema.Path = rel(f.Schema.Path)
+		}
+		f.Emitters = append([]Emitter(nil), f.Emitters...)
+		for j, e := range f.Emitters {
+			f.Emitters[j].Path = rel(e.Path)
+		}
+		f.OutputDir = "."
+		f.Vendor, f.ModMode, f.ModFile, f.Offline, f.Strict = false, "", "", false, false
+		f.Chain, f.Timings = nil, false
+
+		normalized[i] = f
+	}
+
+	encoded, err := json.Marshal(normalized)
+	if err != nil {
+		return Stamp{}, fmt.Errorf("failed to encode flags for stamp: %w", err)
+	}
+
+	sum := sha256.Sum256(encoded)
+	return Stamp{Version: generatorVersion(), FlagsHash: hex.EncodeToString(sum[:6])}, nil
+}
@@ -0,0 +1,57 @@
+package sfgen
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewStampAcrossCheckouts(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+	}{
+		{
+			name: "source and output paths",
+			args: "--struct User --tag json --src-dir models --out-dir models --symbol-index index.json --stable-ids models/ids.json",
+		},
+		{
+			name: "template",
+			args: "--struct User --tag json --src-dir models --out-dir models --template templates/consts.tmpl",
+		},
+		{
+			name: "emitters and type map",
+			args: "--struct User --tag json --src-dir models --out-dir models --emit ts:web/fields.ts --type-map types.json",
+		},
+		{
+			name: "generated root",
+			args: "--struct User --tag json --src-dir models --out-dir generated/models --generated-root generated",
+		},
+	}
+
+	// stamp returns the stamp of the command run from a config file in root, whose paths are resolved against it
+	stamp := func(t *testing.T, root, args string) Stamp {
+		t.Helper()
+
+		var f FlagOptions
+		if err := f.ParseString(args); err != nil {
+			t.Fatalf("failed to parse %q: %v", args, err)
+		}
+		f.resolvePaths(root)
+		f.OutputFile = filepath.Join(f.OutputDir, "user_jsonfield_generated.go")
+
+		s, err := newStamp([]FlagOptions{f})
+		if err != nil {
+			t.Fatalf("failed to stamp %q: %v", args, err)
+		}
+		return s
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, ci := stamp(t, "/home/dev/repo", tt.args), stamp(t, "/builds/ci/repo", tt.args)
+			if local != ci {
+				t.Errorf("got flags hash %s in one checkout and %s in the other", local.FlagsHash, ci.FlagsHash)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// writeStringer writes the String method of the generated type, returning the imports it requires. Nothing is written
+// with --no-stringer, so that the type can declare its own. With --stringer-format, the value is formatted with it
+// rather than returned as is.
+func writeStringer(buf *bytes.Buffer, f FlagOptions, typeName, receiver string) []string {
+	if f.NoStringer {
+		return nil
+	}
+
+	buf.WriteString("// String implements the [fmt.Stringer] interface\n")
+	if f.StringerFormat == "" {
+		buf.WriteString(fmt.Sprintf("func (%s %s) String() string { return (string)(%s) }\n", receiver, typeName, receiver))
+		return nil
+	}
+
+	buf.WriteString(fmt.Sprintf("func (%s %s) String() string { return fmt.Sprintf(%q, (string)(%s)) }\n",
+		receiver, typeName, f.StringerFormat, receiver))
+	return []string{"fmt"}
+}
@@ -0,0 +1,313 @@
+package sfgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// configTarget holds the flags of a single generate command of a config file.
+type configTarget struct {
+	// line is the line of the config file the command starts at.
+	line int
+	args []string
+}
+
+// configEntry holds the keys of a target of a YAML or TOML config file, named after the flags they set.
+type configEntry struct {
+	line   int
+	keys   []string
+	values map[string][]string
+}
+
+// set sets key to values, which are provided as repeated flags when there are several of them.
+func (e *configEntry) set(line int, key string, values []string) error {
+	if e.values == nil {
+		e.values = make(map[string][]string)
+	}
+
+	if _, ok := e.values[key]; ok {
+		return fmt.Errorf("line %d: duplicate key %q", line, key)
+	}
+
+	e.keys = append(e.keys, key)
+	e.values[key] = values
+	return nil
+}
+
+// target returns the flags of the entry, in the order its keys were provided.
+func (e *configEntry) target() configTarget {
+	t := configTarget{line: e.line}
+	for _, key := range e.keys {
+		for _, value := range e.values[key] {
+			t.args = append(t.args, "--"+key+"="+value)
+		}
+	}
+	return t
+}
+
+// parseYAMLConfig parses the targets of a YAML config file. Only the subset of YAML needed to list targets is
+// supported: a top level targets sequence of mappings, whose values are scalars, or flow or block sequences of
+// scalars, e.g.
+//
+//	targets:
+//	  - struct: User
+//	    tag: json
+//	    export: true
+//	    emit: [ts:web/user.ts, md:docs/user.md]
+func parseYAMLConfig(content string) ([]configTarget, error) {
+	var (
+		entries   []*configEntry
+		current   *configEntry
+		inTargets bool
+		// listKey is the key whose block sequence is being read, if any.
+		listKey  string
+		listLine int
+		list     []string
+	)
+	itemIndent, keyIndent := -1, -1
+
+	flushList := func() error {
+		if listKey == "" {
+			return nil
+		}
+		key := listKey
+		listKey = ""
+		return current.set(listLine, key, list)
+	}
+
+	for i, raw := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		line := strings.TrimRight(stripConfigComment(raw), " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		text := strings.TrimLeft(line, " ")
+		indent := len(line) - len(text)
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs may not be used for indentation", lineNum)
+		}
+
+		if indent == 0 {
+			if text != "targets:" || inTargets {
+				return nil, fmt.Errorf("line %d: expected a single top level targets key, got %q", lineNum, text)
+			}
+			inTargets = true
+			continue
+		}
+
+		if !inTargets {
+			return nil, fmt.Errorf("line %d: expected a top level targets key", lineNum)
+		}
+
+		if listKey != "" && indent >= keyIndent && (text == "-" || strings.HasPrefix(text, "- ")) {
+			value, err := parseConfigScalar(strings.TrimSpace(strings.TrimPrefix(text, "-")))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			list = append(list, value)
+			continue
+		}
+
+		if err := flushList(); err != nil {
+			return nil, err
+		}
+
+		if (itemIndent == -1 || indent == itemIndent) && (text == "-" || strings.HasPrefix(text, "- ")) {
+			itemIndent = indent
+			current = &configEntry{line: lineNum}
+			entries = append(entries, current)
+
+			rest := strings.TrimPrefix(text, "-")
+			text = strings.TrimLeft(rest, " ")
+			keyIndent = indent + 1 + len(rest) - len(text)
+			if text == "" {
+				continue
+			}
+		} else if current == nil || indent != keyIndent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", lineNum)
+		}
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a key: value pair, got %q", lineNum, text)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if value == "" {
+			listKey, listLine, list = key, lineNum, nil
+			continue
+		}
+
+		values, err := parseConfigValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if err = current.set(lineNum, key, values); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := flushList(); err != nil {
+		return nil, err
+	}
+
+	targets := make([]configTarget, len(entries))
+	for i, e := range entries {
+		targets[i] = e.target()
+	}
+	return targets, nil
+}
+
+// parseTOMLConfig parses the targets of a TOML config file. Only the subset of TOML needed to list targets is
+// supported: an array of tables named targets, whose values are strings, booleans, numbers, or single line arrays
+// of them, e.g.
+//
+//	[[targets]]
+//	struct = "User"
+//	tag = "json"
+//	export = true
+//	emit = ["ts:web/user.ts", "md:docs/user.md"]
+func parseTOMLConfig(content string) ([]configTarget, error) {
+	var (
+		entries []*configEntry
+		current *configEntry
+	)
+
+	for i, raw := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		line := strings.TrimSpace(stripConfigComment(raw))
+		if line == "" {
+			continue
+		}
+
+		if line == "[[targets]]" {
+			current = &configEntry{line: lineNum}
+			entries = append(entries, current)
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("line %d: expected a [[targets]] table, got %q", lineNum, line)
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a key = value pair, got %q", lineNum, line)
+		}
+
+		key = strings.TrimSpace(key)
+		if unquoted, err := parseConfigScalar(key); err == nil {
+			key = unquoted
+		}
+
+		values, err := parseConfigValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if err = current.set(lineNum, key, values); err != nil {
+			return nil, err
+		}
+	}
+
+	targets := make([]configTarget, len(entries))
+	for i, e := range entries {
+		targets[i] = e.target()
+	}
+	return targets, nil
+}
+
+// parseConfigValue parses a scalar, or a [a, b] array of scalars.
+func parseConfigValue(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") {
+		scalar, err := parseConfigScalar(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{scalar}, nil
+	}
+
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("unterminated array %s", value)
+	}
+
+	var values []string
+	for _, item := range splitOutsideQuotes(value[1:len(value)-1], ',') {
+		if item = strings.TrimSpace(item); item == "" {
+			continue // trailing commas are allowed
+		}
+
+		scalar, err := parseConfigScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, scalar)
+	}
+	return values, nil
+}
+
+// parseConfigScalar returns the value of a double quoted, single quoted, or plain scalar.
+func parseConfigScalar(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid double quoted string %s: %w", value, err)
+		}
+		return unquoted, nil
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case strings.ContainsAny(value, `"'`):
+		return "", fmt.Errorf("invalid string %s", value)
+	}
+	return value, nil
+}
+
+// stripConfigComment returns line without its # comment, if any. A # only starts a comment outside of quotes, and
+// at the start of the line or after whitespace.
+func stripConfigComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitOutsideQuotes splits s around each sep that is not within quotes.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var (
+		parts []string
+		quote byte
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
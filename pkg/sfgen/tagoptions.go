@@ -0,0 +1,48 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// writeTagOptionsFunc writes a function returning the options portion of the --tag (e.g. omitempty) for each
+// generated constant.
+func writeTagOptionsFunc(buf *bytes.Buffer, f FlagOptions, baseName string, fields []parsedField) {
+	funcName := baseName + "Options"
+	buf.WriteString(fmt.Sprintf("\n// %s returns the options of the %s tag of the [%s] field the constant was generated from.\n",
+		funcName, f.Tag, f.SourceStruct))
+	switch f.Style {
+	case StyleAlias, StyleTyped, StyleInt, StyleBitmask:
+		buf.WriteString(fmt.Sprintf("func %s(f %s) []string {\nswitch f {", funcName, baseName))
+	case StyleGeneric:
+		buf.WriteString(fmt.Sprintf("func %s[T any](f %s[T]) []string {\nswitch string(f) {", funcName, baseName))
+	default:
+		buf.WriteString(fmt.Sprintf("func %s(f string) []string {\nswitch f {", funcName))
+	}
+
+	seenValues := make(map[string]struct{})
+	for _, field := range fields {
+		if len(field.tagOptions) == 0 {
+			continue
+		}
+
+		if _, ok := seenValues[field.constValue]; ok {
+			continue
+		}
+		seenValues[field.constValue] = struct{}{}
+
+		if f.Style == StyleGeneric {
+			buf.WriteString(fmt.Sprintf("\ncase %q:", field.constValue))
+		} else {
+			buf.WriteString(fmt.Sprintf("\ncase %s:", field.constName))
+		}
+
+		quoted := make([]string, len(field.tagOptions))
+		for i, opt := range field.tagOptions {
+			quoted[i] = fmt.Sprintf("%q", opt)
+		}
+		buf.WriteString(fmt.Sprintf("\nreturn []string{%s}", strings.Join(quoted, ", ")))
+	}
+	buf.WriteString("\n}\nreturn nil\n}\n")
+}
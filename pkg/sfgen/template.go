@@ -0,0 +1,266 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+)
+
+// TemplateData is the model a --template is executed with, to generate the code of a command in place of the
+// built-in styles.
+type TemplateData struct {
+	// Struct is the name of the --struct.
+	Struct string
+	// Package is the package the code is generated in.
+	Package string
+	// TypeName is the name of the type the built-in styles would declare, e.g. jsonField.
+	TypeName string
+	// Style is the --style, if any.
+	Style string
+	// Tag is the --tag, if any.
+	Tag string
+	// Fields holds one entry per generated constant, in the order of the struct fields.
+	Fields []TemplateField
+}
+
+// TemplateField describes a constant generated from a struct field, see [TemplateData].
+type TemplateField struct {
+	// Field is the name of the struct field.
+	Field string
+	// Const is the name the built-in styles would give the constant.
+	Const string
+	// Value is the value of the constant, e.g. the name held by the --tag.
+	Value string
+	// TagOptions are the options of the --tag, e.g. omitempty.
+	TagOptions []string
+
+	fieldType string
+	imports   []string
+	used      *[]string
+}
+
+// Type returns the Go type of the field, as referenced from the generated package. The packages it refers to are
+// imported by the generated file.
+func (f TemplateField) Type() string {
+	*f.used = append(*f.used, f.imports...)
+	return f.fieldType
+}
+
+// The partials of the built-in code of a command, which a --template may override with {{define "name"}}, or render
+// with {{builtin "name"}}.
+const (
+	// templateTypeSection is the declaration of the --style type, along with its methods such as String and All.
+	templateTypeSection = "type"
+	// templateConstsSection is the const block declaring the constants.
+	templateConstsSection = "consts"
+	// templateDeclsSection holds the declarations following the constants, such as those of --field-index.
+	templateDeclsSection = "decls"
+)
+
+// builtinTemplate renders the built-in code of a command from its partials, and is executed unless the --template has
+// content outside of its {{define}} actions.
+const builtinTemplate = `{{template "type" .}}{{template "consts" .}}{{template "decls" .}}` +
+	`{{define "type"}}{{builtin "type"}}{{end}}` +
+	`{{define "consts"}}{{builtin "consts"}}{{end}}` +
+	`{{define "decls"}}{{builtin "decls"}}{{end}}`
+
+// templateSection is the built-in code of a partial, along with the imports it requires.
+type templateSection struct {
+	code    string
+	imports []string
+}
+
+// executeTemplate returns the code generated with the --template of f from fields, along with the imports it requires:
+// those of the field types and built-in sections the template refers to, and those it declares with
+// {{import "path"}}. Templates consisting only of {{define}} actions override the partials of the built-in code.
+func executeTemplate(f FlagOptions, baseName string, fields []parsedField, sections map[string]templateSection) ([]byte, []string, error) {
+	content, err := os.ReadFile(f.Template)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read --template %s: %w", f.Template, err)
+	}
+
+	var imports []string
+	funcs := templateFuncs(&imports)
+	funcs["builtin"] = func(name string) (string, error) {
+		section, ok := sections[name]
+		if !ok {
+			return "", fmt.Errorf("unknown built-in section %q, valid sections are: %s, %s, %s", name,
+				templateTypeSection, templateConstsSection, templateDeclsSection)
+		}
+
+		imports = append(imports, section.imports...)
+		return section.code, nil
+	}
+
+	tmpl, err := template.New("sfgen").Funcs(funcs).Parse(builtinTemplate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse built-in template: %w", err)
+	}
+
+	name := filepath.Base(f.Template)
+	if _, err = tmpl.New(name).Parse(string(content)); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse --template %s: %w", f.Template, err)
+	}
+
+	// A template with content of its own replaces the whole code of the command
+	if user := tmpl.Lookup(name); user != nil && user.Tree != nil && !parse.IsEmptyTree(user.Tree.Root) {
+		if err = templateConflicts(f); err != nil {
+			return nil, nil, err
+		}
+		tmpl = user
+	}
+
+	data := TemplateData{
+		Struct:   f.SourceStruct,
+		Package:  f.OutputPackage,
+		TypeName: baseName,
+		Style:    f.Style,
+		Tag:      f.Tag,
+		Fields:   make([]TemplateField, len(fields)),
+	}
+	for i, field := range fields {
+		data.Fields[i] = TemplateField{
+			Field:      field.fieldName,
+			Const:      field.constName,
+			Value:      field.constValue,
+			TagOptions: field.tagOptions,
+			fieldType:  field.fieldType,
+			imports:    field.requiredImports,
+			used:       &imports,
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err = tmpl.Execute(buf, data); err != nil {
+		return nil, nil, fmt.Errorf("failed to execute --template %s for %s: %w", f.Template, f.SourceStruct, err)
+	}
+	return buf.Bytes(), imports, nil
+}
+
+// templateConflicts returns an error if f uses a flag adding code to the built-in output, which a --template replacing
+// the whole code of the command would drop.
+func templateConflicts(f FlagOptions) error {
+	conflicts := []struct {
+		name string
+		set  bool
+	}{
+		{"iter", f.Iter}, {"namespace", f.Namespace != ""}, {"interface", f.Interface != ""}, {"field-index", f.FieldIndex},
+		{"tag-options", f.TagOptions}, {"scan-dest", f.ScanDest}, {"mirror-export", f.MirrorExport}, {"gostring", f.GoString},
+		{"unsafe-offsets", f.UnsafeOffsets != ""}, {"guard-test", f.GuardTest}, {"source-map", f.SourceMap},
+		{"json-pointer", f.JSONPointer}, {"parse", f.ParseFunc}, {"bind", f.Bind},
+		{"gen-set", f.GenSet}, {"is-valid", f.IsValid}, {"provenance", f.Provenance},
+		{"text-marshaler", f.TextMarshaler}, {"fold-lookup", f.FoldLookup}, {"json-marshaler", f.JSONMarshaler},
+		{"link-tag", len(f.LinkTags) > 0}, {"map-tags", len(f.MapTags) > 0}, {"field-meta", f.FieldMeta}, {"index-consts", f.IndexConsts},
+		{"accessors", f.Accessors}, {"builder", f.Builder}, {"functional-options", f.FunctionalOptions},
+		{"stable-ids", f.StableIDs != ""}, {"sql-columns", f.SQLColumns}, {"sql-insert", f.SQLInsert},
+	}
+	for _, c := range conflicts {
+		if c.set {
+			return fmt.Errorf("cannot use --%s with a --template generating the whole code of the command, "+
+				"override the partials of the built-in code with {{define}} instead", c.name)
+		}
+	}
+	return nil
+}
+
+// templateFuncs returns the functions available to a --template, recording the imports they declare in imports.
+func templateFuncs(imports *[]string) template.FuncMap {
+	return template.FuncMap{
+		"import": func(path string) string {
+			*imports = append(*imports, path)
+			return ""
+		},
+		"qualify": func(path, name string) string {
+			*imports = append(*imports, path)
+			return importName(path) + "." + name
+		},
+		"pascal": func(s string) string {
+			var sb strings.Builder
+			for _, word := range splitWords(s) {
+				sb.WriteString(casedIdentifier(word, true))
+			}
+			return sb.String()
+		},
+		"camel": func(s string) string {
+			var sb strings.Builder
+			for i, word := range splitWords(s) {
+				if i == 0 {
+					word = strings.ToLower(word)
+				}
+				sb.WriteString(casedIdentifier(word, i > 0))
+			}
+			return sb.String()
+		},
+		"snake": func(s string) string {
+			return strings.ToLower(strings.Join(splitWords(s), "_"))
+		},
+		"kebab": func(s string) string {
+			return strings.ToLower(strings.Join(splitWords(s), "-"))
+		},
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"pluralize": pluralize,
+	}
+}
+
+// splitWords splits s into its words, separated by characters other than letters and digits, or by changes of case,
+// e.g. user, ID, and Token for userIDToken.
+func splitWords(s string) []string {
+	var words []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			// A word starts at an upper case letter following a lower case one, e.g. userID, or at the last upper case
+			// letter of an acronym followed by a lower case one, e.g. IDToken
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// pluralize returns the English plural of the singular noun s, following the regular rules only, e.g. Entries for Entry
+// or IDs for ID.
+func pluralize(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
+
+// majorVersionPattern matches the major version suffix of an import path, e.g. v5.
+var majorVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName returns the name a package is assumed to be imported as: the last element of its path, ignoring a major
+// version suffix, any extension, and a go- prefix, e.g. yaml for gopkg.in/yaml.v3 or sqlite3 for
+// github.com/mattn/go-sqlite3.
+func importName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && majorVersionPattern.MatchString(name) {
+		name = elems[len(elems)-2]
+	}
+
+	name, _, _ = strings.Cut(name, ".")
+	return strings.TrimPrefix(name, "go-")
+}
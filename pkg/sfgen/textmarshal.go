@@ -0,0 +1,81 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// writeTextMarshaler writes the MarshalText and UnmarshalText methods implementing [encoding.TextMarshaler] and
+// [encoding.TextUnmarshaler], so the generated type may be decoded from JSON, YAML, or flags. Values are marshaled as
+// the value their constant was generated from, and UnmarshalText rejects values no constant holds. With the bitmask
+// style, the values of the set bits are joined by |, as by String. It returns the imports the methods require.
+func writeTextMarshaler(buf *bytes.Buffer, f FlagOptions, baseName, typeName, receiver string, fields []parsedField) []string {
+	imports := []string{"fmt"}
+	buf.WriteString("// MarshalText implements the [encoding.TextMarshaler] interface\n")
+	buf.WriteString(fmt.Sprintf("func (%s %s) MarshalText() ([]byte, error) {\n", receiver, typeName))
+	switch f.Style {
+	case StyleBitmask:
+		consts := make([]string, len(fields))
+		buf.WriteString("var values []string\n")
+		for i, field := range fields {
+			buf.WriteString(fmt.Sprintf("if %s&%s != 0 {\nvalues = append(values, %q)\n}\n", receiver, field.constName, field.constValue))
+			consts[i] = field.constName
+		}
+		rest := receiver
+		if len(consts) > 0 {
+			rest = fmt.Sprintf("%s &^ (%s)", receiver, strings.Join(consts, " | "))
+		}
+		buf.WriteString(fmt.Sprintf("if %s != 0 {\nreturn nil, fmt.Errorf(\"invalid %s %%d\", uint64(%s))\n}\n", rest, baseName, receiver))
+		buf.WriteString("return []byte(strings.Join(values, \"|\")), nil\n}\n")
+		imports = append(imports, "strings")
+	case StyleInt:
+		if len(fields) > 0 {
+			buf.WriteString(fmt.Sprintf("switch %s {\n", receiver))
+			for _, field := range fields {
+				buf.WriteString(fmt.Sprintf("case %s:\nreturn []byte(%q), nil\n", field.constName, field.constValue))
+			}
+			buf.WriteString("}\n")
+		}
+		buf.WriteString(fmt.Sprintf("return nil, fmt.Errorf(\"invalid %s %%d\", int(%s))\n}\n", baseName, receiver))
+	default:
+		buf.WriteString(fmt.Sprintf("return []byte(%s), nil\n}\n", receiver))
+	}
+
+	buf.WriteString("\n// UnmarshalText implements the [encoding.TextUnmarshaler] interface, rejecting values no constant holds\n")
+	buf.WriteString(fmt.Sprintf("func (%s *%s) UnmarshalText(text []byte) error {\n", receiver, typeName))
+	if f.Style == StyleBitmask {
+		buf.WriteString(fmt.Sprintf("var v %s\n", typeName))
+		buf.WriteString("if len(text) > 0 {\nfor _, s := range strings.Split(string(text), \"|\") {\nswitch s {\n")
+		for _, field := range fields {
+			buf.WriteString(fmt.Sprintf("case %q:\nv |= %s\n", field.constValue, field.constName))
+		}
+		buf.WriteString(fmt.Sprintf("default:\nreturn fmt.Errorf(\"invalid %s %%q\", s)\n}\n}\n}\n", baseName))
+		buf.WriteString(fmt.Sprintf("*%s = v\nreturn nil\n}\n", receiver))
+		return imports
+	}
+
+	if len(fields) > 0 {
+		buf.WriteString("switch string(text) {\n")
+		seen := make(map[string]struct{}, len(fields))
+		var values []string
+		for _, field := range fields {
+			if _, ok := seen[field.constValue]; ok {
+				continue
+			}
+			seen[field.constValue] = struct{}{}
+
+			if f.Style == StyleInt {
+				buf.WriteString(fmt.Sprintf("case %q:\n*%s = %s\nreturn nil\n", field.constValue, receiver, field.constName))
+				continue
+			}
+			values = append(values, fmt.Sprintf("%q", field.constValue))
+		}
+		if f.Style != StyleInt {
+			buf.WriteString(fmt.Sprintf("case %s:\n*%s = %s(text)\nreturn nil\n", strings.Join(values, ", "), receiver, typeName))
+		}
+		buf.WriteString("}\n")
+	}
+	buf.WriteString(fmt.Sprintf("return fmt.Errorf(\"invalid %s %%q\", text)\n}\n", baseName))
+	return imports
+}
@@ -0,0 +1,42 @@
+package sfgen
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// logTimings logs the phase durations of the commands of result run with --timings, one line per command, e.g.
+//
+//	timings: User jsonField (user_jsonfield_generated.go): load 212ms, parse 1.1ms, render 350µs, format 2.4ms, write 80µs, total 216ms
+//
+// The durations are only logged, nothing is recorded or sent elsewhere.
+func (g *Generator) logTimings(result *Result) {
+	for _, file := range result.Files {
+		for _, target := range file.Targets {
+			if !target.Options.Timings {
+				continue
+			}
+
+			t := target.Timings
+			g.logger.Printf("timings: %s %s (%s): load %s, parse %s, render %s, format %s, write %s, total %s",
+				target.Options.SourceStruct, calculateBaseName(target.Options), filepath.Base(file.Path),
+				roundDuration(t.Load), roundDuration(t.Parse), roundDuration(t.Render), roundDuration(t.Format),
+				roundDuration(t.Write), roundDuration(t.Total()))
+		}
+	}
+}
+
+// Total returns the sum of the durations of the phases.
+func (t Timings) Total() time.Duration {
+	return t.Load + t.Parse + t.Render + t.Format + t.Write
+}
+
+// roundDuration rounds d to 3 significant digits, which is as precise as the timings of a single run are meaningful.
+func roundDuration(d time.Duration) time.Duration {
+	for unit := time.Duration(1); unit < time.Hour; unit *= 10 {
+		if d < 1000*unit {
+			return d.Round(unit)
+		}
+	}
+	return d
+}
@@ -0,0 +1,96 @@
+package sfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"os"
+	"strings"
+)
+
+// typeMap maps Go types to the types they are represented with by the --emit languages, as read from a --type-map
+// file. It is keyed by the Go type, qualified by the import path of its package, e.g.
+// github.com/shopspring/decimal.Decimal, and then by the emit language, e.g. ts:
+//
+//	{"github.com/shopspring/decimal.Decimal": {"ts": "string", "md": "decimal"}}
+type typeMap map[string]map[string]string
+
+// readTypeMap reads the --type-map file at path. An empty path returns an empty map, so the defaults apply.
+func readTypeMap(path string) (typeMap, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --type-map %s: %w", path, err)
+	}
+
+	var m typeMap
+	if err = json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse --type-map %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// lookup returns the type t is mapped to for lang, if any.
+func (m typeMap) lookup(lang string, t types.Type) (string, bool) {
+	mapped, ok := m[types.TypeString(t, nil)][lang]
+	return mapped, ok
+}
+
+// typeScriptType returns the TypeScript type values of t are represented with, consulting m for t and the types it
+// is composed of before the defaults: numbers for numeric kinds and time.Duration, strings for time.Time and []byte,
+// as encoding/json marshals them, and unknown for structs and interfaces.
+func typeScriptType(m typeMap, t types.Type) string {
+	if mapped, ok := m.lookup(EmitTypeScript, t); ok {
+		return mapped
+	}
+
+	switch types.TypeString(t, nil) {
+	case "time.Time", "[]byte":
+		return "string"
+	case "time.Duration":
+		return "number"
+	}
+
+	switch v := t.(type) {
+	case *types.Named:
+		return typeScriptType(m, v.Underlying())
+	case *types.Basic:
+		switch info := v.Info(); {
+		case info&types.IsBoolean != 0:
+			return "boolean"
+		case info&types.IsString != 0:
+			return "string"
+		case info&types.IsNumeric != 0:
+			return "number"
+		}
+	case *types.Pointer:
+		return typeScriptType(m, v.Elem()) + " | null"
+	case *types.Slice:
+		return typeScriptElem(typeScriptType(m, v.Elem())) + "[]"
+	case *types.Array:
+		return typeScriptElem(typeScriptType(m, v.Elem())) + "[]"
+	case *types.Map:
+		return fmt.Sprintf("Record<string, %s>", typeScriptType(m, v.Elem()))
+	}
+	return "unknown"
+}
+
+// typeScriptElem returns the TypeScript type elem, parenthesized if it is a union, so it can be made an array.
+func typeScriptElem(elem string) string {
+	if strings.Contains(elem, "|") {
+		return "(" + elem + ")"
+	}
+	return elem
+}
+
+// markdownType returns the type t is documented as by the md emitter: the type m maps it to, or the Go type qualified
+// by package name by default, e.g. decimal.Decimal.
+func markdownType(m typeMap, t types.Type) string {
+	if mapped, ok := m.lookup(EmitMarkdown, t); ok {
+		return mapped
+	}
+	return types.TypeString(t, func(pkg *types.Package) string { return pkg.Name() })
+}
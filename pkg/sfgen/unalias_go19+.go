@@ -0,0 +1,10 @@
+//go:build !go1.22
+
+package sfgen
+
+import "go/types"
+
+// unalias returns t as is, since aliases are always resolved to their target type before go1.22.
+func unalias(t types.Type) types.Type {
+	return t
+}
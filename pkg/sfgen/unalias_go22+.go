@@ -0,0 +1,10 @@
+//go:build go1.22
+
+package sfgen
+
+import "go/types"
+
+// unalias follows a chain of type aliases to the type they ultimately refer to.
+func unalias(t types.Type) types.Type {
+	return types.Unalias(t)
+}
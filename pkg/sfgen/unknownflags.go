@@ -0,0 +1,92 @@
+package sfgen
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// CheckFlags returns an error suggesting the closest known flag for the first flag of args that is not defined on
+// flagSet, rather than the terse error, and full usage, of [flag.FlagSet.Parse]. Checking stops at the first non-flag
+// argument, as flag parsing does, so the arguments of subcommands are left to them.
+func CheckFlags(flagSet *flag.FlagSet, args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return nil
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		definedFlag := flagSet.Lookup(name)
+		if definedFlag == nil {
+			if name == "h" || name == "help" {
+				return nil
+			}
+			return unknownFlagError(flagSet, name)
+		}
+
+		if boolFlag, ok := definedFlag.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && boolFlag.IsBoolFlag()) {
+			i++ // the value of the flag
+		}
+	}
+
+	return nil
+}
+
+// unknownFlagError returns the error for the undefined flag name, suggesting the closest flag defined on flagSet if
+// there is one.
+func unknownFlagError(flagSet *flag.FlagSet, name string) error {
+	var (
+		suggestion string
+		// names further than this from every flag are more likely to be a different flag altogether than a typo.
+		bestDistance = len(name)/3 + 1
+	)
+
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if d := editDistance(name, f.Name); d <= bestDistance && (suggestion == "" || d < bestDistance) {
+			suggestion, bestDistance = f.Name, d
+		}
+	})
+
+	if suggestion == "" {
+		return fmt.Errorf("unknown flag --%s", name)
+	}
+	return fmt.Errorf("unknown flag --%s, did you mean --%s?", name, suggestion)
+}
+
+// editDistance returns the optimal string alignment distance between a and b, i.e. the Levenshtein distance counting
+// the transposition of adjacent characters, a common typo such as --strcut, as a single edit.
+func editDistance(a, b string) int {
+	var (
+		prevPrev []int
+		prev     = make([]int, len(b)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = prev[j-1] + cost
+			if prev[j]+1 < current[j] {
+				current[j] = prev[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prevPrev[j-2]+1 < current[j] {
+				current[j] = prevPrev[j-2] + 1
+			}
+		}
+		prevPrev, prev = prev, current
+	}
+
+	return prev[len(b)]
+}
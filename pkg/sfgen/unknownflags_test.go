@@ -0,0 +1,77 @@
+package sfgen
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestCheckFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name: "known flags",
+			args: []string{"--struct", "User", "--tag=json", "--export", "-prefix", "Field"},
+		},
+		{
+			name:    "misspelled flag",
+			args:    []string{"--struct", "User", "--tga", "json"},
+			wantErr: "unknown flag --tga, did you mean --tag?",
+		},
+		{
+			name:    "transposed characters",
+			args:    []string{"--strcut", "User"},
+			wantErr: "unknown flag --strcut, did you mean --struct?",
+		},
+		{
+			name:    "misspelled flag with a value",
+			args:    []string{"--out-fiel=user.go"},
+			wantErr: "unknown flag --out-fiel, did you mean --out-file?",
+		},
+		{
+			name:    "unrelated flag",
+			args:    []string{"--struct", "User", "--kubernetes"},
+			wantErr: "unknown flag --kubernetes",
+		},
+		{
+			name: "values are not flags",
+			args: []string{"--prefix", "-tga", "--struct", "User"},
+		},
+		{
+			name: "checking stops at the first non-flag argument",
+			args: []string{"--struct", "User", "rename", "--tga"},
+		},
+		{
+			name: "help",
+			args: []string{"-h"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				f       FlagOptions
+				flagSet = flag.NewFlagSet("sfgen", flag.ContinueOnError)
+			)
+			f.RegisterFlags(flagSet)
+
+			err := CheckFlags(flagSet, tt.args)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseStringUnknownFlag(t *testing.T) {
+	var f FlagOptions
+	err := f.ParseString("--struct User --tga json")
+	if want := "failed to parse flags: unknown flag --tga, did you mean --tag?"; err == nil || err.Error() != want {
+		t.Fatalf("got error %v, want %q", err, want)
+	}
+}
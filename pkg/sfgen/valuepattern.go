@@ -0,0 +1,33 @@
+package sfgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// checkValuePattern returns an error listing the fields whose generated values do not match the --value-pattern.
+func checkValuePattern(f FlagOptions, fields []parsedField) error {
+	if f.ValuePattern == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(f.ValuePattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile --value-pattern %q: %w", f.ValuePattern, err)
+	}
+
+	var violations []string
+	for _, field := range fields {
+		if !re.MatchString(field.constValue) {
+			violations = append(violations, fmt.Sprintf("%s (%q) at %s", field.fieldName, field.constValue, field.position))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("values of %s fields do not match --value-pattern %q: %s",
+			f.SourceStruct, f.ValuePattern, strings.Join(violations, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package sfgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fatih/structtag"
+	"strings"
+	"unicode"
+)
+
+// formerValueOption is the sfgen tag option listing a former value of a field, e.g. `sfgen:"new_name,was:old_name"`.
+const formerValueOption = "was"
+
+// sfgenFormerValues returns the former values listed by the was options of the sfgen tag.
+func sfgenFormerValues(tags *structtag.Tags) []string {
+	sfgenTag, err := tags.Get("sfgen")
+	if err != nil {
+		return nil
+	}
+
+	_, options, ok := strings.Cut(sfgenTag.Value(), ",")
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	for _, option := range strings.Fields(options) {
+		if name, value, ok := strings.Cut(option, ":"); ok && name == formerValueOption && value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// writeFormerValueConstants writes deprecated constants for the former values of fields, so code using an old value
+// keeps compiling during a staged migration.
+func writeFormerValueConstants(buf *bytes.Buffer, f FlagOptions, fields []parsedField) {
+	var specs []string
+	for _, field := range fields {
+		for i, value := range field.formerValues {
+			suffix := f.identifier(identifierSuffix(value))
+			if suffix == "" {
+				suffix = fmt.Sprint(i + 1)
+			}
+
+			name := field.constName + "Was" + suffix
+			specs = append(specs, fmt.Sprintf("// %s is a former value of [%s].\n//\n// Deprecated: use [%s] instead.\n%s",
+				name, field.constName, field.constName, constSpec(f, field, name, value)))
+		}
+	}
+
+	if len(specs) == 0 {
+		return
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// Former values of [%s] struct fields\n", f.SourceStruct))
+	buf.WriteString("const (\n")
+	buf.WriteString(strings.Join(specs, "\n"))
+	buf.WriteString("\n)\n")
+}
+
+// identifierSuffix converts value to an identifier suffix, capitalizing each of its letter and digit runs, e.g. OldName
+// for old_name.
+func identifierSuffix(value string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,224 @@
+package sfgen
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is the interval at which [Generator.Watch] polls the source files for changes.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// sourceFileStamp identifies the version of a source file.
+type sourceFileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch generates the code for each of the provided options, then keeps polling the Go files of their source
+// packages every interval until ctx is done. When files change, the output files generated from them are regenerated
+// along with every other option writing to the same files. Generation errors are logged rather than returned, so the
+// struct can be fixed while watching. Sources are polled rather than watched through OS notifications, which keeps
+// go-sfgen free of platform specific dependencies.
+func (g *Generator) Watch(ctx context.Context, flagOptions []FlagOptions, interval time.Duration) error {
+	sources := make([]string, len(flagOptions))
+	for i := range flagOptions {
+		src, err := flagOptions[i].packageSource()
+		if err != nil {
+			return err
+		}
+		sources[i] = src.Dir
+	}
+
+	var (
+		// groups holds the sources of the options writing to each output file, as of their last generation.
+		groups  = make(map[string][]string)
+		outputs = make(map[string]struct{})
+	)
+	regenerate := func(indexes []int) {
+		opts := make([]FlagOptions, len(indexes))
+		for i, index := range indexes {
+			opts[i] = flagOptions[index]
+		}
+
+		result, err := g.Generate(ctx, opts)
+		if err == nil {
+			for _, w := range result.Warnings() {
+				g.logger.Printf("warning: %s", w)
+			}
+			err = g.Write(ctx, result)
+		}
+		if err == nil {
+			g.logTimings(result)
+			err = g.runChains(ctx, result)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				g.logger.Printf("error: %v", err)
+			}
+			return
+		}
+
+		for _, file := range result.Files {
+			outputs[file.Path] = struct{}{}
+			groups[file.Path] = nil
+			for _, target := range file.Targets {
+				groups[file.Path] = append(groups[file.Path], target.Options.SourceStructDir)
+			}
+		}
+		g.logger.Printf("generated %d files", len(result.Files))
+	}
+
+	all := make([]int, len(flagOptions))
+	for i := range all {
+		all[i] = i
+	}
+	regenerate(all)
+	stamps := watchedFiles(sources, outputs)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current := watchedFiles(sources, outputs)
+		changed := make(map[string]struct{})
+		for path, stamp := range current {
+			if prev, ok := stamps[path]; !ok || prev != stamp {
+				changed[path] = struct{}{}
+			}
+		}
+		for path := range stamps {
+			if _, ok := current[path]; !ok {
+				changed[path] = struct{}{}
+			}
+		}
+		stamps = current
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		// Every option writing to an output file generated from a changed source is regenerated with it, as the
+		// options sharing a file are generated together.
+		affected := make(map[string]struct{})
+		for _, src := range sources {
+			for path := range changed {
+				if sourceContains(src, path) {
+					affected[src] = struct{}{}
+				}
+			}
+		}
+		for grew := true; grew; {
+			grew = false
+			for _, group := range groups {
+				if !groupAffected(group, affected) {
+					continue
+				}
+				for _, src := range group {
+					if _, ok := affected[src]; !ok {
+						affected[src], grew = struct{}{}, true
+					}
+				}
+			}
+		}
+
+		var indexes []int
+		for i, src := range sources {
+			if _, ok := affected[src]; ok {
+				indexes = append(indexes, i)
+			}
+		}
+		if len(indexes) > 0 {
+			regenerate(indexes)
+			stamps = watchedFiles(sources, outputs)
+		}
+	}
+}
+
+func groupAffected(group []string, affected map[string]struct{}) bool {
+	for _, src := range group {
+		if _, ok := affected[src]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceContains reports whether the Go file at path belongs to the source src, as returned by
+// [FlagOptions.packageSource].
+func sourceContains(src, path string) bool {
+	if strings.ContainsRune(src, filepath.ListSeparator) || strings.HasSuffix(src, ".go") {
+		for _, file := range filepath.SplitList(src) {
+			if file == path {
+				return true
+			}
+		}
+		return false
+	}
+
+	if isPackagePattern(src) {
+		root := strings.TrimSuffix(strings.TrimSuffix(src, "..."), string(filepath.Separator))
+		return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+	}
+
+	return filepath.Dir(path) == src
+}
+
+// watchedFiles returns the stamps of the non-test Go files of sources, other than outputs.
+func watchedFiles(sources []string, outputs map[string]struct{}) map[string]sourceFileStamp {
+	stamps := make(map[string]sourceFileStamp)
+	add := func(path string) {
+		if _, ok := outputs[path]; ok || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return
+		}
+
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = sourceFileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	for _, src := range sources {
+		switch {
+		case strings.ContainsRune(src, filepath.ListSeparator) || strings.HasSuffix(src, ".go"):
+			for _, file := range filepath.SplitList(src) {
+				add(file)
+			}
+		case isPackagePattern(src):
+			root := strings.TrimSuffix(strings.TrimSuffix(src, "..."), string(filepath.Separator))
+			_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil // directories removed while walking are picked up by the next poll
+				}
+
+				// As with the go command, vendor and testdata directories, and those starting with . or _, are skipped
+				name := d.Name()
+				if d.IsDir() && path != root && (name == "vendor" || name == "testdata" ||
+					strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+
+				if !d.IsDir() {
+					add(path)
+				}
+				return nil
+			})
+		default:
+			entries, _ := os.ReadDir(src)
+			for _, e := range entries {
+				if !e.IsDir() {
+					add(filepath.Join(src, e.Name()))
+				}
+			}
+		}
+	}
+
+	return stamps
+}
@@ -0,0 +1,45 @@
+package sfgen
+
+import (
+	"github.com/fatih/structtag"
+	"strings"
+)
+
+const xmlTag = "xml"
+
+// xmlTagName returns the element or attribute name encoding/xml uses for the field, following the rules described
+// in [encoding/xml.Marshal]. Nested paths such as a>b>c are kept as is, while the namespace portion of
+// "namespace-URL name" is dropped. Fields that do not map to a named element or attribute (XMLName, chardata,
+// innerxml, comment, any) return "-" so they are skipped.
+func xmlTagName(fieldName string, tags *structtag.Tags) string {
+	if fieldName == "XMLName" {
+		return "-"
+	}
+
+	tag, err := tags.Get(xmlTag)
+	if err != nil {
+		return fieldName
+	}
+
+	if tag.Name == "-" && len(tag.Options) == 0 {
+		return "-"
+	}
+
+	for _, opt := range tag.Options {
+		switch opt {
+		case "chardata", "cdata", "innerxml", "comment", "any":
+			return "-"
+		}
+	}
+
+	name := tag.Name
+	if i := strings.LastIndexByte(name, ' '); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if name == "" {
+		return fieldName
+	}
+
+	return name
+}
@@ -0,0 +1,122 @@
+// Package sfgenlint provides an analyzer reporting the structs whose go-sfgen generated files are out of date, e.g.
+// as fields were added after go generate was last run. It may be run by go vet through cmd/sfgenlint, or by any driver
+// of golang.org/x/tools/go/analysis analyzers, such as golangci-lint.
+package sfgenlint
+
+import (
+	"context"
+	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"path/filepath"
+)
+
+// Analyzer reports the go-sfgen directives of a package whose generated files are missing or differ from the code
+// they would be generated with. The diagnostic is reported at the struct the file is generated from, or at the
+// directive for files that are not generated from a single struct, such as a --symbol-index.
+//
+// Only the directives generating from the structs of the analyzed package are checked, as the packages of other
+// directories are not loaded by the analysis. go-sfgen --check covers the others.
+var Analyzer = &analysis.Analyzer{
+	Name: "sfgen",
+	Doc:  "report structs whose go-sfgen generated constants are out of date",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if len(pass.Files) == 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name())
+	loader := passLoader{pass: pass, dir: dir}
+	for _, file := range pass.Files {
+		path := pass.Fset.File(file.Pos()).Name()
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				d, ok := sfgen.ParseDirective(c.Text, path, file.Name.Name, pass.Fset.Position(c.Slash).Line)
+				if ok {
+					checkDirective(pass, loader, c, d)
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// checkDirective reports the stale files of the directive d, found in the comment c.
+func checkDirective(pass *analysis.Pass, loader passLoader, c *ast.Comment, d sfgen.Directive) {
+	flagOptions, err := d.Options()
+	if err != nil {
+		pass.Reportf(c.Pos(), "invalid go-sfgen directive: %v", err)
+		return
+	}
+
+	var local []sfgen.FlagOptions
+	for _, f := range flagOptions {
+		if len(f.SourceFiles) == 0 && filepath.Clean(f.SourceStructDir) == loader.dir {
+			local = append(local, f)
+		}
+	}
+	if len(local) == 0 {
+		return
+	}
+
+	stale, result, err := sfgen.NewGenerator(nil, nil, loader).Check(context.Background(), local)
+	if err != nil {
+		pass.Reportf(c.Pos(), "failed to check go-sfgen directive: %v", err)
+		return
+	}
+
+	for _, s := range stale {
+		name := s.Path
+		if rel, err := filepath.Rel(loader.dir, s.Path); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+
+		reported := false
+		for _, file := range result.Files {
+			if file.Path != s.Path {
+				continue
+			}
+
+			for _, target := range file.Targets {
+				obj := pass.Pkg.Scope().Lookup(target.Options.SourceStruct)
+				if obj == nil {
+					continue
+				}
+
+				pass.Reportf(obj.Pos(), "%s generated from %s is out of date (%s), run go generate", name, obj.Name(), s.Reason)
+				reported = true
+			}
+		}
+
+		if !reported {
+			pass.Reportf(c.Pos(), "%s generated by this directive is out of date (%s), run go generate", name, s.Reason)
+		}
+	}
+}
+
+// passLoader is the [sfgen.Loader] of an analysis pass, which provides the package type checked by the pass rather
+// than loading it again.
+type passLoader struct {
+	pass *analysis.Pass
+	dir  string
+}
+
+// Load implements the [sfgen.Loader] interface.
+func (l passLoader) Load(_ context.Context, _ *token.FileSet, src sfgen.PackageSource) ([]*types.Package, error) {
+	if src.Dir != l.dir {
+		return nil, fmt.Errorf("cannot load %s, only the analyzed package %s is available", src.Dir, l.pass.Pkg.Path())
+	}
+	return []*types.Package{l.pass.Pkg}, nil
+}
+
+// FileSet returns the file set of the pass, so that positions are resolved against it, see [sfgen.NewGenerator].
+func (l passLoader) FileSet() *token.FileSet {
+	return l.pass.Fset
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fieldRename is a --field of the rename command.
+type fieldRename struct {
+	from, to string
+}
+
+// textEdit replaces the identifier old at offset of a file with new.
+type textEdit struct {
+	offset   int
+	old, new string
+}
+
+// structDirective is a go-sfgen directive generating from the struct whose fields are renamed.
+type structDirective struct {
+	sfgen.Directive
+	// consts holds the constants the directive generated from each renamed field before it was renamed, keyed by the
+	// former field name and then by target, see [fieldConsts].
+	consts map[string]map[string][]string
+}
+
+// constReference is a reference to a constant generated from a renamed field, whose new name is only known once the
+// struct is regenerated.
+type constReference struct {
+	offset int
+	name   string
+}
+
+// fileBackup holds the files written by a rename as they were before it started, keyed by path, so that a failing
+// rename leaves the module as it was rather than half renamed.
+type fileBackup map[string]backedUpFile
+
+// backedUpFile is the content and mode of a file before a rename, or whether it did not exist.
+type backedUpFile struct {
+	exists  bool
+	content []byte
+	mode    os.FileMode
+}
+
+// save records the current state of the file at path, unless it was already recorded.
+func (b fileBackup) save(path string) error {
+	if _, ok := b[path]; ok {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		b[path] = backedUpFile{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	b[path] = backedUpFile{exists: true, content: content, mode: info.Mode()}
+	return nil
+}
+
+// restore writes the recorded files back, and removes those that did not exist.
+func (b fileBackup) restore() error {
+	var errs []string
+	for path, file := range b {
+		var err error
+		if file.exists {
+			err = os.WriteFile(path, file.content, file.mode)
+		} else if err = os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// runRename implements the rename command, renaming fields of a struct along with every reference to them and to the
+// constants generated from them across the module, and regenerating the directives generating from the struct. The
+// files written are restored if any step fails, including regenerating a directive.
+func runRename(ctx context.Context, args []string) (err error) {
+	var (
+		flagSet    = flag.NewFlagSet("rename", flag.ContinueOnError)
+		structName = flagSet.String("struct", "", "the struct declaring the fields, which may be qualified by its package name, e.g. models.User. REQUIRED")
+		renames    []fieldRename
+	)
+	flagSet.Func("field", "a field to rename, in the form Old=New. May be repeated. REQUIRED", func(s string) error {
+		from, to, ok := strings.Cut(s, "=")
+		if !ok || !token.IsIdentifier(from) || !token.IsIdentifier(to) {
+			return fmt.Errorf("--field must be of the form Old=New, got %q", s)
+		}
+		renames = append(renames, fieldRename{from: from, to: to})
+		return nil
+	})
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "Usage: go-sfgen rename --struct [package.]Struct --field Old=New [--field Old=New...]\n\nFlags are:")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if *structName == "" || len(renames) == 0 {
+		flagSet.Usage()
+		return errors.New("--struct and --field are required")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	root, _, err := findModule(wd)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:     root,
+		Fset:    fset,
+		Tests:   true,
+	}, "./...")
+	if err != nil {
+		return fmt.Errorf("failed to load module: %w", err)
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return fmt.Errorf("failed to load package %s: %v", pkg.PkgPath, pkg.Errors)
+		}
+	}
+
+	structObj, fields, err := findRenamedFields(fset, pkgs, *structName, renames)
+	if err != nil {
+		return err
+	}
+
+	// The directives generating from the struct provide the constants generated from the renamed fields, and are
+	// regenerated once the fields are renamed.
+	directives, oldConsts, outputs, err := structDirectives(ctx, wd, root, structObj, renames)
+	if err != nil {
+		return err
+	}
+
+	fieldEdits, constRefs := renameReferences(fset, pkgs, fields, oldConsts, outputs)
+
+	// The fields are renamed on disk before regenerating, as the directives load the struct from its files
+	backup := make(fileBackup)
+	defer func() {
+		if err == nil {
+			return
+		}
+		if restoreErr := backup.restore(); restoreErr != nil {
+			err = fmt.Errorf("%w, and failed to restore the files renamed: %v", err, restoreErr)
+		}
+	}()
+
+	if err = applyFieldEdits(backup, fieldEdits, constRefs); err != nil {
+		return err
+	}
+
+	// Every directive is generated before any is written, as the packages they load still reference the former
+	// constants until the references are renamed.
+	var (
+		results   = make([]*sfgen.Result, len(directives))
+		newConsts = make(map[string]string)
+	)
+	for i, d := range directives {
+		if results[i], err = generateDirective(ctx, wd, d.Directive); err != nil {
+			return fmt.Errorf("failed to regenerate %s:%d: %w", d.Path, d.Line, err)
+		}
+
+		for _, r := range renames {
+			renamed := fieldConsts(results[i], structObj, r.to)
+			for target, consts := range d.consts[r.from] {
+				for j, name := range consts {
+					if j < len(renamed[target]) {
+						newConsts[name] = renamed[target][j]
+					}
+				}
+			}
+		}
+	}
+
+	for _, result := range results {
+		for _, w := range result.Warnings() {
+			log.Printf("warning: %s", w)
+		}
+
+		for _, file := range result.Files {
+			if err = backup.save(file.Path); err != nil {
+				return err
+			}
+		}
+		for _, path := range result.Removed {
+			if err = backup.save(path); err != nil {
+				return err
+			}
+		}
+		if err = sfgen.Write(ctx, result); err != nil {
+			return err
+		}
+	}
+
+	if err = applyConstEdits(backup, constRefs, newConsts); err != nil {
+		return err
+	}
+
+	// The edited files are formatted once every edit is applied, as formatting would move the offsets of the
+	// constant references, e.g. when realigning the fields of a struct
+	if err = formatEditedFiles(fieldEdits, constRefs); err != nil {
+		return err
+	}
+
+	for _, r := range renames {
+		count := 0
+		for _, edits := range fieldEdits[r.from] {
+			count += len(edits)
+		}
+		fmt.Printf("renamed %s.%s to %s, %d references updated\n", structObj.Name(), r.from, r.to, count)
+	}
+
+	constCounts := make(map[string]int)
+	for _, refs := range constRefs {
+		for _, ref := range refs {
+			constCounts[ref.name]++
+		}
+	}
+	names := make([]string, 0, len(newConsts))
+	for name := range newConsts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("renamed %s to %s, %d references updated\n", name, newConsts[name], constCounts[name])
+	}
+
+	return nil
+}
+
+// findRenamedFields returns the struct named structName in pkgs, along with the declaration position of each of its
+// fields renamed by renames, keyed by position.
+func findRenamedFields(fset *token.FileSet, pkgs []*packages.Package, structName string, renames []fieldRename) (*types.TypeName, map[token.Position]fieldRename, error) {
+	pkgName, name := "", structName
+	if qualifier, unqualified, ok := strings.Cut(structName, "."); ok {
+		pkgName, name = qualifier, unqualified
+	}
+
+	var (
+		found *types.TypeName
+		// seen holds the declaration positions of the structs found, as test variants of a package declare their own
+		// objects for the same struct.
+		seen = make(map[token.Position]struct{})
+	)
+	for _, pkg := range pkgs {
+		if pkgName != "" && pkg.Name != pkgName {
+			continue
+		}
+
+		obj, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+		if !ok || !isRenamableStruct(obj) {
+			continue
+		}
+
+		seen[fset.Position(obj.Pos())] = struct{}{}
+		if found == nil {
+			found = obj
+		}
+	}
+
+	switch {
+	case len(seen) == 0:
+		return nil, nil, fmt.Errorf("struct %s not found in the module", structName)
+	case len(seen) > 1:
+		return nil, nil, fmt.Errorf("struct %s is ambiguous, qualify --struct with its package name", structName)
+	}
+
+	s := found.Type().Underlying().(*types.Struct)
+	existing := make(map[string]*types.Var, s.NumFields())
+	for i := 0; i < s.NumFields(); i++ {
+		existing[s.Field(i).Name()] = s.Field(i)
+	}
+
+	fields := make(map[token.Position]fieldRename, len(renames))
+	for _, r := range renames {
+		field, ok := existing[r.from]
+		if !ok || field.Embedded() {
+			return nil, nil, fmt.Errorf("struct %s has no field %s", structName, r.from)
+		}
+
+		if _, ok = existing[r.to]; ok {
+			return nil, nil, fmt.Errorf("struct %s already has a field %s", structName, r.to)
+		}
+		fields[fset.Position(field.Pos())] = r
+	}
+
+	return found, fields, nil
+}
+
+// isRenamableStruct reports whether obj declares a named struct type, rather than an alias.
+func isRenamableStruct(obj *types.TypeName) bool {
+	if obj.IsAlias() {
+		return false
+	}
+	_, ok := obj.Type().Underlying().(*types.Struct)
+	return ok
+}
+
+// structDirectives returns the go-sfgen directives of the module at root generating from structObj, along with the
+// constants they generate from the renamed fields, and the Go files they regenerate. Directives that fail to generate
+// are skipped with a warning.
+func structDirectives(ctx context.Context, wd, root string, structObj *types.TypeName, renames []fieldRename) ([]structDirective, map[string]struct{}, map[string]struct{}, error) {
+	files, err := directiveFiles(filepath.Join(root, "..."))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var (
+		directives []structDirective
+		consts     = make(map[string]struct{})
+		outputs    = make(map[string]struct{})
+	)
+	for _, path := range files {
+		fileDirs, err := sfgen.FileDirectives(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, d := range fileDirs {
+			result, err := generateDirective(ctx, wd, d)
+			if err != nil {
+				log.Printf("warning: skipping %s:%d: %v", d.Path, d.Line, err)
+				continue
+			}
+
+			var (
+				sd        = structDirective{Directive: d, consts: make(map[string]map[string][]string)}
+				generates bool
+			)
+			for _, r := range renames {
+				sd.consts[r.from] = fieldConsts(result, structObj, r.from)
+				for _, targetConsts := range sd.consts[r.from] {
+					for _, name := range targetConsts {
+						consts[name] = struct{}{}
+						generates = true
+					}
+				}
+			}
+
+			if !generates {
+				continue
+			}
+
+			directives = append(directives, sd)
+			for _, file := range result.Files {
+				if !file.CreateOnly { // files only written when absent keep their references, which are renamed
+					outputs[file.Path] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return directives, consts, outputs, nil
+}
+
+// fieldConsts returns the constants generated by result from the field of structObj with the given name, keyed by
+// target. Targets are identified by their output file and their index within it, which are the same when the
+// directive is regenerated.
+func fieldConsts(result *sfgen.Result, structObj *types.TypeName, field string) map[string][]string {
+	consts := make(map[string][]string)
+	for _, file := range result.Files {
+		for i, target := range file.Targets {
+			f := target.Options
+			if f.SourceStruct != structObj.Name() || (f.SourcePackage != "" && f.SourcePackage != structObj.Pkg().Name()) {
+				continue
+			}
+
+			key := fmt.Sprintf("%s#%d", file.Path, i)
+			for _, generated := range target.Fields {
+				if generated.Field == field {
+					consts[key] = append(consts[key], generated.Const)
+				}
+			}
+		}
+	}
+	return consts
+}
+
+// renameReferences returns the edits renaming the declarations of fields and references to them, keyed by the former
+// field name and then by file, along with the references to the constants in consts outside of the outputs, keyed by
+// file.
+func renameReferences(fset *token.FileSet, pkgs []*packages.Package, fields map[token.Position]fieldRename, consts, outputs map[string]struct{}) (map[string]map[string][]textEdit, map[string][]constReference) {
+	var (
+		fieldEdits = make(map[string]map[string][]textEdit)
+		constRefs  = make(map[string][]constReference)
+		// seen holds the identifiers already handled, as test variants of a package share files.
+		seen = make(map[token.Position]struct{})
+	)
+
+	visit := func(ident *ast.Ident, obj types.Object) {
+		if obj == nil {
+			return
+		}
+
+		pos := fset.Position(ident.Pos())
+		if _, ok := seen[pos]; ok {
+			return
+		}
+
+		if v, ok := obj.(*types.Var); ok && v.IsField() {
+			if r, ok := fields[fset.Position(v.Pos())]; ok {
+				seen[pos] = struct{}{}
+				if fieldEdits[r.from] == nil {
+					fieldEdits[r.from] = make(map[string][]textEdit)
+				}
+				fieldEdits[r.from][pos.Filename] = append(fieldEdits[r.from][pos.Filename], textEdit{offset: pos.Offset, old: r.from, new: r.to})
+			}
+			return
+		}
+
+		if c, ok := obj.(*types.Const); ok {
+			if _, ok := consts[c.Name()]; !ok {
+				return
+			}
+			if _, ok := outputs[fset.Position(c.Pos()).Filename]; !ok {
+				return // a constant of the same name declared elsewhere
+			}
+			if _, ok := outputs[pos.Filename]; ok {
+				return // the outputs are regenerated
+			}
+
+			seen[pos] = struct{}{}
+			constRefs[pos.Filename] = append(constRefs[pos.Filename], constReference{offset: pos.Offset, name: c.Name()})
+		}
+	}
+
+	for _, pkg := range pkgs {
+		for ident, obj := range pkg.TypesInfo.Defs {
+			visit(ident, obj)
+		}
+		for ident, obj := range pkg.TypesInfo.Uses {
+			visit(ident, obj)
+		}
+	}
+
+	return fieldEdits, constRefs
+}
+
+// applyFieldEdits writes the field edits to their files, and shifts the offsets of the constant references of those
+// files accordingly. The files are recorded in backup before they are written.
+func applyFieldEdits(backup fileBackup, fieldEdits map[string]map[string][]textEdit, constRefs map[string][]constReference) error {
+	byFile := make(map[string][]textEdit)
+	for _, files := range fieldEdits {
+		for path, edits := range files {
+			byFile[path] = append(byFile[path], edits...)
+		}
+	}
+
+	for path, edits := range byFile {
+		if err := editFile(backup, path, edits); err != nil {
+			return err
+		}
+
+		refs := constRefs[path]
+		for i, ref := range refs {
+			for _, e := range edits {
+				if e.offset < ref.offset {
+					refs[i].offset += len(e.new) - len(e.old)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applyConstEdits renames the constant references to the constants they were renamed to in newConsts. The files are
+// recorded in backup before they are written.
+func applyConstEdits(backup fileBackup, constRefs map[string][]constReference, newConsts map[string]string) error {
+	for path, refs := range constRefs {
+		var edits []textEdit
+		for _, ref := range refs {
+			if newName, ok := newConsts[ref.name]; ok {
+				edits = append(edits, textEdit{offset: ref.offset, old: ref.name, new: newName})
+			}
+		}
+
+		if len(edits) == 0 {
+			continue
+		}
+
+		if err := editFile(backup, path, edits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatEditedFiles formats the files edited by fieldEdits or constRefs, so renaming to a name of a different length
+// keeps the alignment of struct fields, tags, and comments as gofmt expects.
+func formatEditedFiles(fieldEdits map[string]map[string][]textEdit, constRefs map[string][]constReference) error {
+	paths := make(map[string]struct{})
+	for _, files := range fieldEdits {
+		for path := range files {
+			paths[path] = struct{}{}
+		}
+	}
+	for path := range constRefs {
+		paths[path] = struct{}{}
+	}
+
+	for path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := format.Source(content)
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", path, err)
+		}
+		if bytes.Equal(formatted, content) {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(path, formatted, info.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// editFile applies edits to the file at path, after checking each replaces the identifier it expects, and recording
+// the file in backup.
+func editFile(backup fileBackup, path string, edits []textEdit) error {
+	if err := backup.save(path); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].offset < edits[j].offset
+	})
+
+	var (
+		edited []byte
+		last   int
+	)
+	for _, e := range edits {
+		if e.offset < last || e.offset+len(e.old) > len(content) || string(content[e.offset:e.offset+len(e.old)]) != e.old {
+			return fmt.Errorf("failed to rename %s in %s: the file changed while renaming", e.old, path)
+		}
+
+		edited = append(edited, content[last:e.offset]...)
+		edited = append(edited, e.new...)
+		last = e.offset + len(e.old)
+	}
+	edited = append(edited, content[last:]...)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, edited, info.Mode())
+}
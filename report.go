@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/rad12000/go-sfgen/pkg/sfgen"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// Statuses of the output of a [reportEntry].
+const (
+	statusUpToDate = "up-to-date"
+	statusStale    = "stale"
+	statusMissing  = "missing"
+	statusError    = "error"
+)
+
+// reportEntry describes a struct generated from by a //go:generate directive.
+type reportEntry struct {
+	// Directive is the file:line of the directive, relative to the current directory.
+	Directive string `json:"directive"`
+	// Struct is the --struct, empty if the directive could not be parsed.
+	Struct string `json:"struct,omitempty"`
+	// Outputs are the files generated from the struct, relative to the current directory.
+	Outputs []string `json:"outputs,omitempty"`
+	// Fields is the number of constants generated from the fields of the struct.
+	Fields int    `json:"fields"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Removed are the identifiers of the existing Go output that regenerating it would remove, e.g. the constants of
+	// fields deleted from the struct.
+	Removed []string `json:"removed,omitempty"`
+	// References are the file:line:column positions of references to Removed throughout the module, which break once
+	// the struct is regenerated. They are only searched for with --usages.
+	References []string `json:"references,omitempty"`
+
+	// outPath, outDir, and outPkg are the path, directory, and package of the Go output of the struct.
+	outPath, outDir, outPkg string
+}
+
+// runReport implements the report command, printing every struct generated from by the go-sfgen directives of the
+// packages matching the patterns in args, along with whether their outputs are stale.
+func runReport(ctx context.Context, args []string) error {
+	var (
+		flagSet = flag.NewFlagSet("report", flag.ContinueOnError)
+		asJSON  = flagSet.Bool("json", false, "if true, the report is printed as JSON rather than as a table")
+		usages  = flagSet.Bool("usages", false, "if true, the module is searched for references to generated identifiers "+
+			"that regenerating would remove, as their fields no longer exist")
+	)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "Usage: go-sfgen report [--json] [--usages] [packages]\n\nPackages default to ./... Flags are:")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	patterns := flagSet.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	var entries []reportEntry
+	for _, pattern := range patterns {
+		files, err := directiveFiles(pattern)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			fileEntries, err := reportFile(ctx, wd, file)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, fileEntries...)
+		}
+	}
+
+	if *usages {
+		root, modPath, err := findModule(wd)
+		if err != nil {
+			return err
+		}
+
+		if err = findReferences(wd, root, modPath, entries); err != nil {
+			return err
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DIRECTIVE\tSTRUCT\tFIELDS\tSTATUS\tOUTPUTS\tREMOVED")
+	for _, e := range entries {
+		status := e.Status
+		if e.Error != "" {
+			status += ": " + e.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", e.Directive, e.Struct, e.Fields, status, strings.Join(e.Outputs, ", "),
+			strings.Join(e.Removed, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for i, e := range entries {
+		if len(e.References) == 0 {
+			continue
+		}
+		if i == 0 || len(entries[i-1].References) == 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("References broken by regenerating %s:\n", e.Struct)
+		for _, ref := range e.References {
+			fmt.Printf("\t%s\n", ref)
+		}
+	}
+	return nil
+}
+
+// directiveFiles returns the Go files of the directory pattern, which may end in /... to include every directory
+// below it. As with the go command, vendor and testdata directories, and those starting with . or _, are skipped.
+func directiveFiles(pattern string) ([]string, error) {
+	root, recursive := filepath.ToSlash(pattern), false
+	if root == "..." || strings.HasSuffix(root, "/...") {
+		root, recursive = strings.TrimSuffix(strings.TrimSuffix(root, "..."), "/"), true
+	}
+	if root == "" {
+		root = "."
+	}
+
+	var files []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != filepath.FromSlash(root) && (!recursive || name == "vendor" || name == "testdata" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", pattern, err)
+	}
+
+	return files, nil
+}
+
+// reportFile returns the entries of the go-sfgen directives of the Go file at path.
+func reportFile(ctx context.Context, wd, path string) ([]reportEntry, error) {
+	directives, err := sfgen.FileDirectives(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []reportEntry
+	for _, d := range directives {
+		entries = append(entries, reportDirective(ctx, wd, d)...)
+	}
+	return entries, nil
+}
+
+// generateDirective generates the directive d, without writing anything. As with go generate, packages are loaded
+// from the directory of its file, so the go command resolves them within the module holding it.
+func generateDirective(ctx context.Context, wd string, d sfgen.Directive) (*sfgen.Result, error) {
+	flagOptions, err := d.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.Chdir(filepath.Dir(d.Path)); err != nil {
+		return nil, err
+	}
+	defer os.Chdir(wd)
+
+	return sfgen.Generate(ctx, flagOptions)
+}
+
+// reportDirective returns the entries of the directive d.
+func reportDirective(ctx context.Context, wd string, d sfgen.Directive) []reportEntry {
+	directive := fmt.Sprintf("%s:%d", d.Path, d.Line)
+	if rel, err := filepath.Rel(wd, d.Path); err == nil {
+		directive = fmt.Sprintf("%s:%d", rel, d.Line)
+	}
+
+	result, err := generateDirective(ctx, wd, d)
+	if err != nil {
+		return []reportEntry{{Directive: directive, Status: statusError, Error: err.Error()}}
+	}
+
+	var (
+		statuses = make(map[string]string)
+		contents = make(map[string][]byte)
+	)
+	for _, file := range result.Files {
+		existing, err := os.ReadFile(file.Path)
+		contents[file.Path] = existing
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			statuses[file.Path] = statusMissing
+		case err != nil:
+			statuses[file.Path] = statusError
+		case !file.CreateOnly && !bytes.Equal(existing, file.Content): // create only files intentionally keep their contents
+			statuses[file.Path] = statusStale
+		default:
+			statuses[file.Path] = statusUpToDate
+		}
+	}
+
+	var entries []reportEntry
+	for _, file := range result.Files {
+		for _, target := range file.Targets {
+			outputs := []string{file.Path}
+			for _, e := range target.Options.Emitters {
+				outputs = append(outputs, e.Path)
+			}
+
+			entry := reportEntry{Directive: directive, Struct: target.Options.SourceStruct, Fields: len(target.Fields), Status: statusUpToDate,
+				outPath: file.Path, outDir: filepath.Dir(file.Path), outPkg: file.Package}
+			if target.Options.SourcePackage != "" {
+				entry.Struct = target.Options.SourcePackage + "." + entry.Struct
+			}
+
+			if existing := contents[file.Path]; statuses[file.Path] == statusStale {
+				if entry.Removed, err = file.RemovedIdentifiers(target, existing); err != nil {
+					entry.Status, entry.Error = statusError, err.Error()
+				}
+			}
+
+			for _, output := range outputs {
+				entry.Status = worseStatus(entry.Status, statuses[output])
+				if rel, err := filepath.Rel(wd, output); err == nil {
+					output = rel
+				}
+				entry.Outputs = append(entry.Outputs, output)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// worseStatus returns the status of a struct with outputs of status a and b.
+func worseStatus(a, b string) string {
+	rank := map[string]int{"": 0, statusUpToDate: 0, statusStale: 1, statusMissing: 2, statusError: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// findModule returns the root directory and module path of the module containing dir.
+func findModule(dir string) (root, path string, err error) {
+	for root = dir; ; root = filepath.Dir(root) {
+		content, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			scanner := bufio.NewScanner(bytes.NewReader(content))
+			for scanner.Scan() {
+				if fields := strings.Fields(scanner.Text()); len(fields) == 2 && fields[0] == "module" {
+					path, _ = strconv.Unquote(fields[1])
+					if path == "" {
+						path = fields[1]
+					}
+					return root, path, nil
+				}
+			}
+			return "", "", fmt.Errorf("no module path found in %s", filepath.Join(root, "go.mod"))
+		}
+
+		if filepath.Dir(root) == root {
+			return "", "", errors.New("--usages requires running within a module, no go.mod found")
+		}
+	}
+}
+
+// findReferences searches the Go files of the module at root, whose module path is modPath, for references to the
+// Removed identifiers of entries, and adds them to the References of their entry. References are found from the
+// syntax alone, either as selectors of an import of the output package, or as plain identifiers within the output
+// package itself, so local declarations shadowing a removed identifier may be reported as well.
+func findReferences(wd, root, modPath string, entries []reportEntry) error {
+	type removedIdentifier struct {
+		entry  *reportEntry
+		outPkg string
+	}
+
+	var (
+		// removed holds the removed identifiers of each output directory.
+		removed = make(map[string]map[string]removedIdentifier)
+		outputs = make(map[string]struct{})
+	)
+	for i := range entries {
+		e := &entries[i]
+		if len(e.Removed) == 0 {
+			continue
+		}
+
+		if removed[e.outDir] == nil {
+			removed[e.outDir] = make(map[string]removedIdentifier)
+		}
+		for _, name := range e.Removed {
+			removed[e.outDir][name] = removedIdentifier{entry: e, outPkg: e.outPkg}
+		}
+		outputs[e.outPath] = struct{}{}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	importPaths := make(map[string]string, len(removed))
+	for dir := range removed {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // outputs outside of the module cannot be imported by it
+		}
+		importPaths[strings.TrimSuffix(modPath+"/"+filepath.ToSlash(rel), "/.")] = dir
+	}
+
+	files, err := directiveFiles(filepath.Join(root, "..."))
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if _, ok := outputs[path]; ok {
+			continue // the generated file itself is replaced along with its declarations
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue // files that do not parse cannot be checked for references
+		}
+
+		var (
+			// local holds the identifiers that may be referenced unqualified, as the file belongs to, or dot imports,
+			// the output package.
+			local = make(map[string]removedIdentifier)
+			// qualified holds the identifiers that may be referenced through each import name.
+			qualified = make(map[string]map[string]removedIdentifier)
+		)
+		if idents, ok := removed[filepath.Dir(path)]; ok {
+			for name, r := range idents {
+				if r.outPkg == file.Name.Name {
+					local[name] = r
+				}
+			}
+		}
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			dir, ok := importPaths[importPath]
+			if !ok {
+				continue
+			}
+
+			for name, r := range removed[dir] {
+				importName := r.outPkg
+				if spec.Name != nil {
+					importName = spec.Name.Name
+				}
+
+				switch importName {
+				case "_":
+				case ".":
+					local[name] = r
+				default:
+					if qualified[importName] == nil {
+						qualified[importName] = make(map[string]removedIdentifier)
+					}
+					qualified[importName][name] = r
+				}
+			}
+		}
+
+		if len(local) == 0 && len(qualified) == 0 {
+			continue
+		}
+
+		report := func(ident *ast.Ident, r removedIdentifier) {
+			pos := fset.Position(ident.Pos())
+			if rel, err := filepath.Rel(wd, pos.Filename); err == nil {
+				pos.Filename = rel
+			}
+			r.entry.References = append(r.entry.References, fmt.Sprintf("%s: %s", pos, ident.Name))
+		}
+
+		// selectors holds the selected identifiers of selector expressions, which are not plain references.
+		selectors := make(map[*ast.Ident]struct{})
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				selectors[n.Sel] = struct{}{}
+				if x, ok := n.X.(*ast.Ident); ok {
+					if r, ok := qualified[x.Name][n.Sel.Name]; ok {
+						report(n.Sel, r)
+					}
+				}
+			case *ast.Ident:
+				if _, ok := selectors[n]; ok {
+					return true
+				}
+				if r, ok := local[n.Name]; ok {
+					report(n, r)
+				}
+			}
+			return true
+		})
+	}
+
+	return nil
+}